@@ -1,23 +1,18 @@
 package main
 
 import (
-	"encoding/json"
 	"os"
 	"project_masAde/internal/entities"
 	"project_masAde/internal/infrastructure"
 	"project_masAde/internal/interfaces/http"
 	"project_masAde/internal/repository"
 	"project_masAde/internal/usecases"
-	"strconv"
-	"strings"
-	"time"
 
 	"fmt"
 
 	"github.com/gin-gonic/gin"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/joho/godotenv"
-	"go.mau.fi/whatsmeow/types/events" // Import events
 )
 
 func main() {
@@ -39,6 +34,26 @@ func main() {
 	configRepo := repository.NewConfigRepository(pgClient.Pool)
 	tableManager := repository.NewTableManager(pgClient.Pool)
 	tenantManager := repository.NewTenantManager(pgClient.Pool)
+	contactRepo := repository.NewContactRepository(pgClient.Pool)
+	
+	// Bring older tenant schemas up to date with newly added tables
+	if err := tenantManager.UpgradeTenantSchemas(); err != nil {
+		fmt.Println("Warning: Failed to upgrade tenant schemas:", err)
+	}
+
+	// Index datasets imported before dataset search existed; searches only read the index
+	go func() {
+		schemas, err := tenantManager.TenantSchemas()
+		if err != nil {
+			fmt.Println("Warning: Failed to list tenant schemas:", err)
+			return
+		}
+		for _, schema := range append([]string{"public"}, schemas...) {
+			if err := tableManager.BackfillSearchIndex(schema); err != nil {
+				fmt.Printf("Warning: Failed to index datasets of %s: %v\n", schema, err)
+			}
+		}
+	}()
 	
 	// Initialize Usecases & Services
 	authUsecase := usecases.NewAuthUsecase(userRepo, tenantManager, os.Getenv("JWT_SECRET"))
@@ -52,117 +67,68 @@ func main() {
 	// See: AI Service project for AI integration
 	telegramClient := infrastructure.NewTelegramClient(os.Getenv("TELEGRAM_BOT_TOKEN"))
 	
-	messageService := usecases.NewMessageService(telegramClient, configRepo, tableManager)
-	if tc, ok := telegramClient.(*infrastructure.TelegramClient); ok {
-		messageService.TelegramClient = tc
-	}
-
-	dashboardUsecase := usecases.NewDashboardUsecase(configRepo, tableManager)
-	authMiddleware := http.NewMiddleware(os.Getenv("JWT_SECRET"))
-
-	sessionManager := infrastructure.NewSessionManager()
-	dynamicCalc := usecases.NewDynamicCalculator(tableManager)
-	
-	// User state for calculation flow (chatID -> pending calculation table)
-	calcPendingTable := make(map[int64]string)
-
 	// Initialize message rate limiter and usage tracking
 	rateLimiter := infrastructure.NewMessageRateLimiter(1.0, 5) // 1 msg/sec, burst 5
 	usageRepo := repository.NewUsageRepository(pgClient.Pool)
 
+	// Real-time dashboard events (messages, QR/pairing, bot status, quota)
+	eventHub := infrastructure.NewEventHub()
+
 	// Initialize WhatsApp Manager (per-user clients)
 	waManager := infrastructure.NewWhatsAppManager("devices")
+	waManager.Events = eventHub
 	
-	// Handler factory for per-user WhatsApp message routing
-	waManager.HandlerFactory = func(userID int, schemaName string) func(interface{}) {
-		return func(evt interface{}) {
-			switch v := evt.(type) {
-			case *events.Message:
-				client := waManager.GetClient(userID)
-				if client == nil {
-					return
-				}
-				
-				sender, content := client.ParseMessage(v)
-				
-				// Skip if message is from self or empty
-				if sender == "" || content == "" {
-					return
-				}
-				
-				// Ignore group messages
-				if v.Info.IsGroup {
-					return
-				}
-				
-				// DEBUG: Log who we're replying to
-				fmt.Printf("[WA] Message from: %s, content: '%s'\n", sender, content)
-				
-				// Track received message
-				usageRepo.IncrementReceived(userID)
-				
-				// Check quota before sending response
-				user, err := userRepo.GetByID(userID)
-				if err != nil || user == nil {
-					fmt.Printf("Error getting user %d: %v\n", userID, err)
-					return
-				}
-				
-				canSend, reason := usageRepo.CanSendMessage(userID, user.DailyLimit, user.MonthlyLimit)
-				if !canSend {
-					client.SendMessage(sender, "⚠️ "+reason+"\n\nYour message quota has been reached. Please contact support or wait for quota reset.")
-					return
-				}
-				
-				// Check rate limit
-				if !rateLimiter.Allow(userID) {
-					waitTime := rateLimiter.WaitTime(userID)
-					if waitTime > 0 {
-						time.Sleep(waitTime)
-					}
-				}
-				
-				// Handle regular text menu selection
-				if content == "1" || strings.Contains(strings.ToLower(content), "calculate") {
-					usageRepo.IncrementSent(userID)
-					client.SendMessage(sender, "📝 Enter product details:\n\nFormat: *30 tumbler 30kg*\n\n(quantity product weight)")
-					return
-				}
-				
-				// Dynamic calculation check - use datasets
-				if strings.Contains(content, "kg") || strings.Contains(content, "g") {
-					// Use dynamic calculator with default dataset
-					result := dynamicCalc.CalculateFromInput(schemaName, "products", content)
-					usageRepo.IncrementSent(userID)
-					client.SendMessage(sender, result+"\n\nReply with *1* to calculate again.")
-					return
-				}
-				
-				// Process message with tenant context
-				msg := entities.Message{
-					From:       strings.TrimSuffix(sender, "@s.whatsapp.net"),
-					Content:    content,
-					Platform:   "whatsapp",
-					SchemaName: schemaName, // Tenant-specific
-				}
-				
-				client.SendPresence(sender)
-				
-				// Create tenant-aware service copy
-				tenantService := *messageService
-				tenantService.WhatsAppClient = client
-				go tenantService.ProcessMessage(msg)
-			}
+	// Initialize TelegramBotManager for per-user bots
+	tgManager := infrastructure.NewTelegramBotManager(configRepo, tableManager)
+	tgManager.Events = eventHub
+	
+	// Transcript of every inbound/outbound message
+	conversationLogger := usecases.NewConversationLogger(repository.NewConversationLogRepository(pgClient.Pool))
+
+	// Outbound routing: replies go out through the client that received the message
+	router := usecases.NewChannelRouter(waManager, tgManager, usageRepo)
+	router.Logger = conversationLogger
+	router.Events = eventHub
+	router.Messenger = telegramClient
+	if tc, ok := telegramClient.(*infrastructure.TelegramClient); ok {
+		router.TelegramClient = tc
+	}
+	
+	// Per-chat conversation state (persisted so multi-step flows survive restarts)
+	conversations := usecases.NewConversationManager(repository.NewConversationRepository(pgClient.Pool))
+	
+	messageService := usecases.NewMessageService(router, configRepo, tableManager, conversations)
+	messageService.Contacts = contactRepo
+	intentRules := usecases.NewIntentRuleService(repository.NewIntentRuleRepository(pgClient.Pool))
+	messageService.Intents = intentRules
+	pricingRules := usecases.NewPricingRuleService(repository.NewPricingRuleRepository(pgClient.Pool))
+	messageService.Calculator.Pricing = pricingRules
+	messageService.Calculator.Currency = usecases.NewCurrencyService(repository.NewExchangeRateRepository(pgClient.Pool), configRepo)
+	messageService.Cart = usecases.NewCartService(repository.NewCartRepository(pgClient.Pool), messageService.Calculator)
+	orderService := usecases.NewOrderService(repository.NewOrderRepository(pgClient.Pool), contactRepo, router)
+	messageService.Orders = orderService
+
+	dashboardUsecase := usecases.NewDashboardUsecase(configRepo, tableManager)
+	dashboardUsecase.Events = eventHub
+	agentInbox := usecases.NewAgentInbox(conversations, router, conversationLogger)
+	campaignService := usecases.NewCampaignService(repository.NewCampaignRepository(pgClient.Pool), contactRepo, router, userRepo, usageRepo, rateLimiter)
+	campaignService.Start()
+	authMiddleware := http.NewMiddleware(os.Getenv("JWT_SECRET"))
+
+	// Single inbound pipeline shared by every channel
+	pipeline := usecases.NewInboundPipeline(messageService, userRepo, usageRepo, rateLimiter, conversationLogger, contactRepo)
+	handleInbound := func(msg entities.Message) {
+		if err := pipeline.Handle(msg); err != nil {
+			fmt.Printf("[%s] Error handling message from %s: %v\n", msg.Platform, msg.From, err)
 		}
 	}
+	waManager.MessageHandler = handleInbound
+	tgManager.MessageHandler = handleInbound
 
 	// Setup HTTP server
 	r := gin.Default()
 	
-	// Initialize TelegramBotManager for per-user bots
-	tgManager := infrastructure.NewTelegramBotManager(configRepo, tableManager)
-	
-	http.SetupRoutes(r, messageService, authUsecase, dashboardUsecase, waManager, tgManager, userRepo, usageRepo, authMiddleware)
+	http.SetupRoutes(r, pipeline, authUsecase, dashboardUsecase, conversationLogger, agentInbox, campaignService, usecases.NewContactService(contactRepo), intentRules, pricingRules, messageService.Calculator, orderService, messageService.Documents, messageService.Calculator.Currency, eventHub, waManager, tgManager, userRepo, usageRepo, authMiddleware)
 	go func() {
 		if err := r.Run("0.0.0.0:8080"); err != nil {
 			fmt.Printf("FAILED to start HTTP Server: %v\n", err)
@@ -171,7 +137,7 @@ func main() {
 	}()
 
 
-	// Telegram polling
+	// Telegram polling (platform bot)
 	var bot *tgbotapi.BotAPI
 	if tc, ok := telegramClient.(*infrastructure.TelegramClient); ok && tc.Bot != nil {
 		bot = tc.Bot
@@ -186,206 +152,11 @@ func main() {
 	updates := bot.GetUpdatesChan(u)
 
 	for update := range updates {
-		// Handle regular messages
-		if update.Message != nil {
-			chatID := update.Message.Chat.ID
-			
-			// Check for /start command
-			if update.Message.IsCommand() && update.Message.Command() == "start" {
-				msg := tgbotapi.NewMessage(chatID, "Welcome! 👋")
-				
-				// Fetch dynamic main_menu
-				menu, err := configRepo.GetMenu("public", "main_menu")
-				if err == nil {
-					msg.Text = menu.Title + "\n\nChoose an option:"
-					
-					// Parse Items
-					itemsBytes, _ := json.Marshal(menu.Items)
-					var items []repository.MenuItem
-					if err := json.Unmarshal(itemsBytes, &items); err == nil {
-						keyboard := http.GenerateDynamicKeyboard(items)
-						msg.ReplyMarkup = &keyboard
-						bot.Send(msg)
-						continue
-					}
-				}
-
-				// Fallback if no dynamic menu
-				msg.Text = "Welcome! 👋 What would you like to know about our products?\n\nChoose a category:"
-				keyboard := http.CreateCategoryKeyboard()
-				msg.ReplyMarkup = &keyboard
-				bot.Send(msg)
-				continue
-			}
-
-			// Regular text message
-			
-			// Check if user has pending calculation from dataset
-			if tableName, hasPending := calcPendingTable[chatID]; hasPending {
-				// Process calculation from dataset
-				result := dynamicCalc.CalculateFromInput("public", tableName, update.Message.Text)
-				
-				msgText := tgbotapi.NewMessage(chatID, result)
-				msgText.ParseMode = "Markdown"
-				followUpKeyboard := http.CreateFollowUpMenu()
-				msgText.ReplyMarkup = &followUpKeyboard
-				bot.Send(msgText)
-				
-				// Clear pending state
-				delete(calcPendingTable, chatID)
-				continue
-			}
-			
-			msg := entities.Message{
-				From:     strconv.FormatInt(update.Message.Chat.ID, 10),
-				Content:  update.Message.Text,
-				Platform: "telegram",
-			}
-			
-			// Legacy weight-based calculation removed - use menu buttons with calculate_from_table action instead
-			// Regular AI query with context
-			go messageService.ProcessMessageWithContext(msg)
-		}
-
-		// Handle button clicks (callback queries)
-		if update.CallbackQuery != nil {
-			callbackData := update.CallbackQuery.Data
-			chatID := update.CallbackQuery.Message.Chat.ID
-			messageID := update.CallbackQuery.Message.MessageID
-
-			// Get or create user session
-			session := sessionManager.GetOrCreateSession(chatID)
-
-			// Handle action callbacks first
-			if strings.HasPrefix(callbackData, "dyn:") {
-				// Format: dyn:Action:Payload
-				parts := strings.SplitN(callbackData, ":", 3)
-				if len(parts) >= 2 {
-					action := parts[1]
-					payload := ""
-					if len(parts) > 2 {
-						payload = parts[2]
-					}
-					
-					bot.Request(tgbotapi.NewCallback(update.CallbackQuery.ID, ""))
-
-					switch action {
-					case "view_table":
-						// Fetch table data
-						data, err := tableManager.GetTableData("public", payload)
-						if err != nil {
-							bot.Send(tgbotapi.NewMessage(chatID, "Error fetching data: "+err.Error()))
-							continue
-						}
-						
-						// Format Data (Simple List)
-						var sb strings.Builder
-						sb.WriteString(fmt.Sprintf("📊 *%s Data:*\n\n", payload))
-						limit := 10
-						if len(data) < limit { limit = len(data) }
-						for i := 0; i < limit; i++ {
-							row := data[i]
-							sb.WriteString("- ")
-							for k, v := range row {
-								if k != "id" {
-									sb.WriteString(fmt.Sprintf("%s: %v, ", k, v))
-								}
-							}
-							sb.WriteString("\n")
-						}
-						if len(data) > limit {
-							sb.WriteString(fmt.Sprintf("\n...and %d more rows.", len(data)-limit))
-						}
-						
-						msgText := tgbotapi.NewMessage(chatID, sb.String())
-						msgText.ParseMode = "Markdown"
-						bot.Send(msgText)
-						continue
-
-					case "reply":
-						bot.Send(tgbotapi.NewMessage(chatID, payload))
-						continue
-						
-					case "calculate_from_table":
-						// Store pending calculation state
-						calcPendingTable[chatID] = payload
-						msgText := tgbotapi.NewMessage(chatID, "📝 *Masukkan detail perhitungan:*\n\nFormat: `jumlah nama_produk`\nContoh: `30 tumbler` atau `50 gelas 5kg`")
-						msgText.ParseMode = "Markdown"
-						bot.Send(msgText)
-						continue
-					}
-				}
-			}
-
-			if strings.HasPrefix(callbackData, "action_") {
-				action := strings.TrimPrefix(callbackData, "action_")
-				bot.Request(tgbotapi.NewCallback(update.CallbackQuery.ID, ""))
-				
-				switch action {
-				case "menu":
-					// Back to main menu
-					editMsg := tgbotapi.NewEditMessageText(chatID, messageID, "👋 Choose a category:")
-					menuKeyboard := http.CreateCategoryKeyboard()
-					editMsg.ReplyMarkup = &menuKeyboard
-					bot.Send(editMsg)
-					continue
-				case "calculate":
-					// Ask for calculation input
-					editMsg := tgbotapi.NewEditMessageText(chatID, messageID, "📝 Enter product details:\n\nFormat: `30 tumbler 30kg`\n\n(quantity product weight)")
-					bot.Send(editMsg)
-					continue
-				case "ask":
-					// Ask another question
-					editMsg := tgbotapi.NewEditMessageText(chatID, messageID, "❓ Type your question about our products:")
-					bot.Send(editMsg)
-					continue
-				}
-			}
-
-			// Check if click is allowed (debouncing & concurrent request prevention)
-			if !session.IsAllowedClick() {
-				// Silently ignore spam clicks
-				bot.Request(tgbotapi.NewCallback(update.CallbackQuery.ID, "Please wait..."))
-				continue
-			}
-
-			// Mark as processing to prevent concurrent requests
-			session.StartProcessing()
-
-			// Answer callback query immediately (shows loading)
-			bot.Request(tgbotapi.NewCallback(update.CallbackQuery.ID, ""))
-
-			// Edit message to show loading state
-			editMsg := tgbotapi.NewEditMessageText(chatID, messageID, "⏳ Loading...")
-			bot.Send(editMsg)
-
-			var categoryFilter, typeFilter string
-
-			// Parse callback data
-			if strings.HasPrefix(callbackData, "cat_") {
-				categoryFilter = strings.TrimPrefix(callbackData, "cat_")
-			} else if strings.HasPrefix(callbackData, "type_") {
-				typeFilter = strings.TrimPrefix(callbackData, "type_")
-			}
-
-			// Legacy product table removed - show message to use datasets
-			filterDesc := categoryFilter + typeFilter
-			if filterDesc == "" {
-				filterDesc = "products"
-			}
-			
-			msg := entities.Message{
-				From:       strconv.FormatInt(chatID, 10),
-				Content:    "Show me " + filterDesc + " with pricing and details.",
-				Platform:   "telegram",
-				IsCallback: true,
-			}
-
-			// Process in goroutine with cleanup
-			go func() {
-				defer session.FinishProcessing()
-				messageService.ProcessMessageWithContext(msg)
-			}()
+		msg, ok := infrastructure.ParseTelegramUpdate(bot, update)
+		if !ok {
+			continue
 		}
+		msg.SchemaName = "public"
+		go handleInbound(msg)
 	}
-}
\ No newline at end of file
+}
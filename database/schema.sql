@@ -66,6 +66,9 @@ CREATE TABLE IF NOT EXISTS dynamic_tables (
     id SERIAL PRIMARY KEY,
     table_name VARCHAR(255) NOT NULL,   -- Actual table name (dt_xxx_timestamp)
     display_name VARCHAR(255) NOT NULL, -- User-friendly name
+    columns JSONB DEFAULT '[]',         -- Column schema: [{name, type, header}], type = text/integer/decimal/boolean/date
+    search_indexed BOOLEAN DEFAULT FALSE, -- Rows are in dataset_search (older datasets are indexed on first search)
+    roles JSONB DEFAULT '{}',           -- Column roles: {name, search_keys, unit_price, currency, unit, weight, min_order, image}
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     UNIQUE(table_name)
 );
@@ -95,18 +98,239 @@ CREATE TABLE IF NOT EXISTS message_usage (
 CREATE INDEX IF NOT EXISTS idx_usage_user_date ON message_usage(user_id, date);
 
 -- =====================================================
--- CONVERSATION LOGS (Optional - for analytics)
+-- CONVERSATION STATES (per-chat state machine)
+-- =====================================================
+CREATE TABLE IF NOT EXISTS conversation_states (
+    schema_name VARCHAR(128) NOT NULL,  -- Tenant schema
+    platform VARCHAR(20) NOT NULL,      -- 'telegram', 'whatsapp', 'web'
+    chat_id VARCHAR(100) NOT NULL,
+    state VARCHAR(50) NOT NULL,         -- e.g. 'awaiting_calc_input'
+    data JSONB DEFAULT '{}',            -- State payload (dataset, last input, ...)
+    expires_at TIMESTAMP,               -- NULL = no timeout
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (schema_name, platform, chat_id)
+);
+
+-- Index for expiry sweeps
+CREATE INDEX IF NOT EXISTS idx_conversation_states_expires ON conversation_states(expires_at);
+
+-- =====================================================
+-- CONVERSATION LOGS (every inbound/outbound message)
 -- =====================================================
 CREATE TABLE IF NOT EXISTS conversation_logs (
-    id SERIAL PRIMARY KEY,
-    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL, -- Tenant owner (NULL = platform bot)
+    schema_name VARCHAR(128) NOT NULL DEFAULT 'public',
     platform VARCHAR(20), -- 'telegram', 'whatsapp', 'web'
     chat_id VARCHAR(100),
-    message_type VARCHAR(20), -- 'incoming', 'outgoing'
+    direction VARCHAR(10), -- 'incoming', 'outgoing'
     content TEXT,
+    menu_action VARCHAR(100), -- Menu action answered by an outgoing message
+    latency_ms INTEGER, -- Outgoing only: time since the inbound message
+    search_vector tsvector GENERATED ALWAYS AS (to_tsvector('simple', COALESCE(content, ''))) STORED,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
 -- Index for log queries
 CREATE INDEX IF NOT EXISTS idx_logs_user ON conversation_logs(user_id);
 CREATE INDEX IF NOT EXISTS idx_logs_created ON conversation_logs(created_at);
+CREATE INDEX IF NOT EXISTS idx_logs_chat ON conversation_logs(schema_name, platform, chat_id, id);
+CREATE INDEX IF NOT EXISTS idx_logs_schema_created ON conversation_logs(schema_name, created_at);
+CREATE INDEX IF NOT EXISTS idx_logs_search ON conversation_logs USING GIN(search_vector);
+
+-- =====================================================
+-- CONTACTS (end customers; each tenant schema has its own copy)
+-- =====================================================
+CREATE TABLE IF NOT EXISTS contacts (
+    id SERIAL PRIMARY KEY,
+    platform VARCHAR(20) NOT NULL,
+    chat_id VARCHAR(100) NOT NULL,
+    user_id INTEGER, -- Bot owner that talks to this contact (0 = platform bot)
+    display_name VARCHAR(256), -- WhatsApp push name or Telegram username
+    language VARCHAR(16),
+    tags TEXT[] DEFAULT '{}',
+    opted_out BOOLEAN DEFAULT FALSE, -- Refuses broadcast campaigns
+    first_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (platform, chat_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_contacts_tags ON contacts USING GIN(tags);
+CREATE INDEX IF NOT EXISTS idx_contacts_last_seen ON contacts(last_seen_at);
+
+-- =====================================================
+-- INTENT RULES (keyword/regex triggers, per tenant)
+-- =====================================================
+CREATE TABLE IF NOT EXISTS intent_rules (
+    id SERIAL PRIMARY KEY,
+    pattern VARCHAR(512) NOT NULL,
+    match_type VARCHAR(16) NOT NULL DEFAULT 'contains', -- exact, contains, prefix, regex
+    priority INTEGER NOT NULL DEFAULT 0, -- Highest first; first match wins
+    language VARCHAR(16), -- NULL = any language
+    action VARCHAR(50) NOT NULL, -- greeting, menu, search, calculate, calculate_hint or a menu item action
+    payload TEXT,
+    enabled BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_intent_rules_priority ON intent_rules(priority DESC, id);
+
+-- =====================================================
+-- DATASET REVISIONS (change history of dynamic tables, per tenant)
+-- =====================================================
+CREATE TABLE IF NOT EXISTS dataset_revisions (
+    id SERIAL PRIMARY KEY,
+    table_name VARCHAR(128) NOT NULL,
+    kind VARCHAR(20) NOT NULL, -- import, reimport, row_update, row_delete, columns, rollback
+    summary TEXT,
+    author_id INTEGER, -- users.id; NULL for system changes
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_dataset_revisions_table ON dataset_revisions(table_name, id);
+
+-- Before/after image of each row a revision changed (rollback and diff source)
+CREATE TABLE IF NOT EXISTS dataset_row_changes (
+    id BIGSERIAL PRIMARY KEY,
+    revision_id INTEGER NOT NULL REFERENCES dataset_revisions(id) ON DELETE CASCADE,
+    row_id INTEGER NOT NULL,
+    before JSONB, -- NULL when the row was inserted
+    after JSONB   -- NULL when the row was deleted
+);
+
+CREATE INDEX IF NOT EXISTS idx_dataset_row_changes_revision ON dataset_row_changes(revision_id);
+
+-- =====================================================
+-- DATASET SEARCH INDEX (one document per dataset row, per tenant)
+-- =====================================================
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE TABLE IF NOT EXISTS dataset_search (
+    table_name VARCHAR(128) NOT NULL,
+    row_id INTEGER NOT NULL,
+    content TEXT NOT NULL, -- Every value of the row but id
+    tsv TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple', content)) STORED,
+    PRIMARY KEY (table_name, row_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_dataset_search_tsv ON dataset_search USING GIN (tsv);
+-- Trigram index for typo-tolerant matches
+CREATE INDEX IF NOT EXISTS idx_dataset_search_trgm ON dataset_search USING GIN (content gin_trgm_ops);
+
+-- =====================================================
+-- PRICING RULES (per tenant; applied by the calculator's quotes)
+-- =====================================================
+CREATE TABLE IF NOT EXISTS pricing_rules (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(128) NOT NULL,       -- Quote line label ("PPN", "Diskon grosir")
+    kind VARCHAR(16) NOT NULL,        -- tier, discount, moq, tax
+    table_name VARCHAR(128),          -- NULL = every dataset
+    product VARCHAR(256),             -- NULL = every product (else part of the product name)
+    min_qty NUMERIC NOT NULL DEFAULT 0,
+    max_qty NUMERIC,                  -- NULL = no upper bound
+    value NUMERIC NOT NULL,           -- Unit price, percent, amount or quantity depending on kind
+    value_type VARCHAR(10) NOT NULL DEFAULT 'percent', -- percent, fixed
+    priority INTEGER NOT NULL DEFAULT 0,
+    enabled BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- =====================================================
+-- CART ITEMS (per tenant; one cart per chat, re-priced when shown)
+-- =====================================================
+CREATE TABLE IF NOT EXISTS cart_items (
+    id SERIAL PRIMARY KEY,
+    platform VARCHAR(20) NOT NULL,
+    chat_id VARCHAR(100) NOT NULL,
+    table_name VARCHAR(128) NOT NULL, -- Dataset the product is priced from
+    product VARCHAR(256) NOT NULL,    -- As typed by the customer
+    quantity NUMERIC NOT NULL,
+    unit VARCHAR(16) NOT NULL DEFAULT '', -- Unit typed with the quantity ("lusin"); '' = the pricing unit
+    weight_grams NUMERIC NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_cart_items_chat ON cart_items(platform, chat_id, id);
+
+-- =====================================================
+-- ORDERS (per tenant; confirmed quotes and their lifecycle)
+-- =====================================================
+CREATE TABLE IF NOT EXISTS orders (
+    id SERIAL PRIMARY KEY,
+    number VARCHAR(32) UNIQUE,              -- ORD-YYMMDD-00001
+    platform VARCHAR(20) NOT NULL,
+    chat_id VARCHAR(100) NOT NULL,
+    user_id INTEGER,                        -- Bot owner that received the order
+    contact_name VARCHAR(255),
+    items JSONB NOT NULL DEFAULT '[]',      -- Priced lines, frozen at order time
+    discounts JSONB NOT NULL DEFAULT '[]',
+    taxes JSONB NOT NULL DEFAULT '[]',
+    currency VARCHAR(10) NOT NULL,
+    subtotal NUMERIC NOT NULL,
+    total NUMERIC NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'new', -- new, confirmed, paid, shipped, cancelled
+    status_note TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_orders_chat ON orders(platform, chat_id, id);
+CREATE INDEX IF NOT EXISTS idx_orders_status ON orders(status, created_at);
+
+CREATE TABLE IF NOT EXISTS order_status_history (
+    id SERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    status VARCHAR(16) NOT NULL,
+    note TEXT,                              -- Staff note sent to the customer
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_status_history_order ON order_status_history(order_id, id);
+
+-- =====================================================
+-- EXCHANGE RATES (per tenant; converts quotes to the display currency)
+-- =====================================================
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    id SERIAL PRIMARY KEY,
+    base_currency VARCHAR(3) NOT NULL,    -- ISO 4217 code, e.g. USD
+    quote_currency VARCHAR(3) NOT NULL,   -- ISO 4217 code, e.g. IDR
+    rate NUMERIC NOT NULL CHECK (rate > 0), -- 1 base = rate quote (also used inverted)
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (base_currency, quote_currency)
+);
+
+-- =====================================================
+-- BROADCAST CAMPAIGNS
+-- =====================================================
+CREATE TABLE IF NOT EXISTS campaigns (
+    id SERIAL PRIMARY KEY,
+    schema_name VARCHAR(128) NOT NULL,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    name VARCHAR(256) NOT NULL,
+    platform VARCHAR(20) NOT NULL,
+    audience_tags TEXT[] DEFAULT '{}', -- Empty = everyone seen on the platform
+    template TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'scheduled', -- scheduled, running, paused, completed, cancelled
+    status_reason TEXT,
+    scheduled_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_campaigns_schema ON campaigns(schema_name, created_at);
+CREATE INDEX IF NOT EXISTS idx_campaigns_status ON campaigns(status, scheduled_at);
+
+CREATE TABLE IF NOT EXISTS campaign_recipients (
+    id BIGSERIAL PRIMARY KEY,
+    campaign_id INTEGER NOT NULL REFERENCES campaigns(id) ON DELETE CASCADE,
+    platform VARCHAR(20) NOT NULL,
+    chat_id VARCHAR(100) NOT NULL,
+    user_id INTEGER, -- Bot owner the message is sent through (0 = platform bot)
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, sent, failed, skipped
+    error TEXT,
+    sent_at TIMESTAMP,
+    UNIQUE (campaign_id, platform, chat_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_campaign_recipients_status ON campaign_recipients(campaign_id, status);
@@ -1,16 +1,23 @@
 package entities
 
+import "time"
+
 type Message struct {
 	ID         string
 	From       string
 	To         string
 	Content    string
-	Platform   string // e.g., "whatsapp", "web", "telegram"
-	AIContext  string // Context from CSV/data for RAG
-	IsCallback bool   // Whether this is from a button callback
-	SchemaName string // Tenant schema for multi-tenancy
+	Platform   string    // e.g., "whatsapp", "web", "telegram"
+	AIContext  string    // Context from CSV/data for RAG
+	IsCallback bool      // Whether this is from a button callback
+	SchemaName string    // Tenant schema for multi-tenancy
+	UserID     int       // Tenant owner whose bot received the message (0 = platform bot)
+	ReceivedAt time.Time // When the inbound message entered the pipeline (for reply latency)
+	MenuAction string    // Menu action being answered, recorded in conversation logs
+	SenderName string    // WhatsApp push name or Telegram username, when known
+	Language   string    // Sender's client language code, when known (e.g. "id", "en")
 }
 
 type Response struct {
 	Content string
-}
\ No newline at end of file
+}
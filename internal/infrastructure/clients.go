@@ -85,6 +85,16 @@ func (t *TelegramClient) SendMessageWithMenu(to, content string, keyboard tgbota
 	return err
 }
 
+// SendDocument sends a file with a caption
+func (t *TelegramClient) SendDocument(to, filename string, data []byte, caption string) error {
+	chatID, _ := strconv.ParseInt(to, 10, 64)
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{Name: filename, Bytes: data})
+	doc.Caption = caption
+	doc.ParseMode = "Markdown"
+	_, err := t.Bot.Send(doc)
+	return err
+}
+
 func (t *TelegramClient) ReceiveMessage() (entities.Message, error) {
 	// Polling-based; handled in main loop
 	return entities.Message{}, nil
@@ -0,0 +1,98 @@
+package infrastructure
+
+import (
+	"sync"
+	"time"
+)
+
+// Event types pushed to dashboard clients
+const (
+	EventMessageReceived      = "message.received"
+	EventMessageSent          = "message.sent"
+	EventWhatsAppQR           = "whatsapp.qr"
+	EventWhatsAppQRExpired    = "whatsapp.qr_expired"
+	EventWhatsAppPaired       = "whatsapp.paired"
+	EventWhatsAppDisconnected = "whatsapp.disconnected"
+	EventTelegramStarted      = "telegram.started"
+	EventTelegramStopped      = "telegram.stopped"
+	EventQuotaThreshold       = "quota.threshold"
+	EventInboxUpdated         = "inbox.updated"
+	EventCampaignUpdated      = "campaign.updated"
+	EventDatasetImport        = "dataset.import"
+	EventOrderUpdated         = "order.updated"
+)
+
+// Event is a real-time notification scoped to one tenant schema
+type Event struct {
+	Type       string         `json:"type"`
+	SchemaName string         `json:"schema_name"`
+	UserID     int            `json:"user_id,omitempty"`
+	Data       map[string]any `json:"data,omitempty"`
+	Time       time.Time      `json:"time"`
+}
+
+// eventBufferSize is how many events a slow subscriber may fall behind before events are dropped
+const eventBufferSize = 64
+
+// EventHub fans out events to the subscribers of each tenant.
+// Publishing never blocks: a subscriber with a full buffer misses events.
+type EventHub struct {
+	subscribers map[string]map[chan Event]struct{} // schema -> subscriber channels
+	mu          sync.RWMutex
+}
+
+// NewEventHub creates an empty hub
+func NewEventHub() *EventHub {
+	return &EventHub{
+		subscribers: make(map[string]map[chan Event]struct{}),
+	}
+}
+
+// Subscribe registers a listener for a tenant's events.
+// Call the returned function to unsubscribe.
+func (h *EventHub) Subscribe(schemaName string) (<-chan Event, func()) {
+	ch := make(chan Event, eventBufferSize)
+
+	h.mu.Lock()
+	if h.subscribers[schemaName] == nil {
+		h.subscribers[schemaName] = make(map[chan Event]struct{})
+	}
+	h.subscribers[schemaName][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subscribers[schemaName], ch)
+			if len(h.subscribers[schemaName]) == 0 {
+				delete(h.subscribers, schemaName)
+			}
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Publish sends an event to every subscriber of its schema. Safe on a nil hub.
+func (h *EventHub) Publish(evt Event) {
+	if h == nil {
+		return
+	}
+	if evt.SchemaName == "" {
+		evt.SchemaName = "public"
+	}
+	if evt.Time.IsZero() {
+		evt.Time = time.Now()
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for ch := range h.subscribers[evt.SchemaName] {
+		select {
+		case ch <- evt:
+		default:
+			// Slow consumer, drop rather than stall the bot
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"project_masAde/internal/repository"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -123,13 +124,64 @@ func (p *PostgresClient) Migrate() error {
 			id SERIAL PRIMARY KEY,
 			table_name VARCHAR(255) UNIQUE NOT NULL,
 			display_name VARCHAR(255),
+			columns JSONB DEFAULT '[]', -- [{name, type, header}]
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 		);
+		ALTER TABLE dynamic_tables ADD COLUMN IF NOT EXISTS columns JSONB DEFAULT '[]';
+		ALTER TABLE dynamic_tables ADD COLUMN IF NOT EXISTS search_indexed BOOLEAN DEFAULT FALSE;
+		ALTER TABLE dynamic_tables ADD COLUMN IF NOT EXISTS roles JSONB DEFAULT '{}';
 	`)
 	if err != nil {
 		return fmt.Errorf("create dynamic_tables registry: %w", err)
 	}
 
+	// Conversation State Table (per-chat state machine, all tenants)
+	_, err = p.Pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS conversation_states (
+			schema_name VARCHAR(128) NOT NULL,
+			platform VARCHAR(20) NOT NULL,
+			chat_id VARCHAR(100) NOT NULL,
+			state VARCHAR(50) NOT NULL,
+			data JSONB DEFAULT '{}',
+			expires_at TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (schema_name, platform, chat_id)
+		);
+		CREATE INDEX IF NOT EXISTS idx_conversation_states_expires ON conversation_states(expires_at);
+	`)
+	if err != nil {
+		return fmt.Errorf("create conversation_states table: %w", err)
+	}
+
+	// Conversation Logs Table (every inbound/outbound message, all tenants)
+	_, err = p.Pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS conversation_logs (
+			id BIGSERIAL PRIMARY KEY,
+			user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+			platform VARCHAR(20),
+			chat_id VARCHAR(100),
+			content TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`)
+	if err != nil {
+		return fmt.Errorf("create conversation_logs table: %w", err)
+	}
+	// Columns missing from tables created by older database/schema.sql
+	_, _ = p.Pool.Exec(ctx, `ALTER TABLE conversation_logs ADD COLUMN IF NOT EXISTS schema_name VARCHAR(128) NOT NULL DEFAULT 'public'`)
+	_, _ = p.Pool.Exec(ctx, `ALTER TABLE conversation_logs ADD COLUMN IF NOT EXISTS direction VARCHAR(10)`)
+	_, _ = p.Pool.Exec(ctx, `ALTER TABLE conversation_logs ADD COLUMN IF NOT EXISTS menu_action VARCHAR(100)`)
+	_, _ = p.Pool.Exec(ctx, `ALTER TABLE conversation_logs ADD COLUMN IF NOT EXISTS latency_ms INTEGER`)
+	_, _ = p.Pool.Exec(ctx, `ALTER TABLE conversation_logs ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (to_tsvector('simple', COALESCE(content, ''))) STORED`)
+	_, err = p.Pool.Exec(ctx, `
+		CREATE INDEX IF NOT EXISTS idx_logs_chat ON conversation_logs(schema_name, platform, chat_id, id);
+		CREATE INDEX IF NOT EXISTS idx_logs_schema_created ON conversation_logs(schema_name, created_at);
+		CREATE INDEX IF NOT EXISTS idx_logs_search ON conversation_logs USING GIN(search_vector);
+	`)
+	if err != nil {
+		return fmt.Errorf("create conversation_logs indexes: %w", err)
+	}
+
 	// Seed Admin User (root/root) if not exists
 	// Password is bcrypt hash of "root"
 	// Cost: 10, Hash: $2a$10$tM.y.y... (generated for 'root')
@@ -173,6 +225,87 @@ func (p *PostgresClient) Migrate() error {
 		return fmt.Errorf("create menus table: %w", err)
 	}
 
+	// Contact registry for the platform bot (tenants get theirs in their own schema)
+	if _, err = p.Pool.Exec(ctx, repository.ContactsTableDDL("public")); err != nil {
+		return fmt.Errorf("create contacts table: %w", err)
+	}
+
+	// Keyword/regex intent rules of the platform bot
+	if _, err = p.Pool.Exec(ctx, repository.IntentRulesTableDDL("public")); err != nil {
+		return fmt.Errorf("create intent_rules table: %w", err)
+	}
+
+	// Change history of the platform's own datasets
+	if _, err = p.Pool.Exec(ctx, repository.DatasetRevisionsTableDDL("public")); err != nil {
+		return fmt.Errorf("create dataset_revisions tables: %w", err)
+	}
+
+	// Search index of the platform's datasets (tenant indexes use the same extension)
+	if _, err = p.Pool.Exec(ctx, "CREATE EXTENSION IF NOT EXISTS pg_trgm"); err != nil {
+		return fmt.Errorf("enable pg_trgm: %w", err)
+	}
+	if _, err = p.Pool.Exec(ctx, repository.DatasetSearchTableDDL("public")); err != nil {
+		return fmt.Errorf("create dataset_search table: %w", err)
+	}
+
+	// Pricing rules (tiers, discounts, MOQ, tax) of the platform bot
+	if _, err = p.Pool.Exec(ctx, repository.PricingRulesTableDDL("public")); err != nil {
+		return fmt.Errorf("create pricing_rules table: %w", err)
+	}
+
+	// Chat carts of the platform bot
+	if _, err = p.Pool.Exec(ctx, repository.CartItemsTableDDL("public")); err != nil {
+		return fmt.Errorf("create cart_items table: %w", err)
+	}
+
+	// Orders placed with the platform bot
+	if _, err = p.Pool.Exec(ctx, repository.OrdersTableDDL("public")); err != nil {
+		return fmt.Errorf("create orders table: %w", err)
+	}
+	if _, err = p.Pool.Exec(ctx, repository.OrderHistoryTableDDL("public")); err != nil {
+		return fmt.Errorf("create order_status_history table: %w", err)
+	}
+	if _, err = p.Pool.Exec(ctx, repository.ExchangeRatesTableDDL("public")); err != nil {
+		return fmt.Errorf("create exchange_rates table: %w", err)
+	}
+
+	// Broadcast Campaigns (all tenants; recipients are snapshotted from the tenant's contacts)
+	_, err = p.Pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS campaigns (
+			id SERIAL PRIMARY KEY,
+			schema_name VARCHAR(128) NOT NULL,
+			created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+			name VARCHAR(256) NOT NULL,
+			platform VARCHAR(20) NOT NULL,
+			audience_tags TEXT[] DEFAULT '{}', -- Empty = everyone seen on the platform
+			template TEXT NOT NULL,
+			status VARCHAR(20) NOT NULL DEFAULT 'scheduled',
+			status_reason TEXT,
+			scheduled_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			started_at TIMESTAMP,
+			completed_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_campaigns_schema ON campaigns(schema_name, created_at);
+		CREATE INDEX IF NOT EXISTS idx_campaigns_status ON campaigns(status, scheduled_at);
+
+		CREATE TABLE IF NOT EXISTS campaign_recipients (
+			id BIGSERIAL PRIMARY KEY,
+			campaign_id INTEGER NOT NULL REFERENCES campaigns(id) ON DELETE CASCADE,
+			platform VARCHAR(20) NOT NULL,
+			chat_id VARCHAR(100) NOT NULL,
+			user_id INTEGER, -- Bot owner the message is sent through (0 = platform bot)
+			status VARCHAR(20) NOT NULL DEFAULT 'pending',
+			error TEXT,
+			sent_at TIMESTAMP,
+			UNIQUE (campaign_id, platform, chat_id)
+		);
+		CREATE INDEX IF NOT EXISTS idx_campaign_recipients_status ON campaign_recipients(campaign_id, status);
+	`)
+	if err != nil {
+		return fmt.Errorf("create campaign tables: %w", err)
+	}
+
 	return nil
 }
 
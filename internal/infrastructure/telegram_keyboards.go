@@ -0,0 +1,117 @@
+package infrastructure
+
+import (
+	"project_masAde/internal/repository"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// GenerateDynamicKeyboardFromItems creates keyboard from menu items
+// Callback data format: "dyn:" + Action + ":" + Payload (max 64 chars)
+func GenerateDynamicKeyboardFromItems(items []repository.MenuItem) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+
+	// Create 2 buttons per row for better mobile layout
+	var row []tgbotapi.InlineKeyboardButton
+	for i, item := range items {
+		btnData := "dyn:" + item.Action + ":" + item.Payload
+		btn := tgbotapi.NewInlineKeyboardButtonData(item.Label, btnData)
+		row = append(row, btn)
+
+		if (i+1)%2 == 0 {
+			rows = append(rows, row)
+			row = []tgbotapi.InlineKeyboardButton{}
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// GenerateMenuKeyboard creates a menu keyboard; nested menus get a Back/Home row
+func GenerateMenuKeyboard(items []repository.MenuItem, nested bool) tgbotapi.InlineKeyboardMarkup {
+	keyboard := GenerateDynamicKeyboardFromItems(items)
+	if nested {
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("⬅️ Back", "dyn:"+repository.MenuActionBack+":"),
+			tgbotapi.NewInlineKeyboardButtonData("🏠 Home", "dyn:"+repository.MenuActionHome+":"),
+		))
+	}
+	return keyboard
+}
+
+// CreateCategoryKeyboard creates inline keyboard buttons for product categories
+func CreateCategoryKeyboard() tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("📦 General", "cat_general"),
+			tgbotapi.NewInlineKeyboardButtonData("👑 Luxury", "cat_luxury"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("📤 Export", "type_export"),
+			tgbotapi.NewInlineKeyboardButtonData("📥 Import", "type_import"),
+		),
+	)
+}
+
+// CreateFollowUpMenu creates menu buttons after a calculation result
+func CreateFollowUpMenu() tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🧮 Calculate Price", "action_calculate"),
+			tgbotapi.NewInlineKeyboardButtonData("❓ Ask More", "action_ask"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🛒 Tambah ke keranjang", "action_cart_add"),
+			tgbotapi.NewInlineKeyboardButtonData("✅ Pesan", "action_order_confirm"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("📄 Penawaran PDF", "action_quote_pdf"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🏠 Back to Menu", "action_menu"),
+		),
+	)
+}
+
+// CreateCartMenu creates the buttons shown below the cart
+func CreateCartMenu() tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🧮 Hitung lagi", "action_calculate"),
+			tgbotapi.NewInlineKeyboardButtonData("🗑️ Kosongkan", "action_cart_clear"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Konfirmasi order", "action_order_confirm"),
+			tgbotapi.NewInlineKeyboardButtonData("📄 PDF", "action_quote_pdf"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🏠 Back to Menu", "action_menu"),
+		),
+	)
+}
+
+// CreateSearchMenu creates the Menu/Search buttons shown with generic replies
+func CreateSearchMenu() tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("📋 Menu", "action_menu"),
+			tgbotapi.NewInlineKeyboardButtonData("🔍 Cari", "action_search"),
+		),
+	)
+}
+
+// CreateSearchPager creates the buttons under a search result page that has more results
+func CreateSearchPager() tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("➡️ Berikutnya", "action_search_next"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("📋 Menu", "action_menu"),
+			tgbotapi.NewInlineKeyboardButtonData("🔍 Cari", "action_search"),
+		),
+	)
+}
@@ -1,10 +1,10 @@
 package infrastructure
 
 import (
-	"context"
-	"encoding/json"
 	"fmt"
+	"project_masAde/internal/entities"
 	"project_masAde/internal/repository"
+	"strconv"
 	"sync"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
@@ -27,8 +27,11 @@ type TelegramBotManager struct {
 	configRepo *repository.ConfigRepository
 	tableManager *repository.TableManager
 	
-	// Handler factory for message processing
-	MessageHandler func(bot *tgbotapi.BotAPI, update tgbotapi.Update, userID int, schema string)
+	// MessageHandler receives every inbound message, tagged with the owning user and schema
+	MessageHandler func(msg entities.Message)
+	
+	// Events receives bot start/stop notifications (optional)
+	Events *EventHub
 }
 
 // NewTelegramBotManager creates a new manager for per-user Telegram bots
@@ -99,83 +102,105 @@ func (m *TelegramBotManager) startPolling(instance *TelegramBotInstance) {
 	updates := instance.Bot.GetUpdatesChan(u)
 	
 	fmt.Printf("[TG Bot] Started polling for user %d (@%s)\n", instance.UserID, instance.Bot.Self.UserName)
+	m.Events.Publish(Event{
+		Type:       EventTelegramStarted,
+		SchemaName: instance.Schema,
+		UserID:     instance.UserID,
+		Data:       map[string]any{"bot_name": instance.Bot.Self.UserName},
+	})
 	
 	for {
 		select {
 		case <-instance.StopChan:
 			fmt.Printf("[TG Bot] Stopped polling for user %d\n", instance.UserID)
+			instance.Bot.StopReceivingUpdates()
 			instance.mu.Lock()
 			instance.IsRunning = false
 			instance.mu.Unlock()
+			m.Events.Publish(Event{
+				Type:       EventTelegramStopped,
+				SchemaName: instance.Schema,
+				UserID:     instance.UserID,
+				Data:       map[string]any{"bot_name": instance.Bot.Self.UserName},
+			})
 			return
 		case update := <-updates:
-			if m.MessageHandler != nil {
-				go m.MessageHandler(instance.Bot, update, instance.UserID, instance.Schema)
-			} else {
-				// Default simple handler
-				m.defaultHandler(instance, update)
+			msg, ok := ParseTelegramUpdate(instance.Bot, update)
+			if !ok {
+				continue
+			}
+			msg.UserID = instance.UserID
+			msg.SchemaName = instance.Schema
+			
+			if m.MessageHandler == nil {
+				fmt.Printf("[TG Bot] No message handler set, dropping update for user %d\n", instance.UserID)
+				continue
 			}
+			go m.MessageHandler(msg)
 		}
 	}
 }
 
-// defaultHandler handles messages when no custom handler is set
-func (m *TelegramBotManager) defaultHandler(instance *TelegramBotInstance, update tgbotapi.Update) {
-	if update.Message == nil {
-		return
-	}
-	
-	chatID := update.Message.Chat.ID
-	
-	// Handle /start command
-	if update.Message.IsCommand() && update.Message.Command() == "start" {
-		msg := tgbotapi.NewMessage(chatID, "Welcome! 👋")
-		
-		// Try to get dynamic menu
-		if m.configRepo != nil {
-			menu, err := m.configRepo.GetMenu(instance.Schema, "main_menu")
-			if err == nil {
-				msg.Text = menu.Title + "\n\nChoose an option:"
-				
-				// Build keyboard
-				itemsBytes, _ := json.Marshal(menu.Items)
-				var items []repository.MenuItem
-				if err := json.Unmarshal(itemsBytes, &items); err == nil {
-					keyboard := GenerateDynamicKeyboardFromItems(items)
-					msg.ReplyMarkup = &keyboard
-				}
+// ParseTelegramUpdate converts a Telegram update into a platform-agnostic message.
+// Callback queries are acknowledged here so the client stops showing a spinner.
+// Returns false for updates the pipeline does not handle, including group
+// chats: carts and orders belong to a chat, so members would share them.
+func ParseTelegramUpdate(bot *tgbotapi.BotAPI, update tgbotapi.Update) (entities.Message, bool) {
+	if update.Message != nil {
+		if update.Message.Chat == nil || !update.Message.Chat.IsPrivate() {
+			return entities.Message{}, false
+		}
+		content := update.Message.Text
+		if update.Message.IsCommand() {
+			content = "/" + update.Message.Command()
+			if args := update.Message.CommandArguments(); args != "" {
+				content += " " + args
 			}
 		}
-		
-		instance.Bot.Send(msg)
-		return
+		if content == "" {
+			return entities.Message{}, false
+		}
+		name, lang := telegramSender(update.Message.From)
+		return entities.Message{
+			ID:         strconv.Itoa(update.Message.MessageID),
+			From:       strconv.FormatInt(update.Message.Chat.ID, 10),
+			Content:    content,
+			Platform:   "telegram",
+			SenderName: name,
+			Language:   lang,
+		}, true
 	}
-	
-	// Echo for testing
-	reply := tgbotapi.NewMessage(chatID, "Bot connected! Message received: "+update.Message.Text)
-	instance.Bot.Send(reply)
-}
 
-// GenerateDynamicKeyboardFromItems creates keyboard from menu items
-func GenerateDynamicKeyboardFromItems(items []repository.MenuItem) tgbotapi.InlineKeyboardMarkup {
-	var rows [][]tgbotapi.InlineKeyboardButton
-	var row []tgbotapi.InlineKeyboardButton
-	
-	for i, item := range items {
-		btnData := "dyn:" + item.Action + ":" + item.Payload
-		btn := tgbotapi.NewInlineKeyboardButtonData(item.Label, btnData)
-		row = append(row, btn)
-		
-		if (i+1)%2 == 0 {
-			rows = append(rows, row)
-			row = []tgbotapi.InlineKeyboardButton{}
+	if update.CallbackQuery != nil && update.CallbackQuery.Message != nil {
+		bot.Request(tgbotapi.NewCallback(update.CallbackQuery.ID, ""))
+		if update.CallbackQuery.Message.Chat == nil || !update.CallbackQuery.Message.Chat.IsPrivate() {
+			return entities.Message{}, false
 		}
+		name, lang := telegramSender(update.CallbackQuery.From)
+		return entities.Message{
+			ID:         update.CallbackQuery.ID,
+			From:       strconv.FormatInt(update.CallbackQuery.Message.Chat.ID, 10),
+			Content:    update.CallbackQuery.Data,
+			Platform:   "telegram",
+			IsCallback: true,
+			SenderName: name,
+			Language:   lang,
+		}, true
 	}
-	if len(row) > 0 {
-		rows = append(rows, row)
+
+	return entities.Message{}, false
+}
+
+// telegramSender returns the sender's username (or first name) and language code
+func telegramSender(from *tgbotapi.User) (string, string) {
+	if from == nil {
+		return "", ""
 	}
-	
-	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+	name := from.UserName
+	if name == "" {
+		name = from.FirstName
+	}
+	return name, from.LanguageCode
 }
 
 // DisconnectBot stops a user's bot
@@ -227,13 +252,36 @@ func (m *TelegramBotManager) SendMessage(userID int, chatID int64, text string)
 	return err
 }
 
-// HandleCallbackQuery processes callback queries for per-user bots
-func (m *TelegramBotManager) HandleCallbackQuery(instance *TelegramBotInstance, callback *tgbotapi.CallbackQuery, ctx context.Context) {
-	// Acknowledge callback
-	instance.Bot.Request(tgbotapi.NewCallback(callback.ID, ""))
+// SendMessageWithMenu sends a message with an inline keyboard via a user's bot
+func (m *TelegramBotManager) SendMessageWithMenu(userID int, chatID int64, text string, keyboard tgbotapi.InlineKeyboardMarkup) error {
+	m.mu.RLock()
+	instance, ok := m.bots[userID]
+	m.mu.RUnlock()
 	
-	// Handle callback data (can be extended)
-	chatID := callback.Message.Chat.ID
-	reply := tgbotapi.NewMessage(chatID, "Processing: "+callback.Data)
-	instance.Bot.Send(reply)
+	if !ok || !instance.IsRunning {
+		return fmt.Errorf("bot not connected for user %d", userID)
+	}
+	
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = keyboard
+	_, err := instance.Bot.Send(msg)
+	return err
+}
+
+// SendDocument sends a file via a user's bot
+func (m *TelegramBotManager) SendDocument(userID int, chatID int64, filename string, data []byte, caption string) error {
+	m.mu.RLock()
+	instance, ok := m.bots[userID]
+	m.mu.RUnlock()
+
+	if !ok || !instance.IsRunning {
+		return fmt.Errorf("bot not connected for user %d", userID)
+	}
+
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{Name: filename, Bytes: data})
+	doc.Caption = caption
+	doc.ParseMode = "Markdown"
+	_, err := instance.Bot.Send(doc)
+	return err
 }
@@ -3,6 +3,7 @@ package infrastructure
 import (
 	"context"
 	"fmt"
+	"project_masAde/internal/entities"
 	"sync"
 
 	"go.mau.fi/whatsmeow"
@@ -11,6 +12,7 @@ import (
 	"go.mau.fi/whatsmeow/types"
 	"go.mau.fi/whatsmeow/types/events"
 	waLog "go.mau.fi/whatsmeow/util/log"
+	"google.golang.org/protobuf/proto"
 
 	_ "modernc.org/sqlite" // Pure Go SQLite driver
 )
@@ -21,6 +23,7 @@ type WhatsAppClient struct {
 	
 	UserID     int    // Owner user ID for multi-tenancy
 	SchemaName string // Tenant schema for data isolation
+	Events     *EventHub // Optional: QR/pairing notifications for the dashboard
 	
 	qrCode      string
 	qrLock      sync.RWMutex
@@ -77,21 +80,7 @@ func (w *WhatsAppClient) Connect() error {
 		}
 		
 		// Wait for QR code in a goroutine
-		go func() {
-			for evt := range qrChan {
-				if evt.Event == "code" {
-					// Update QR code safely
-					w.qrLock.Lock()
-					w.qrCode = evt.Code
-					w.qrLock.Unlock()
-					
-					// Print QR code to ISO standard terminal output (fallback)
-					fmt.Println("QR Code:", evt.Code)
-				} else {
-					fmt.Println("Login event:", evt.Event)
-				}
-			}
-		}()
+		go w.watchQR(qrChan)
 	} else {
 		// Already logged in
 		err := w.Client.Connect()
@@ -165,19 +154,48 @@ func (w *WhatsAppClient) Logout() error {
 	}
 
 	// Listen for new QR in background
-	go func() {
-		for evt := range qrChan {
-			if evt.Event == "code" {
-				w.qrLock.Lock()
-				w.qrCode = evt.Code
-				w.qrLock.Unlock()
-				fmt.Println("New QR Code Generated")
-			}
-		}
-	}()
+	go w.watchQR(qrChan)
 
 	return nil
 }
+
+// watchQR stores each new QR code and pushes login progress to the dashboard
+func (w *WhatsAppClient) watchQR(qrChan <-chan whatsmeow.QRChannelItem) {
+	for evt := range qrChan {
+		switch evt.Event {
+		case "code":
+			// Update QR code safely
+			w.qrLock.Lock()
+			w.qrCode = evt.Code
+			w.qrLock.Unlock()
+
+			// Print QR code to ISO standard terminal output (fallback)
+			fmt.Println("QR Code:", evt.Code)
+			w.publish(EventWhatsAppQR, map[string]any{"code": evt.Code})
+		case "success":
+			w.qrLock.Lock()
+			w.qrCode = ""
+			w.qrLock.Unlock()
+			fmt.Println("Login event:", evt.Event)
+			w.publish(EventWhatsAppPaired, map[string]any{"phone": w.GetPhoneNumber()})
+		case "timeout":
+			fmt.Println("Login event:", evt.Event)
+			w.publish(EventWhatsAppQRExpired, nil)
+		default:
+			fmt.Println("Login event:", evt.Event)
+		}
+	}
+}
+
+// publish sends a dashboard event for this client's tenant
+func (w *WhatsAppClient) publish(eventType string, data map[string]any) {
+	w.Events.Publish(Event{
+		Type:       eventType,
+		SchemaName: w.SchemaName,
+		UserID:     w.UserID,
+		Data:       data,
+	})
+}
 func (w *WhatsAppClient) Disconnect() {
 	w.Client.Disconnect()
 }
@@ -202,6 +220,34 @@ func (w *WhatsAppClient) SendMessage(to string, content string) error {
 	return err
 }
 
+// SendDocument uploads a file and sends it as a document message
+func (w *WhatsAppClient) SendDocument(to, filename, mimetype string, data []byte, caption string) error {
+	jid, err := types.ParseJID(to + "@s.whatsapp.net")
+	if err != nil {
+		return fmt.Errorf("invalid number format: %v", err)
+	}
+
+	uploaded, err := w.Client.Upload(context.Background(), data, whatsmeow.MediaDocument)
+	if err != nil {
+		return fmt.Errorf("upload document: %w", err)
+	}
+	_, err = w.Client.SendMessage(context.Background(), jid, &waProto.Message{
+		DocumentMessage: &waProto.DocumentMessage{
+			URL:           proto.String(uploaded.URL),
+			DirectPath:    proto.String(uploaded.DirectPath),
+			MediaKey:      uploaded.MediaKey,
+			FileEncSHA256: uploaded.FileEncSHA256,
+			FileSHA256:    uploaded.FileSHA256,
+			FileLength:    proto.Uint64(uploaded.FileLength),
+			Mimetype:      proto.String(mimetype),
+			FileName:      proto.String(filename),
+			Title:         proto.String(filename),
+			Caption:       proto.String(caption),
+		},
+	})
+	return err
+}
+
 // Helper to broadcast presence/typing status
 func (w *WhatsAppClient) SendPresence(to string) {
 	jid, _ := types.ParseJID(to + "@s.whatsapp.net")
@@ -232,3 +278,25 @@ func (w *WhatsAppClient) ParseMessage(evt *events.Message) (string, string) {
 	return replyTo, content
 }
 
+// ToMessage converts a whatsmeow message event into a platform-agnostic message
+// Returns false for messages the pipeline should ignore (own, empty or group messages)
+func (w *WhatsAppClient) ToMessage(evt *events.Message) (entities.Message, bool) {
+	if evt.Info.IsGroup {
+		return entities.Message{}, false
+	}
+
+	sender, content := w.ParseMessage(evt)
+	if sender == "" || content == "" {
+		return entities.Message{}, false
+	}
+
+	return entities.Message{
+		ID:         evt.Info.ID,
+		From:       sender,
+		Content:    content,
+		Platform:   "whatsapp",
+		UserID:     w.UserID,
+		SchemaName: w.SchemaName,
+		SenderName: evt.Info.PushName,
+	}, true
+}
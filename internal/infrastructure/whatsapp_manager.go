@@ -3,7 +3,10 @@ package infrastructure
 import (
 	"fmt"
 	"os"
+	"project_masAde/internal/entities"
 	"sync"
+
+	"go.mau.fi/whatsmeow/types/events"
 )
 
 // WhatsAppManager manages per-user WhatsApp clients
@@ -12,8 +15,14 @@ type WhatsAppManager struct {
 	mu       sync.RWMutex
 	baseDir  string
 	
-	// Callback for registering message handlers per client
+	// Callback for registering raw whatsmeow event handlers per client
 	HandlerFactory func(userID int, schemaName string) func(interface{})
+	
+	// MessageHandler receives every inbound chat message as a platform-agnostic message
+	MessageHandler func(msg entities.Message)
+	
+	// Events receives pairing/connection notifications (optional)
+	Events *EventHub
 }
 
 // NewWhatsAppManager creates a new manager for per-user WhatsApp clients
@@ -52,6 +61,7 @@ func (m *WhatsAppManager) GetOrCreateClient(userID int, schemaName string) (*Wha
 	if err != nil {
 		return nil, fmt.Errorf("failed to create WhatsApp client for user %d: %w", userID, err)
 	}
+	client.Events = m.Events
 	
 	// Register handler if factory is set
 	if m.HandlerFactory != nil {
@@ -59,6 +69,33 @@ func (m *WhatsAppManager) GetOrCreateClient(userID int, schemaName string) (*Wha
 		client.AddHandler(handler)
 	}
 	
+	// Report pairing and connection changes to the dashboard
+	client.AddHandler(func(evt interface{}) {
+		switch v := evt.(type) {
+		case *events.PairSuccess:
+			client.publish(EventWhatsAppPaired, map[string]any{"phone": v.ID.User})
+		case *events.LoggedOut:
+			client.publish(EventWhatsAppDisconnected, map[string]any{"reason": "logged_out"})
+		case *events.Disconnected:
+			client.publish(EventWhatsAppDisconnected, map[string]any{"reason": "disconnected"})
+		}
+	})
+	
+	// Feed chat messages into the inbound pipeline
+	client.AddHandler(func(evt interface{}) {
+		v, ok := evt.(*events.Message)
+		if !ok || m.MessageHandler == nil {
+			return
+		}
+		msg, ok := client.ToMessage(v)
+		if !ok {
+			return
+		}
+		fmt.Printf("[WA] Message from: %s, content: '%s'\n", msg.From, msg.Content)
+		client.SendPresence(msg.From)
+		go m.MessageHandler(msg)
+	})
+	
 	m.clients[userID] = client
 	return client, nil
 }
@@ -13,16 +13,16 @@ import (
 )
 
 type Handler struct {
-	messageService   *usecases.MessageService
+	pipeline         *usecases.InboundPipeline
 	dashboardUsecase *usecases.DashboardUsecase
 	waManager        *infrastructure.WhatsAppManager
 	usageRepo        *repository.UsageRepository
 	userRepo         *repository.UserRepository
 }
 
-func NewHandler(service *usecases.MessageService, dashboard *usecases.DashboardUsecase, waManager *infrastructure.WhatsAppManager, usageRepo *repository.UsageRepository, userRepo *repository.UserRepository) *Handler {
+func NewHandler(pipeline *usecases.InboundPipeline, dashboard *usecases.DashboardUsecase, waManager *infrastructure.WhatsAppManager, usageRepo *repository.UsageRepository, userRepo *repository.UserRepository) *Handler {
 	return &Handler{
-		messageService:   service,
+		pipeline:         pipeline,
 		dashboardUsecase: dashboard,
 		waManager:        waManager,
 		usageRepo:        usageRepo,
@@ -30,14 +30,26 @@ func NewHandler(service *usecases.MessageService, dashboard *usecases.DashboardU
 	}
 }
 
-func SetupRoutes(r *gin.Engine, service *usecases.MessageService, auth *usecases.AuthUsecase, dashboard *usecases.DashboardUsecase, waManager *infrastructure.WhatsAppManager, tgManager *infrastructure.TelegramBotManager, userRepo *repository.UserRepository, usageRepo *repository.UsageRepository, middleware *Middleware) {
-	h := NewHandler(service, dashboard, waManager, usageRepo, userRepo)
+func SetupRoutes(r *gin.Engine, pipeline *usecases.InboundPipeline, auth *usecases.AuthUsecase, dashboard *usecases.DashboardUsecase, conversationLogger *usecases.ConversationLogger, inbox *usecases.AgentInbox, campaigns *usecases.CampaignService, contacts *usecases.ContactService, intents *usecases.IntentRuleService, pricing *usecases.PricingRuleService, calculator *usecases.DynamicCalculator, orders *usecases.OrderService, documents *usecases.DocumentService, currency *usecases.CurrencyService, events *infrastructure.EventHub, waManager *infrastructure.WhatsAppManager, tgManager *infrastructure.TelegramBotManager, userRepo *repository.UserRepository, usageRepo *repository.UsageRepository, middleware *Middleware) {
+	h := NewHandler(pipeline, dashboard, waManager, usageRepo, userRepo)
 	adminHandler := NewAdminHandler(userRepo, waManager)
 	telegramHandler := NewTelegramHandler(tgManager, userRepo)
+	conversationHandler := NewConversationHandler(conversationLogger)
+	inboxHandler := NewInboxHandler(inbox)
+	eventsHandler := NewEventsHandler(events)
+	campaignHandler := NewCampaignHandler(campaigns)
+	contactHandler := NewContactHandler(contacts)
+	intentHandler := NewIntentHandler(intents)
+	pricingHandler := NewPricingHandler(pricing, calculator, documents)
+	orderHandler := NewOrderHandler(orders, documents)
+	currencyHandler := NewCurrencyHandler(currency)
 	
 	// Apply Security Middleware
 	r.Use(SecurityHeaders())
-	r.Use(RequestSizeLimiter(10 << 20)) // 10MB max request size
+	r.Use(RequestSizeLimiter(10<<20, map[string]int64{ // 10MB max request size, except dataset uploads
+		"/api/tables/import":         MaxImportSize,
+		"/api/tables/import/preview": MaxImportSize,
+	}))
 	r.Use(middleware.CORSMiddleware())
 	
 	// Public Routes
@@ -85,12 +97,16 @@ func SetupRoutes(r *gin.Engine, service *usecases.MessageService, auth *usecases
 		})
 	}
 	
+	// Real-time event stream (long-lived, so outside the per-user rate limit)
+	r.GET("/api/events", middleware.StreamAuth(), eventsHandler.Stream)
+	
 	// Protected Dashboard Routes
 	api := r.Group("/api")
 	api.Use(middleware.AuthRequired())
 	api.Use(middleware.RateLimitPerUser(5, 10))
 	{
 		api.GET("/dashboard/stats", h.GetUserStats)
+		api.POST("/events/token", middleware.IssueStreamToken)
 		
 		// Config Routes
 		api.GET("/config", h.GetAllConfigs)
@@ -108,11 +124,26 @@ func SetupRoutes(r *gin.Engine, service *usecases.MessageService, auth *usecases
 		
 		// Dynamic Table Routes
 		api.GET("/tables", h.ListTables)
+		api.GET("/tables/search", h.SearchTables)
 		api.GET("/tables/:name/data", h.GetTableData)
 		api.POST("/tables/import", h.ImportTable)
+		api.POST("/tables/import/preview", h.PreviewImport)
+		api.GET("/tables/import/jobs", h.ListImportJobs)
+		api.GET("/tables/import/jobs/:id", h.GetImportJob)
+		api.POST("/tables/:name/reimport", h.ReimportTable)
+		api.GET("/tables/:name/export", h.ExportTable)
 		api.DELETE("/tables/:name", h.DeleteTable)
 		api.PUT("/tables/:name/row", h.UpdateRow)
 		api.DELETE("/tables/:name/row", h.DeleteRow)
+		api.GET("/tables/:name/columns", h.GetColumns)
+		api.POST("/tables/:name/columns", h.AddColumn)
+		api.PUT("/tables/:name/columns/:column", h.UpdateColumn)
+		api.DELETE("/tables/:name/columns/:column", h.DropColumn)
+		api.GET("/tables/:name/roles", h.GetRoles)
+		api.PUT("/tables/:name/roles", h.SetRoles)
+		api.GET("/tables/:name/revisions", h.ListRevisions)
+		api.GET("/tables/:name/revisions/diff", h.DiffRevisions)
+		api.POST("/tables/:name/revisions/:id/rollback", h.RollbackTable)
 		
 		// WhatsApp Management Routes - DISABLED (using Telegram)
 		// api.GET("/whatsapp/qr", h.GetUserQRCode)
@@ -122,6 +153,30 @@ func SetupRoutes(r *gin.Engine, service *usecases.MessageService, auth *usecases
 		
 		// Telegram Management Routes (per-user bots)
 		telegramHandler.RegisterRoutes(api)
+
+		// Conversation transcripts (support staff)
+		conversationHandler.RegisterRoutes(api)
+
+		// Live inbox: human agent takeover
+		inboxHandler.RegisterRoutes(api)
+
+		// Broadcast campaigns
+		campaignHandler.RegisterRoutes(api)
+
+		// Customer contacts
+		contactHandler.RegisterRoutes(api)
+
+		// Keyword/regex intent rules
+		intentHandler.RegisterRoutes(api)
+
+		// Pricing rules (tiers, discounts, MOQ, tax)
+		pricingHandler.RegisterRoutes(api)
+
+		// Orders placed in chat
+		orderHandler.RegisterRoutes(api)
+
+		// Exchange rates and display currency
+		currencyHandler.RegisterRoutes(api)
 	}
 	
 	// Admin-only Routes
@@ -303,6 +358,6 @@ func (h *Handler) HandleWebMessage(c *gin.Context) {
 		Platform: "web",
 	}
 
-	go h.messageService.ProcessMessage(msg)
+	go h.pipeline.Handle(msg)
 	c.JSON(200, gin.H{"status": "received"})
 }
\ No newline at end of file
@@ -0,0 +1,162 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"project_masAde/internal/usecases"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CampaignHandler manages broadcast campaigns
+type CampaignHandler struct {
+	campaigns *usecases.CampaignService
+}
+
+// NewCampaignHandler creates a new campaign handler
+func NewCampaignHandler(campaigns *usecases.CampaignService) *CampaignHandler {
+	return &CampaignHandler{campaigns: campaigns}
+}
+
+// RegisterRoutes registers campaign routes
+func (h *CampaignHandler) RegisterRoutes(api *gin.RouterGroup) {
+	campaigns := api.Group("/campaigns")
+	{
+		campaigns.GET("", h.List)
+		campaigns.POST("", h.Create)
+		campaigns.GET("/:id", h.Get)
+		campaigns.GET("/:id/recipients", h.Recipients)
+		campaigns.POST("/:id/pause", h.Pause)
+		campaigns.POST("/:id/resume", h.Resume)
+		campaigns.POST("/:id/cancel", h.Cancel)
+	}
+}
+
+// List returns the tenant's campaigns with delivery stats
+func (h *CampaignHandler) List(c *gin.Context) {
+	campaigns, err := h.campaigns.List(getSchemaName(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch campaigns"})
+		return
+	}
+	c.JSON(http.StatusOK, campaigns)
+}
+
+// Create schedules a new campaign
+// Body: {name, platform, audience_tags, template, scheduled_at (RFC3339, optional = now)}
+func (h *CampaignHandler) Create(c *gin.Context) {
+	userID, schema := getUserIDAndSchema(c)
+	var req struct {
+		Name         string   `json:"name"`
+		Platform     string   `json:"platform"`
+		AudienceTags []string `json:"audience_tags"`
+		Template     string   `json:"template"`
+		ScheduledAt  string   `json:"scheduled_at"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	if !ValidateLength(req.Name, 1, MaxTitleLength) || !ValidateLength(req.Template, 1, MaxPayloadLength) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid name or template"})
+		return
+	}
+
+	var scheduledAt time.Time
+	if req.ScheduledAt != "" {
+		t, err := time.Parse(time.RFC3339, req.ScheduledAt)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "scheduled_at must be RFC3339"})
+			return
+		}
+		scheduledAt = t
+	}
+
+	campaign, err := h.campaigns.Create(schema, userID, SanitizeString(req.Name), req.Platform, req.AudienceTags, req.Template, scheduledAt)
+	if err != nil {
+		campaignError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, campaign)
+}
+
+// Get returns one campaign with delivery stats
+func (h *CampaignHandler) Get(c *gin.Context) {
+	id, ok := campaignID(c)
+	if !ok {
+		return
+	}
+	campaign, err := h.campaigns.Get(getSchemaName(c), id)
+	if err != nil {
+		campaignError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, campaign)
+}
+
+// Recipients returns per-recipient delivery status
+// Query: status (pending/sent/failed/skipped), limit, offset
+func (h *CampaignHandler) Recipients(c *gin.Context) {
+	id, ok := campaignID(c)
+	if !ok {
+		return
+	}
+	limit, offset := pageParams(c)
+	recipients, err := h.campaigns.Recipients(getSchemaName(c), id, c.Query("status"), limit, offset)
+	if err != nil {
+		campaignError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recipients": recipients, "offset": offset})
+}
+
+// Pause stops delivery
+func (h *CampaignHandler) Pause(c *gin.Context) {
+	h.changeStatus(c, h.campaigns.Pause, "paused")
+}
+
+// Resume continues delivery
+func (h *CampaignHandler) Resume(c *gin.Context) {
+	h.changeStatus(c, h.campaigns.Resume, "resumed")
+}
+
+// Cancel stops the campaign for good
+func (h *CampaignHandler) Cancel(c *gin.Context) {
+	h.changeStatus(c, h.campaigns.Cancel, "cancelled")
+}
+
+func (h *CampaignHandler) changeStatus(c *gin.Context, change func(schema string, id int) error, status string) {
+	id, ok := campaignID(c)
+	if !ok {
+		return
+	}
+	if err := change(getSchemaName(c), id); err != nil {
+		campaignError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": status})
+}
+
+func campaignID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid campaign ID"})
+		return 0, false
+	}
+	return id, true
+}
+
+func campaignError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecases.ErrInvalidCampaign):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, usecases.ErrCampaignNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, usecases.ErrCampaignState):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
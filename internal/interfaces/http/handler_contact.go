@@ -0,0 +1,197 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"project_masAde/internal/repository"
+	"project_masAde/internal/usecases"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContactHandler manages the tenant's customer contacts
+type ContactHandler struct {
+	contacts *usecases.ContactService
+}
+
+// NewContactHandler creates a new contact handler
+func NewContactHandler(contacts *usecases.ContactService) *ContactHandler {
+	return &ContactHandler{contacts: contacts}
+}
+
+// RegisterRoutes registers contact routes
+func (h *ContactHandler) RegisterRoutes(api *gin.RouterGroup) {
+	contacts := api.Group("/contacts")
+	{
+		contacts.GET("", h.List)
+		contacts.POST("", h.Create)
+		contacts.GET("/export", h.Export)
+		contacts.GET("/:id", h.Get)
+		contacts.PUT("/:id", h.Update)
+		contacts.DELETE("/:id", h.Delete)
+	}
+}
+
+// contactRequest is the editable part of a contact
+type contactRequest struct {
+	Platform    string   `json:"platform"`
+	ChatID      string   `json:"chat_id"`
+	DisplayName string   `json:"display_name"`
+	Language    string   `json:"language"`
+	Tags        []string `json:"tags"`
+	OptedOut    bool     `json:"opted_out"`
+}
+
+// List returns contacts, most recently seen first
+// Query: platform, tag, q (chat ID or name), limit, offset
+func (h *ContactHandler) List(c *gin.Context) {
+	filter, ok := contactFilter(c)
+	if !ok {
+		return
+	}
+	filter.Limit, filter.Offset = pageParams(c)
+
+	contacts, total, err := h.contacts.List(getSchemaName(c), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch contacts"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"contacts": contacts, "total": total, "offset": filter.Offset})
+}
+
+// Export downloads matching contacts as CSV (same filters as List, no paging)
+func (h *ContactHandler) Export(c *gin.Context) {
+	filter, ok := contactFilter(c)
+	if !ok {
+		return
+	}
+	filename := fmt.Sprintf("contacts_%s.csv", time.Now().Format("20060102"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	if err := h.contacts.ExportCSV(getSchemaName(c), filter, c.Writer); err != nil {
+		fmt.Printf("Warning: contact export failed: %v\n", err)
+		c.Status(http.StatusInternalServerError)
+	}
+}
+
+// Get returns one contact
+func (h *ContactHandler) Get(c *gin.Context) {
+	id, ok := contactID(c)
+	if !ok {
+		return
+	}
+	contact, err := h.contacts.Get(getSchemaName(c), id)
+	if err != nil {
+		contactError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, contact)
+}
+
+// Create adds a contact manually
+func (h *ContactHandler) Create(c *gin.Context) {
+	var req contactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	if !ValidateLength(req.ChatID, 1, 100) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid chat_id"})
+		return
+	}
+	userID, schema := getUserIDAndSchema(c)
+	contact := &repository.Contact{
+		Platform:    req.Platform,
+		ChatID:      req.ChatID,
+		UserID:      userID,
+		DisplayName: SanitizeString(req.DisplayName),
+		Language:    req.Language,
+		Tags:        req.Tags,
+		OptedOut:    req.OptedOut,
+	}
+	if err := h.contacts.Create(schema, contact); err != nil {
+		contactError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, contact)
+}
+
+// Update edits name, language, tags and opt-out
+func (h *ContactHandler) Update(c *gin.Context) {
+	id, ok := contactID(c)
+	if !ok {
+		return
+	}
+	var req contactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	contact := &repository.Contact{
+		ID:          id,
+		DisplayName: SanitizeString(req.DisplayName),
+		Language:    req.Language,
+		Tags:        req.Tags,
+		OptedOut:    req.OptedOut,
+	}
+	if err := h.contacts.Update(getSchemaName(c), contact); err != nil {
+		contactError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "updated"})
+}
+
+// Delete removes a contact
+func (h *ContactHandler) Delete(c *gin.Context) {
+	id, ok := contactID(c)
+	if !ok {
+		return
+	}
+	if err := h.contacts.Delete(getSchemaName(c), id); err != nil {
+		contactError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+func contactFilter(c *gin.Context) (repository.ContactFilter, bool) {
+	f := repository.ContactFilter{
+		Platform: c.Query("platform"),
+		Tag:      c.Query("tag"),
+		Search:   c.Query("q"),
+	}
+	if f.Platform != "" && !validPlatform(f.Platform) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid platform"})
+		return f, false
+	}
+	if len(f.Tag) > 64 || len(f.Search) > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Filter too long"})
+		return f, false
+	}
+	return f, true
+}
+
+func contactID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID"})
+		return 0, false
+	}
+	return id, true
+}
+
+func contactError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecases.ErrInvalidContact):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, usecases.ErrContactNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, usecases.ErrContactExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,122 @@
+package http
+
+import (
+	"net/http"
+	"project_masAde/internal/usecases"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConversationHandler serves chat transcripts scoped to the caller's tenant
+type ConversationHandler struct {
+	logger *usecases.ConversationLogger
+}
+
+// NewConversationHandler creates a new conversation handler
+func NewConversationHandler(logger *usecases.ConversationLogger) *ConversationHandler {
+	return &ConversationHandler{logger: logger}
+}
+
+// RegisterRoutes registers conversation routes
+func (h *ConversationHandler) RegisterRoutes(api *gin.RouterGroup) {
+	conv := api.Group("/conversations")
+	{
+		conv.GET("", h.ListChats)
+		conv.GET("/search", h.Search)
+		conv.GET("/:platform/:chat_id", h.GetTranscript)
+	}
+}
+
+// ListChats returns the tenant's chats, most recently active first
+// Query: platform (optional), limit, offset
+func (h *ConversationHandler) ListChats(c *gin.Context) {
+	platform := c.Query("platform")
+	if platform != "" && !validPlatform(platform) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid platform"})
+		return
+	}
+	limit, offset := pageParams(c)
+
+	chats, err := h.logger.ListChats(getSchemaName(c), platform, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch conversations"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"chats": chats, "offset": offset})
+}
+
+// GetTranscript returns a page of one chat's messages in chronological order
+// Query: before (message ID, loads older messages), limit
+func (h *ConversationHandler) GetTranscript(c *gin.Context) {
+	platform := c.Param("platform")
+	chatID := c.Param("chat_id")
+	if !validPlatform(platform) || !ValidateLength(chatID, 1, 100) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid chat"})
+		return
+	}
+
+	var before int64
+	if v := c.Query("before"); v != "" {
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid before cursor"})
+			return
+		}
+		before = parsed
+	}
+	limit, _ := pageParams(c)
+
+	messages, err := h.logger.Transcript(getSchemaName(c), platform, chatID, before, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transcript"})
+		return
+	}
+
+	// Cursor for the next (older) page
+	var next int64
+	if len(messages) > 0 {
+		next = messages[0].ID
+	}
+	c.JSON(http.StatusOK, gin.H{"messages": messages, "next_before": next})
+}
+
+// Search runs a full-text search across the tenant's messages
+// Query: q, limit, offset
+func (h *ConversationHandler) Search(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if !ValidateLength(query, 1, MaxTitleLength) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid search query"})
+		return
+	}
+	limit, offset := pageParams(c)
+
+	results, err := h.logger.Search(getSchemaName(c), query, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Search failed"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"results": results, "offset": offset})
+}
+
+// pageParams reads limit/offset query params (0 when missing or invalid)
+func pageParams(c *gin.Context) (int, int) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	offset, _ := strconv.Atoi(c.Query("offset"))
+	if limit < 0 {
+		limit = 0
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
+
+func validPlatform(platform string) bool {
+	switch platform {
+	case "whatsapp", "telegram", "web":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"project_masAde/internal/repository"
+	"project_masAde/internal/usecases"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CurrencyHandler manages the tenant's exchange rates and display currency
+type CurrencyHandler struct {
+	currency *usecases.CurrencyService
+}
+
+// NewCurrencyHandler creates a new currency handler
+func NewCurrencyHandler(currency *usecases.CurrencyService) *CurrencyHandler {
+	return &CurrencyHandler{currency: currency}
+}
+
+// RegisterRoutes registers exchange rate routes
+func (h *CurrencyHandler) RegisterRoutes(api *gin.RouterGroup) {
+	rates := api.Group("/exchange-rates")
+	{
+		rates.GET("", h.List)
+		rates.POST("", h.Set)
+		rates.POST("/import", h.Import)
+		rates.PUT("/display", h.SetDisplay)
+		rates.DELETE("/:id", h.Delete)
+	}
+}
+
+// List returns the rates and the display currency
+func (h *CurrencyHandler) List(c *gin.Context) {
+	schema := getSchemaName(c)
+	rates, err := h.currency.List(schema)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch exchange rates"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rates": rates, "display_currency": h.currency.DisplayCurrency(schema)})
+}
+
+// Set adds or replaces the rate of a currency pair
+// Body: {base, quote, rate} meaning 1 base = rate quote
+func (h *CurrencyHandler) Set(c *gin.Context) {
+	var rate repository.ExchangeRate
+	if err := c.ShouldBindJSON(&rate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	if err := h.currency.Set(getSchemaName(c), &rate); err != nil {
+		currencyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rate)
+}
+
+// Import sets rates from an uploaded CSV file ("file") of base,quote,rate lines
+func (h *CurrencyHandler) Import(c *gin.Context) {
+	file, _, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bad request: missing file"})
+		return
+	}
+	defer file.Close()
+
+	imported, err := h.currency.ImportCSV(getSchemaName(c), file)
+	if err != nil {
+		currencyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"imported": imported})
+}
+
+// SetDisplay changes the currency quotes are also shown in
+// Body: {currency} ("" turns conversion off)
+func (h *CurrencyHandler) SetDisplay(c *gin.Context) {
+	var req struct {
+		Currency string `json:"currency"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	schema := getSchemaName(c)
+	if err := h.currency.SetDisplayCurrency(schema, req.Currency); err != nil {
+		currencyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"display_currency": h.currency.DisplayCurrency(schema)})
+}
+
+// Delete removes a rate
+func (h *CurrencyHandler) Delete(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rate ID"})
+		return
+	}
+	if err := h.currency.Delete(getSchemaName(c), id); err != nil {
+		currencyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+func currencyError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecases.ErrInvalidExchangeRate):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, usecases.ErrExchangeRateNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -2,8 +2,15 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"os"
+	"strconv"
+	"strings"
 	"project_masAde/internal/repository"
+	"project_masAde/internal/usecases"
 
 	"github.com/gin-gonic/gin"
 )
@@ -166,6 +173,10 @@ func (h *Handler) CreateMenu(c *gin.Context) {
 	m.Title = SanitizeString(m.Title)
 	
 	if err := h.dashboardUsecase.CreateMenu(schema, &repository.Menu{Slug: m.Slug, Title: m.Title, Items: m.Items}); err != nil {
+		if errors.Is(err, usecases.ErrInvalidMenu) {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(500, gin.H{"error": "Failed to create menu"})
 		return
 	}
@@ -184,6 +195,10 @@ func (h *Handler) UpdateMenu(c *gin.Context) {
 		return
 	}
 	if err := h.dashboardUsecase.UpdateMenu(schema, &repository.Menu{Slug: slug, Title: m.Title, Items: m.Items}); err != nil {
+		if errors.Is(err, usecases.ErrInvalidMenu) {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
@@ -194,6 +209,10 @@ func (h *Handler) DeleteMenu(c *gin.Context) {
 	schema := getSchemaName(c)
 	slug := c.Param("slug")
 	if err := h.dashboardUsecase.DeleteMenu(schema, slug); err != nil {
+		if errors.Is(err, usecases.ErrInvalidMenu) {
+			c.JSON(409, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
@@ -225,14 +244,80 @@ func (h *Handler) GetTableData(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "Invalid table name"})
 		return
 	}
-	data, err := h.dashboardUsecase.GetTableData(schema, name)
+	q, ok := tableQuery(c)
+	if !ok {
+		return
+	}
+	page, err := h.dashboardUsecase.QueryTable(schema, name, q)
 	if err != nil {
+		if errors.Is(err, repository.ErrTableNotFound) {
+			c.JSON(404, gin.H{"error": "Table not found"})
+			return
+		}
+		if errors.Is(err, repository.ErrColumnNotFound) || errors.Is(err, repository.ErrInvalidColumn) {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(500, gin.H{"error": "Failed to fetch data"})
 		return
 	}
-	c.JSON(200, data)
+	c.JSON(200, page)
+}
+
+// tableQuery reads dataset paging, sorting and filters from the query string:
+//
+//	limit, offset, cursor (next_cursor of the previous page)
+//	sort=price,-name           (- for descending)
+//	q=beras                    (text in any column)
+//	filter[price]=gte:10000    (eq, ne, contains, gt, gte, lt, lte; no operator = eq)
+//	filter[price]=between:10000..50000
+func tableQuery(c *gin.Context) (repository.TableQuery, bool) {
+	q := repository.TableQuery{Search: c.Query("q")}
+	q.Limit, _ = strconv.Atoi(c.Query("limit"))
+	q.Offset, _ = strconv.Atoi(c.Query("offset"))
+	q.AfterID, _ = strconv.Atoi(c.Query("cursor"))
+	if len(q.Search) > 100 {
+		c.JSON(400, gin.H{"error": "Search too long"})
+		return q, false
+	}
+
+	if sort := c.Query("sort"); sort != "" {
+		for _, field := range strings.Split(sort, ",") {
+			field = strings.TrimSpace(field)
+			desc := strings.HasPrefix(field, "-")
+			q.Sort = append(q.Sort, repository.SortField{Column: strings.TrimPrefix(field, "-"), Desc: desc})
+		}
+	}
+
+	for column, expr := range c.QueryMap("filter") {
+		f := repository.TableFilter{Column: column, Op: repository.FilterEquals, Value: expr}
+		if op, value, found := strings.Cut(expr, ":"); found && validFilterOp(op) {
+			f.Op, f.Value = op, value
+		}
+		if f.Op == repository.FilterBetween {
+			lo, hi, found := strings.Cut(f.Value, "..")
+			if !found {
+				c.JSON(400, gin.H{"error": "between filter needs low..high"})
+				return q, false
+			}
+			f.Value, f.Value2 = lo, hi
+		}
+		q.Filters = append(q.Filters, f)
+	}
+	return q, true
+}
+
+func validFilterOp(op string) bool {
+	switch op {
+	case repository.FilterEquals, repository.FilterNot, repository.FilterContains, repository.FilterGT,
+		repository.FilterGTE, repository.FilterLT, repository.FilterLTE, repository.FilterBetween:
+		return true
+	}
+	return false
 }
 
+// ImportTable starts a background import of a CSV, XLSX or JSON file and
+// returns the job to poll. Form: display_name, file, column_types, format, sheet
 func (h *Handler) ImportTable(c *gin.Context) {
 	schema := getSchemaName(c)
 	// Multipart form upload
@@ -242,19 +327,172 @@ func (h *Handler) ImportTable(c *gin.Context) {
 		return
 	}
 	displayName = SanitizeString(displayName)
-	
-	file, _, err := c.Request.FormFile("file")
+
+	src, columnTypes, ok := saveImportUpload(c)
+	if !ok {
+		return
+	}
+	userID, _ := getUserIDAndSchema(c)
+	job := h.dashboardUsecase.StartImport(schema, displayName, userID, src, columnTypes)
+	c.JSON(202, gin.H{"status": job.Status, "job": job})
+}
+
+// PreviewImport is a dry run of ImportTable: it reports headers, inferred column
+// types, rows whose length differs from the header and sample rows.
+// Form: file, column_types, format, sheet
+func (h *Handler) PreviewImport(c *gin.Context) {
+	src, columnTypes, ok := saveImportUpload(c)
+	if !ok {
+		return
+	}
+	defer os.Remove(src.Path)
+
+	preview, err := h.dashboardUsecase.PreviewImport(src, columnTypes)
+	if err != nil {
+		columnError(c, err)
+		return
+	}
+	c.JSON(200, preview)
+}
+
+// GetImportJob reports the progress of a background import
+func (h *Handler) GetImportJob(c *gin.Context) {
+	job, err := h.dashboardUsecase.ImportJob(getSchemaName(c), c.Param("id"))
+	if err != nil {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, job)
+}
+
+// ListImportJobs returns the tenant's recent imports, newest first
+func (h *Handler) ListImportJobs(c *gin.Context) {
+	c.JSON(200, gin.H{"jobs": h.dashboardUsecase.ImportJobs(getSchemaName(c))})
+}
+
+// saveImportUpload copies the uploaded file to a temp file, since imports
+// outlive the request, and parses the optional column_types overrides.
+// It writes the error response itself when it fails.
+func saveImportUpload(c *gin.Context) (repository.ImportSource, map[string]string, bool) {
+	// Optional type overrides: {"header": "integer|decimal|boolean|date|text"}
+	var columnTypes map[string]string
+	if raw := c.PostForm("column_types"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &columnTypes); err != nil {
+			c.JSON(400, gin.H{"error": "column_types must be a JSON object"})
+			return repository.ImportSource{}, nil, false
+		}
+	}
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(413, gin.H{"error": fmt.Sprintf("File too large (max %d MB)", MaxImportSize>>20)})
+			return repository.ImportSource{}, nil, false
+		}
+		c.JSON(400, gin.H{"error": "Bad request: missing file"})
+		return repository.ImportSource{}, nil, false
+	}
+	defer file.Close()
+
+	src := repository.ImportSource{Format: importFormat(c, header.Filename), Sheet: c.PostForm("sheet")}
+	tmp, err := os.CreateTemp("", "dataset-import-*")
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to store upload"})
+		return src, nil, false
+	}
+	src.Path = tmp.Name()
+	_, err = io.Copy(tmp, file)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(src.Path)
+		c.JSON(500, gin.H{"error": "Failed to store upload"})
+		return src, nil, false
+	}
+	return src, columnTypes, true
+}
+
+// importFormat returns the "format" form field, or the format implied by the file name
+func importFormat(c *gin.Context, filename string) string {
+	if format := c.PostForm("format"); format != "" {
+		return format
+	}
+	return repository.DetectFormat(filename)
+}
+
+// ReimportTable updates an existing dataset from a CSV, XLSX or JSON file, matching rows by key column.
+// Form: file, key_column, delete_missing (true/false), column_types (JSON, for new columns), format, sheet
+func (h *Handler) ReimportTable(c *gin.Context) {
+	userID, schema := getUserIDAndSchema(c)
+	name := c.Param("name")
+	if !ValidTableName(name) {
+		c.JSON(400, gin.H{"error": "Invalid table name"})
+		return
+	}
+	opts := repository.ReimportOptions{
+		KeyColumn:     c.PostForm("key_column"),
+		DeleteMissing: c.PostForm("delete_missing") == "true",
+		AuthorID:      userID,
+	}
+	if !ValidateLength(opts.KeyColumn, 1, MaxTitleLength) {
+		c.JSON(400, gin.H{"error": "key_column is required"})
+		return
+	}
+	if raw := c.PostForm("column_types"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &opts.ColumnTypes); err != nil {
+			c.JSON(400, gin.H{"error": "column_types must be a JSON object"})
+			return
+		}
+	}
+
+	file, header, err := c.Request.FormFile("file")
 	if err != nil {
 		c.JSON(400, gin.H{"error": "Bad request: missing file"})
 		return
 	}
 	defer file.Close()
 
-	if err := h.dashboardUsecase.ImportTable(schema, displayName, file); err != nil {
-		c.JSON(500, gin.H{"error": "Import failed: " + err.Error()})
+	result, err := h.dashboardUsecase.ReimportTable(schema, name, importFormat(c, header.Filename), c.PostForm("sheet"), file, opts)
+	if err != nil {
+		columnError(c, err)
+		return
+	}
+	c.JSON(200, result)
+}
+
+// ExportTable downloads a dataset. Query: format (csv, xlsx, json; default csv)
+func (h *Handler) ExportTable(c *gin.Context) {
+	schema := getSchemaName(c)
+	name := c.Param("name")
+	if !ValidTableName(name) {
+		c.JSON(400, gin.H{"error": "Invalid table name"})
 		return
 	}
-	c.JSON(201, gin.H{"status": "imported"})
+	format := c.DefaultQuery("format", repository.FormatCSV)
+	contentTypes := map[string]string{
+		repository.FormatCSV:  "text/csv; charset=utf-8",
+		repository.FormatJSON: "application/json",
+		repository.FormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	}
+	contentType, ok := contentTypes[format]
+	if !ok {
+		c.JSON(400, gin.H{"error": "format must be csv, xlsx or json"})
+		return
+	}
+	// Check the dataset exists before the download headers go out
+	if _, err := h.dashboardUsecase.GetColumns(schema, name); err != nil {
+		columnError(c, err)
+		return
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+"."+format))
+	if err := h.dashboardUsecase.ExportTable(schema, name, format, c.Writer); err != nil {
+		fmt.Printf("Warning: export of %s failed: %v\n", name, err)
+		c.Status(500)
+	}
 }
 
 func (h *Handler) DeleteTable(c *gin.Context) {
@@ -272,7 +510,7 @@ func (h *Handler) DeleteTable(c *gin.Context) {
 }
 
 func (h *Handler) UpdateRow(c *gin.Context) {
-	schema := getSchemaName(c)
+	userID, schema := getUserIDAndSchema(c)
 	tableName := c.Param("name")
 	var payload struct {
 		RowID int                    `json:"row_id"`
@@ -282,15 +520,219 @@ func (h *Handler) UpdateRow(c *gin.Context) {
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
-	if err := h.dashboardUsecase.UpdateRow(schema, tableName, payload.RowID, payload.Data); err != nil {
-		c.JSON(500, gin.H{"error": err.Error()})
+	if err := h.dashboardUsecase.UpdateRow(schema, tableName, payload.RowID, payload.Data, userID); err != nil {
+		columnError(c, err)
 		return
 	}
 	c.JSON(200, gin.H{"status": "updated"})
 }
 
-func (h *Handler) DeleteRow(c *gin.Context) {
+// -- Dataset schema handlers --
+
+func (h *Handler) GetColumns(c *gin.Context) {
+	schema := getSchemaName(c)
+	name := c.Param("name")
+	if !ValidTableName(name) {
+		c.JSON(400, gin.H{"error": "Invalid table name"})
+		return
+	}
+	cols, err := h.dashboardUsecase.GetColumns(schema, name)
+	if err != nil {
+		columnError(c, err)
+		return
+	}
+	c.JSON(200, cols)
+}
+
+// AddColumn adds an empty column. Body: {name, type}
+func (h *Handler) AddColumn(c *gin.Context) {
+	userID, schema := getUserIDAndSchema(c)
+	name := c.Param("name")
+	var req struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || !ValidTableName(name) {
+		c.JSON(400, gin.H{"error": "Invalid request"})
+		return
+	}
+	if req.Type == "" {
+		req.Type = repository.ColumnText
+	}
+	col, err := h.dashboardUsecase.AddColumn(schema, name, req.Name, req.Type, userID)
+	if err != nil {
+		columnError(c, err)
+		return
+	}
+	c.JSON(201, col)
+}
+
+// UpdateColumn renames and/or retypes a column. Body: {name, type} (either optional)
+func (h *Handler) UpdateColumn(c *gin.Context) {
+	userID, schema := getUserIDAndSchema(c)
+	name := c.Param("name")
+	var req struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || !ValidTableName(name) {
+		c.JSON(400, gin.H{"error": "Invalid request"})
+		return
+	}
+	if req.Name == "" && req.Type == "" {
+		c.JSON(400, gin.H{"error": "Nothing to change"})
+		return
+	}
+	if err := h.dashboardUsecase.UpdateColumn(schema, name, c.Param("column"), req.Name, req.Type, userID); err != nil {
+		columnError(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"status": "updated"})
+}
+
+func (h *Handler) DropColumn(c *gin.Context) {
+	userID, schema := getUserIDAndSchema(c)
+	name := c.Param("name")
+	if !ValidTableName(name) {
+		c.JSON(400, gin.H{"error": "Invalid table name"})
+		return
+	}
+	if err := h.dashboardUsecase.DropColumn(schema, name, c.Param("column"), userID); err != nil {
+		columnError(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"status": "deleted"})
+}
+
+// -- Dataset column role handlers --
+
+// GetRoles returns which columns the bot uses as name, price, etc., along
+// with the roles suggested from the column names
+func (h *Handler) GetRoles(c *gin.Context) {
 	schema := getSchemaName(c)
+	name := c.Param("name")
+	if !ValidTableName(name) {
+		c.JSON(400, gin.H{"error": "Invalid table name"})
+		return
+	}
+	roles, suggested, err := h.dashboardUsecase.GetRoles(schema, name)
+	if err != nil {
+		columnError(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"roles": roles, "suggested": suggested})
+}
+
+// SetRoles replaces a dataset's column roles. Body: {name, search_keys,
+// unit_price, currency, unit, weight, min_order, image}
+func (h *Handler) SetRoles(c *gin.Context) {
+	schema := getSchemaName(c)
+	name := c.Param("name")
+	if !ValidTableName(name) {
+		c.JSON(400, gin.H{"error": "Invalid table name"})
+		return
+	}
+	var roles repository.ColumnRoles
+	if err := c.ShouldBindJSON(&roles); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.dashboardUsecase.SetRoles(schema, name, roles); err != nil {
+		columnError(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"roles": roles})
+}
+
+// SearchTables searches every dataset the way the bot's CARI command does,
+// best matches first. Query: q, limit, offset
+func (h *Handler) SearchTables(c *gin.Context) {
+	schema := getSchemaName(c)
+	query := strings.TrimSpace(c.Query("q"))
+	if !ValidateLength(query, 1, 200) {
+		c.JSON(400, gin.H{"error": "Search query must be 1-200 characters"})
+		return
+	}
+	limit, offset := pageParams(c)
+	result, err := h.dashboardUsecase.SearchDatasets(schema, query, limit, offset)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Search failed"})
+		return
+	}
+	c.JSON(200, result)
+}
+
+// -- Dataset revision handlers --
+
+// ListRevisions returns a dataset's change history, newest first. Query: limit, offset
+func (h *Handler) ListRevisions(c *gin.Context) {
+	schema := getSchemaName(c)
+	name := c.Param("name")
+	if !ValidTableName(name) {
+		c.JSON(400, gin.H{"error": "Invalid table name"})
+		return
+	}
+	limit, offset := pageParams(c)
+	revisions, total, err := h.dashboardUsecase.ListRevisions(schema, name, limit, offset)
+	if err != nil {
+		columnError(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"revisions": revisions, "total": total, "offset": offset})
+}
+
+// DiffRevisions compares the dataset at two revisions. Query: from (0 = before
+// the first revision), to
+func (h *Handler) DiffRevisions(c *gin.Context) {
+	schema := getSchemaName(c)
+	name := c.Param("name")
+	from, errFrom := strconv.Atoi(c.DefaultQuery("from", "0"))
+	to, errTo := strconv.Atoi(c.Query("to"))
+	if !ValidTableName(name) || errFrom != nil || errTo != nil || from < 0 || to < 0 {
+		c.JSON(400, gin.H{"error": "Invalid table name or revision"})
+		return
+	}
+	diff, err := h.dashboardUsecase.DiffRevisions(schema, name, from, to)
+	if err != nil {
+		columnError(c, err)
+		return
+	}
+	c.JSON(200, diff)
+}
+
+// RollbackTable restores a dataset's rows to their state at a revision
+func (h *Handler) RollbackTable(c *gin.Context) {
+	userID, schema := getUserIDAndSchema(c)
+	name := c.Param("name")
+	revisionID, err := strconv.Atoi(c.Param("id"))
+	if !ValidTableName(name) || err != nil || revisionID <= 0 {
+		c.JSON(400, gin.H{"error": "Invalid table name or revision"})
+		return
+	}
+	revision, err := h.dashboardUsecase.RollbackTable(schema, name, revisionID, userID)
+	if err != nil {
+		columnError(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"status": "rolled_back", "revision": revision})
+}
+
+func columnError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrInvalidColumn), errors.Is(err, repository.ErrColumnConversion), errors.Is(err, repository.ErrUnsupportedFormat):
+		c.JSON(400, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrTableNotFound), errors.Is(err, repository.ErrColumnNotFound),
+		errors.Is(err, repository.ErrRowNotFound), errors.Is(err, repository.ErrRevisionNotFound):
+		c.JSON(404, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrColumnExists), errors.Is(err, repository.ErrRollbackBlocked):
+		c.JSON(409, gin.H{"error": err.Error()})
+	default:
+		c.JSON(500, gin.H{"error": err.Error()})
+	}
+}
+
+func (h *Handler) DeleteRow(c *gin.Context) {
+	userID, schema := getUserIDAndSchema(c)
 	tableName := c.Param("name")
 	var payload struct {
 		RowID int `json:"row_id"`
@@ -299,8 +741,8 @@ func (h *Handler) DeleteRow(c *gin.Context) {
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
-	if err := h.dashboardUsecase.DeleteRow(schema, tableName, payload.RowID); err != nil {
-		c.JSON(500, gin.H{"error": err.Error()})
+	if err := h.dashboardUsecase.DeleteRow(schema, tableName, payload.RowID, userID); err != nil {
+		columnError(c, err)
 		return
 	}
 	c.JSON(200, gin.H{"status": "deleted"})
@@ -0,0 +1,63 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"project_masAde/internal/infrastructure"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// eventKeepAlive is how often an idle stream sends a comment so proxies keep it open
+const eventKeepAlive = 25 * time.Second
+
+// EventsHandler streams real-time events to the dashboard over Server-Sent Events
+type EventsHandler struct {
+	hub *infrastructure.EventHub
+}
+
+// NewEventsHandler creates a new events handler
+func NewEventsHandler(hub *infrastructure.EventHub) *EventsHandler {
+	return &EventsHandler{hub: hub}
+}
+
+// Stream sends the caller's tenant events until the client disconnects.
+// Each SSE message is named after the event type and carries the event as JSON.
+func (h *EventsHandler) Stream(c *gin.Context) {
+	if h.hub == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Event stream not available"})
+		return
+	}
+
+	events, unsubscribe := h.hub.Subscribe(getSchemaName(c))
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	// Confirm the subscription so clients know the stream is live
+	c.SSEvent("ready", gin.H{"schema_name": getSchemaName(c)})
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(eventKeepAlive)
+	defer keepAlive.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case evt, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.SSEvent(evt.Type, evt)
+			return true
+		case <-keepAlive.C:
+			_, err := io.WriteString(w, ": ping\n\n")
+			return err == nil
+		}
+	})
+}
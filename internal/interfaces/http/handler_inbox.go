@@ -0,0 +1,119 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"project_masAde/internal/usecases"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InboxHandler lets staff take over chats from the bot and reply to customers
+type InboxHandler struct {
+	inbox *usecases.AgentInbox
+}
+
+// NewInboxHandler creates a new inbox handler
+func NewInboxHandler(inbox *usecases.AgentInbox) *InboxHandler {
+	return &InboxHandler{inbox: inbox}
+}
+
+// RegisterRoutes registers inbox routes
+func (h *InboxHandler) RegisterRoutes(api *gin.RouterGroup) {
+	inbox := api.Group("/inbox")
+	{
+		inbox.GET("", h.List)
+		inbox.POST("/:platform/:chat_id/takeover", h.TakeOver)
+		inbox.POST("/:platform/:chat_id/reply", h.Reply)
+		inbox.POST("/:platform/:chat_id/release", h.Release)
+	}
+}
+
+// List returns chats waiting for or handled by a human agent
+func (h *InboxHandler) List(c *gin.Context) {
+	chats, err := h.inbox.List(getSchemaName(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch inbox"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"chats": chats})
+}
+
+// TakeOver assigns the chat to the caller and silences the bot
+func (h *InboxHandler) TakeOver(c *gin.Context) {
+	userID, schema, platform, chatID, ok := inboxChatParams(c)
+	if !ok {
+		return
+	}
+	if err := h.inbox.TakeOver(schema, platform, chatID, userID); err != nil {
+		inboxError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "taken_over"})
+}
+
+// Reply sends a staff message to the customer
+func (h *InboxHandler) Reply(c *gin.Context) {
+	userID, schema, platform, chatID, ok := inboxChatParams(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		Text string `json:"text"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	text := strings.TrimSpace(req.Text)
+	if !ValidateLength(text, 1, MaxPayloadLength) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid message text"})
+		return
+	}
+	if err := h.inbox.Reply(schema, platform, chatID, userID, text); err != nil {
+		inboxError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "sent"})
+}
+
+// Release hands the chat back to the bot
+func (h *InboxHandler) Release(c *gin.Context) {
+	userID, schema, platform, chatID, ok := inboxChatParams(c)
+	if !ok {
+		return
+	}
+	if err := h.inbox.Release(schema, platform, chatID, userID); err != nil {
+		inboxError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "released"})
+}
+
+// inboxChatParams validates the caller and chat path params, writing an error response on failure
+func inboxChatParams(c *gin.Context) (int, string, string, string, bool) {
+	userID, schema := getUserIDAndSchema(c)
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return 0, "", "", "", false
+	}
+	platform := c.Param("platform")
+	chatID := c.Param("chat_id")
+	if !validPlatform(platform) || !ValidateLength(chatID, 1, 100) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid chat"})
+		return 0, "", "", "", false
+	}
+	return userID, schema, platform, chatID, true
+}
+
+func inboxError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecases.ErrChatNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, usecases.ErrChatNotTakenOver), errors.Is(err, usecases.ErrChatTakenByOther):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,189 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"project_masAde/internal/repository"
+	"project_masAde/internal/usecases"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IntentHandler manages the tenant's keyword/regex intent rules
+type IntentHandler struct {
+	intents *usecases.IntentRuleService
+}
+
+// NewIntentHandler creates a new intent rule handler
+func NewIntentHandler(intents *usecases.IntentRuleService) *IntentHandler {
+	return &IntentHandler{intents: intents}
+}
+
+// RegisterRoutes registers intent rule routes
+func (h *IntentHandler) RegisterRoutes(api *gin.RouterGroup) {
+	intents := api.Group("/intents")
+	{
+		intents.GET("", h.List)
+		intents.POST("", h.Create)
+		intents.POST("/defaults", h.ResetDefaults)
+		intents.POST("/test", h.Test)
+		intents.GET("/:id", h.Get)
+		intents.PUT("/:id", h.Update)
+		intents.DELETE("/:id", h.Delete)
+	}
+}
+
+// intentRuleRequest is the editable part of a rule
+type intentRuleRequest struct {
+	Pattern   string `json:"pattern"`
+	MatchType string `json:"match_type"`
+	Priority  int    `json:"priority"`
+	Language  string `json:"language"`
+	Action    string `json:"action"`
+	Payload   string `json:"payload"`
+	Enabled   *bool  `json:"enabled"` // Defaults to true
+}
+
+func (r intentRuleRequest) rule() *repository.IntentRule {
+	enabled := r.Enabled == nil || *r.Enabled
+	return &repository.IntentRule{
+		Pattern:   r.Pattern,
+		MatchType: r.MatchType,
+		Priority:  r.Priority,
+		Language:  r.Language,
+		Action:    r.Action,
+		Payload:   r.Payload,
+		Enabled:   enabled,
+	}
+}
+
+// List returns the rules in evaluation order; using_defaults is true while the
+// tenant has not defined any rules of its own
+func (h *IntentHandler) List(c *gin.Context) {
+	rules, usingDefaults, err := h.intents.Rules(getSchemaName(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch intent rules"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rules": rules, "using_defaults": usingDefaults})
+}
+
+// Get returns one rule
+func (h *IntentHandler) Get(c *gin.Context) {
+	id, ok := intentRuleID(c)
+	if !ok {
+		return
+	}
+	rule, err := h.intents.Get(getSchemaName(c), id)
+	if err != nil {
+		intentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rule)
+}
+
+// Create adds a rule
+// Body: {pattern, match_type (exact/contains/prefix/regex), priority, language, action, payload, enabled}
+func (h *IntentHandler) Create(c *gin.Context) {
+	var req intentRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	if !ValidateLength(req.Payload, 0, MaxPayloadLength) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Payload too long"})
+		return
+	}
+	rule := req.rule()
+	if err := h.intents.Create(getSchemaName(c), rule); err != nil {
+		intentError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, rule)
+}
+
+// Update replaces a rule
+func (h *IntentHandler) Update(c *gin.Context) {
+	id, ok := intentRuleID(c)
+	if !ok {
+		return
+	}
+	var req intentRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	if !ValidateLength(req.Payload, 0, MaxPayloadLength) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Payload too long"})
+		return
+	}
+	rule := req.rule()
+	rule.ID = id
+	if err := h.intents.Update(getSchemaName(c), rule); err != nil {
+		intentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "updated"})
+}
+
+// Delete removes a rule
+func (h *IntentHandler) Delete(c *gin.Context) {
+	id, ok := intentRuleID(c)
+	if !ok {
+		return
+	}
+	if err := h.intents.Delete(getSchemaName(c), id); err != nil {
+		intentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+// ResetDefaults replaces the tenant's rules with the built-in defaults
+func (h *IntentHandler) ResetDefaults(c *gin.Context) {
+	if err := h.intents.ResetDefaults(getSchemaName(c)); err != nil {
+		intentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "reset"})
+}
+
+// Test shows which rule a message would trigger
+// Body: {text, language}
+func (h *IntentHandler) Test(c *gin.Context) {
+	var req struct {
+		Text     string `json:"text"`
+		Language string `json:"language"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || !ValidateLength(req.Text, 1, MaxPayloadLength) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	match, err := h.intents.Match(getSchemaName(c), req.Text, req.Language)
+	if err != nil {
+		intentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"matched": match != nil, "match": match})
+}
+
+func intentRuleID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rule ID"})
+		return 0, false
+	}
+	return id, true
+}
+
+func intentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecases.ErrInvalidIntentRule):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, usecases.ErrIntentRuleNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,137 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"project_masAde/internal/repository"
+	"project_masAde/internal/usecases"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OrderHandler manages the orders customers place in chat
+type OrderHandler struct {
+	orders    *usecases.OrderService
+	documents *usecases.DocumentService
+}
+
+// NewOrderHandler creates a new order handler
+func NewOrderHandler(orders *usecases.OrderService, documents *usecases.DocumentService) *OrderHandler {
+	return &OrderHandler{orders: orders, documents: documents}
+}
+
+// RegisterRoutes registers order routes
+func (h *OrderHandler) RegisterRoutes(api *gin.RouterGroup) {
+	orders := api.Group("/orders")
+	{
+		orders.GET("", h.List)
+		orders.GET("/:id", h.Get)
+		orders.GET("/:id/pdf", h.Invoice)
+		orders.PUT("/:id/status", h.UpdateStatus)
+	}
+}
+
+// List returns orders, newest first
+// Query: status, platform, chat_id, q (number, name or chat ID), limit, offset
+func (h *OrderHandler) List(c *gin.Context) {
+	filter := repository.OrderFilter{
+		Status:   c.Query("status"),
+		Platform: c.Query("platform"),
+		ChatID:   c.Query("chat_id"),
+		Search:   c.Query("q"),
+	}
+	if filter.Platform != "" && !validPlatform(filter.Platform) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid platform"})
+		return
+	}
+	if len(filter.ChatID) > 100 || len(filter.Search) > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Filter too long"})
+		return
+	}
+	filter.Limit, filter.Offset = pageParams(c)
+
+	orders, total, err := h.orders.List(getSchemaName(c), filter)
+	if err != nil {
+		orderError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"orders": orders, "total": total, "offset": filter.Offset})
+}
+
+// Get returns one order
+func (h *OrderHandler) Get(c *gin.Context) {
+	id, ok := orderID(c)
+	if !ok {
+		return
+	}
+	order, err := h.orders.Get(getSchemaName(c), id)
+	if err != nil {
+		orderError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, order)
+}
+
+// Invoice downloads an order as a PDF invoice
+func (h *OrderHandler) Invoice(c *gin.Context) {
+	id, ok := orderID(c)
+	if !ok {
+		return
+	}
+	schema := getSchemaName(c)
+	order, err := h.orders.Get(schema, id)
+	if err != nil {
+		orderError(c, err)
+		return
+	}
+	doc := h.documents.InvoicePDF(schema, order)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", doc.Filename))
+	c.Data(http.StatusOK, usecases.PDFMimeType, doc.Data)
+}
+
+// UpdateStatus moves an order along its lifecycle; the customer is notified in chat
+// Body: {status (confirmed/paid/shipped/cancelled), note}
+func (h *OrderHandler) UpdateStatus(c *gin.Context) {
+	id, ok := orderID(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		Status string `json:"status"`
+		Note   string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.Note) > MaxPayloadLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	order, err := h.orders.UpdateStatus(getSchemaName(c), id, req.Status, SanitizeString(req.Note))
+	if err != nil {
+		orderError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, order)
+}
+
+func orderID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return 0, false
+	}
+	return id, true
+}
+
+func orderError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecases.ErrInvalidOrderStatus):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, usecases.ErrOrderNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, usecases.ErrOrderStatusChange):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
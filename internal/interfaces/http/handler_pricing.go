@@ -0,0 +1,216 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"project_masAde/internal/repository"
+	"project_masAde/internal/usecases"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PricingHandler manages the tenant's pricing rules
+type PricingHandler struct {
+	pricing    *usecases.PricingRuleService
+	calculator *usecases.DynamicCalculator
+	documents  *usecases.DocumentService
+}
+
+// NewPricingHandler creates a new pricing rule handler
+func NewPricingHandler(pricing *usecases.PricingRuleService, calculator *usecases.DynamicCalculator, documents *usecases.DocumentService) *PricingHandler {
+	return &PricingHandler{pricing: pricing, calculator: calculator, documents: documents}
+}
+
+// RegisterRoutes registers pricing rule routes
+func (h *PricingHandler) RegisterRoutes(api *gin.RouterGroup) {
+	pricing := api.Group("/pricing-rules")
+	{
+		pricing.GET("", h.List)
+		pricing.POST("", h.Create)
+		pricing.POST("/quote", h.Quote)
+		pricing.POST("/quote/pdf", h.QuotePDF)
+		pricing.GET("/:id", h.Get)
+		pricing.PUT("/:id", h.Update)
+		pricing.DELETE("/:id", h.Delete)
+	}
+}
+
+// pricingRuleRequest is the editable part of a rule
+type pricingRuleRequest struct {
+	Name      string  `json:"name"`
+	Kind      string  `json:"kind"`
+	TableName string  `json:"table_name"`
+	Product   string  `json:"product"`
+	MinQty    float64 `json:"min_qty"`
+	MaxQty    float64 `json:"max_qty"`
+	Value     float64 `json:"value"`
+	ValueType string  `json:"value_type"`
+	Priority  int     `json:"priority"`
+	Enabled   *bool   `json:"enabled"` // Defaults to true
+}
+
+func (r pricingRuleRequest) rule() *repository.PricingRule {
+	enabled := r.Enabled == nil || *r.Enabled
+	return &repository.PricingRule{
+		Name:      r.Name,
+		Kind:      r.Kind,
+		TableName: r.TableName,
+		Product:   r.Product,
+		MinQty:    r.MinQty,
+		MaxQty:    r.MaxQty,
+		Value:     r.Value,
+		ValueType: r.ValueType,
+		Priority:  r.Priority,
+		Enabled:   enabled,
+	}
+}
+
+// List returns the rules in evaluation order
+func (h *PricingHandler) List(c *gin.Context) {
+	rules, err := h.pricing.Rules(getSchemaName(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch pricing rules"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rules": rules})
+}
+
+// Get returns one rule
+func (h *PricingHandler) Get(c *gin.Context) {
+	id, ok := pricingRuleID(c)
+	if !ok {
+		return
+	}
+	rule, err := h.pricing.Get(getSchemaName(c), id)
+	if err != nil {
+		pricingError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rule)
+}
+
+// Create adds a rule
+// Body: {name, kind (tier/discount/moq/tax), table_name, product, min_qty, max_qty, value, value_type (percent/fixed), priority, enabled}
+func (h *PricingHandler) Create(c *gin.Context) {
+	var req pricingRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	rule := req.rule()
+	if err := h.pricing.Create(getSchemaName(c), rule); err != nil {
+		pricingError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, rule)
+}
+
+// Update replaces a rule
+func (h *PricingHandler) Update(c *gin.Context) {
+	id, ok := pricingRuleID(c)
+	if !ok {
+		return
+	}
+	var req pricingRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	rule := req.rule()
+	rule.ID = id
+	if err := h.pricing.Update(getSchemaName(c), rule); err != nil {
+		pricingError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "updated"})
+}
+
+// Delete removes a rule
+func (h *PricingHandler) Delete(c *gin.Context) {
+	id, ok := pricingRuleID(c)
+	if !ok {
+		return
+	}
+	if err := h.pricing.Delete(getSchemaName(c), id); err != nil {
+		pricingError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+// Quote prices a calculator input the way the bot would, with the current rules
+// Body: {table, input, currency} e.g. {"table": "products", "input": "30 tumbler, 50 gelas 5kg", "currency": "IDR"}
+func (h *PricingHandler) Quote(c *gin.Context) {
+	var req struct {
+		Table    string `json:"table"`
+		Input    string `json:"input"`
+		Currency string `json:"currency"` // Also show the total in this currency
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || !ValidateLength(req.Input, 1, MaxPayloadLength) || req.Table == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	queries, failure := h.calculator.ParseItems(req.Input)
+	if failure != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": failure})
+		return
+	}
+	quote, failure := h.calculator.QuoteIn(getSchemaName(c), req.Table, req.Currency, queries...)
+	if failure != "" {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": failure})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"quote": quote, "text": usecases.FormatQuote(quote)})
+}
+
+// QuotePDF downloads a quotation as PDF
+// Body: {table, input, customer, currency}
+func (h *PricingHandler) QuotePDF(c *gin.Context) {
+	var req struct {
+		Table    string `json:"table"`
+		Input    string `json:"input"`
+		Customer string `json:"customer"`
+		Currency string `json:"currency"` // Also show the total in this currency
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || !ValidateLength(req.Input, 1, MaxPayloadLength) || req.Table == "" || len(req.Customer) > 200 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	queries, failure := h.calculator.ParseItems(req.Input)
+	if failure != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": failure})
+		return
+	}
+	schema := getSchemaName(c)
+	quote, failure := h.calculator.QuoteIn(schema, req.Table, req.Currency, queries...)
+	if failure != "" {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": failure})
+		return
+	}
+	doc := h.documents.QuotePDF(schema, quote, SanitizeString(req.Customer), time.Now())
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", doc.Filename))
+	c.Data(http.StatusOK, usecases.PDFMimeType, doc.Data)
+}
+
+func pricingRuleID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rule ID"})
+		return 0, false
+	}
+	return id, true
+}
+
+func pricingError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecases.ErrInvalidPricingRule):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, usecases.ErrPricingRuleNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -1,11 +1,14 @@
 package http
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
@@ -16,12 +19,26 @@ type Middleware struct {
 	jwtSecret   []byte
 	rateLimiters map[string]*rate.Limiter
 	mu          sync.Mutex
+
+	streamTokens map[string]streamToken // Guarded by mu
+}
+
+// streamTokenTTL is how long a stream token may wait to be used
+const streamTokenTTL = 30 * time.Second
+
+// streamToken stands in for a session on one event stream connection
+type streamToken struct {
+	userID     any
+	role       any
+	schemaName any
+	expiresAt  time.Time
 }
 
 func NewMiddleware(secret string) *Middleware {
 	return &Middleware{
 		jwtSecret:    []byte(secret),
 		rateLimiters: make(map[string]*rate.Limiter),
+		streamTokens: make(map[string]streamToken),
 	}
 }
 
@@ -61,6 +78,63 @@ func (m *Middleware) AuthRequired() gin.HandlerFunc {
 	}
 }
 
+// IssueStreamToken hands an authenticated caller a short-lived, single-use
+// token for clients that cannot set headers (browser EventSource). The
+// session JWT never goes in a URL, where access logs and proxies keep it.
+// Must follow AuthRequired.
+func (m *Middleware) IssueStreamToken(c *gin.Context) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue token"})
+		return
+	}
+	token := hex.EncodeToString(raw)
+	now := time.Now()
+
+	m.mu.Lock()
+	for t, st := range m.streamTokens {
+		if now.After(st.expiresAt) {
+			delete(m.streamTokens, t)
+		}
+	}
+	m.streamTokens[token] = streamToken{
+		userID:     c.MustGet("user_id"),
+		role:       c.MustGet("role"),
+		schemaName: c.MustGet("schema_name"),
+		expiresAt:  now.Add(streamTokenTTL),
+	}
+	m.mu.Unlock()
+
+	c.JSON(http.StatusOK, gin.H{"token": token, "expires_in": int(streamTokenTTL.Seconds())})
+}
+
+// StreamAuth authenticates a streaming route with ?token= from
+// IssueStreamToken, or else like AuthRequired. A token works once.
+func (m *Middleware) StreamAuth() gin.HandlerFunc {
+	authRequired := m.AuthRequired()
+	return func(c *gin.Context) {
+		token := c.Query("token")
+		if token == "" {
+			authRequired(c)
+			return
+		}
+
+		m.mu.Lock()
+		st, ok := m.streamTokens[token]
+		delete(m.streamTokens, token)
+		m.mu.Unlock()
+		if !ok || time.Now().After(st.expiresAt) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			return
+		}
+
+		c.Set("user_id", st.userID)
+		c.Set("role", st.role)
+		c.Set("schema_name", st.schemaName)
+		c.Next()
+	}
+}
+
 // AdminRequired middleware - requires role == "admin" (must follow AuthRequired)
 func (m *Middleware) AdminRequired() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -143,10 +217,18 @@ func SecurityHeaders() gin.HandlerFunc {
 	}
 }
 
-// RequestSizeLimiter limits request body size to prevent DoS
-func RequestSizeLimiter(maxBytes int64) gin.HandlerFunc {
+// MaxImportSize is the upload limit for dataset imports, which stream to disk
+const MaxImportSize = 256 << 20
+
+// RequestSizeLimiter limits request body size to prevent DoS.
+// routeLimits overrides the limit for specific routes (by gin route path).
+func RequestSizeLimiter(maxBytes int64, routeLimits map[string]int64) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+		limit := maxBytes
+		if l, ok := routeLimits[c.FullPath()]; ok {
+			limit = l
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
 		c.Next()
 	}
 }
@@ -0,0 +1,268 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Campaign statuses
+const (
+	CampaignScheduled = "scheduled" // Waiting for scheduled_at
+	CampaignRunning   = "running"
+	CampaignPaused    = "paused" // By staff, or automatically when the quota runs out
+	CampaignCompleted = "completed"
+	CampaignCancelled = "cancelled"
+)
+
+// Recipient delivery statuses
+const (
+	RecipientPending = "pending"
+	RecipientSent    = "sent"
+	RecipientFailed  = "failed"
+	RecipientSkipped = "skipped" // Opted out after the audience was built
+)
+
+// Campaign is a broadcast message to a tenant's contacts
+type Campaign struct {
+	ID           int        `json:"id"`
+	SchemaName   string     `json:"schema_name"`
+	CreatedBy    int        `json:"created_by"`
+	Name         string     `json:"name"`
+	Platform     string     `json:"platform"`
+	AudienceTags []string   `json:"audience_tags"` // Empty = everyone seen on the platform
+	Template     string     `json:"template"`
+	Status       string     `json:"status"`
+	StatusReason string     `json:"status_reason,omitempty"`
+	ScheduledAt  time.Time  `json:"scheduled_at"`
+	StartedAt    *time.Time `json:"started_at,omitempty"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+
+	Stats CampaignStats `json:"stats"`
+}
+
+// CampaignStats counts recipients by delivery status
+type CampaignStats struct {
+	Total   int `json:"total"`
+	Pending int `json:"pending"`
+	Sent    int `json:"sent"`
+	Failed  int `json:"failed"`
+	Skipped int `json:"skipped"`
+}
+
+// CampaignRecipient is one delivery of a campaign
+type CampaignRecipient struct {
+	ID         int64      `json:"id"`
+	CampaignID int        `json:"campaign_id"`
+	Platform   string     `json:"platform"`
+	ChatID     string     `json:"chat_id"`
+	UserID     int        `json:"user_id"`
+	Status     string     `json:"status"`
+	Error      string     `json:"error,omitempty"`
+	SentAt     *time.Time `json:"sent_at,omitempty"`
+}
+
+type CampaignRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewCampaignRepository(db *pgxpool.Pool) *CampaignRepository {
+	return &CampaignRepository{db: db}
+}
+
+const campaignColumns = `id, schema_name, COALESCE(created_by, 0), name, platform, audience_tags, template,
+	status, COALESCE(status_reason, ''), scheduled_at, started_at, completed_at, created_at`
+
+func scanCampaign(row pgx.Row) (*Campaign, error) {
+	var c Campaign
+	err := row.Scan(&c.ID, &c.SchemaName, &c.CreatedBy, &c.Name, &c.Platform, &c.AudienceTags, &c.Template,
+		&c.Status, &c.StatusReason, &c.ScheduledAt, &c.StartedAt, &c.CompletedAt, &c.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Create stores a new scheduled campaign
+func (r *CampaignRepository) Create(c *Campaign) error {
+	if c.AudienceTags == nil {
+		c.AudienceTags = []string{}
+	}
+	return r.db.QueryRow(context.Background(), `
+		INSERT INTO campaigns (schema_name, created_by, name, platform, audience_tags, template, status, scheduled_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`, c.SchemaName, c.CreatedBy, c.Name, c.Platform, c.AudienceTags, c.Template, CampaignScheduled, c.ScheduledAt).Scan(&c.ID, &c.CreatedAt)
+}
+
+// Get returns a tenant's campaign with delivery stats (nil if not found)
+func (r *CampaignRepository) Get(schemaName string, id int) (*Campaign, error) {
+	c, err := scanCampaign(r.db.QueryRow(context.Background(),
+		fmt.Sprintf("SELECT %s FROM campaigns WHERE schema_name = $1 AND id = $2", campaignColumns), schemaName, id))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.Stats, err = r.Stats(c.ID)
+	return c, err
+}
+
+// List returns a tenant's campaigns, newest first
+func (r *CampaignRepository) List(schemaName string) ([]Campaign, error) {
+	rows, err := r.db.Query(context.Background(),
+		fmt.Sprintf("SELECT %s FROM campaigns WHERE schema_name = $1 ORDER BY created_at DESC", campaignColumns), schemaName)
+	if err != nil {
+		return nil, err
+	}
+	campaigns, err := collectCampaigns(rows)
+	if err != nil {
+		return nil, err
+	}
+	for i := range campaigns {
+		if campaigns[i].Stats, err = r.Stats(campaigns[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return campaigns, nil
+}
+
+// Active returns campaigns the worker should process: running ones and
+// scheduled ones whose time has come, oldest first
+func (r *CampaignRepository) Active() ([]Campaign, error) {
+	rows, err := r.db.Query(context.Background(), fmt.Sprintf(`
+		SELECT %s FROM campaigns
+		WHERE status = $1 OR (status = $2 AND scheduled_at <= NOW())
+		ORDER BY scheduled_at ASC
+	`, campaignColumns), CampaignRunning, CampaignScheduled)
+	if err != nil {
+		return nil, err
+	}
+	return collectCampaigns(rows)
+}
+
+func collectCampaigns(rows pgx.Rows) ([]Campaign, error) {
+	defer rows.Close()
+	campaigns := []Campaign{}
+	for rows.Next() {
+		c, err := scanCampaign(rows)
+		if err != nil {
+			return nil, err
+		}
+		campaigns = append(campaigns, *c)
+	}
+	return campaigns, rows.Err()
+}
+
+// SetStatus moves a campaign to a new status if it is currently in one of from.
+// Returns false when the campaign was not in an allowed status.
+func (r *CampaignRepository) SetStatus(id int, from []string, to, reason string) (bool, error) {
+	tag, err := r.db.Exec(context.Background(), `
+		UPDATE campaigns SET
+			status = $1,
+			status_reason = NULLIF($2, ''),
+			started_at = CASE WHEN $1 = 'running' THEN COALESCE(started_at, NOW()) ELSE started_at END,
+			completed_at = CASE WHEN $1 IN ('completed', 'cancelled') THEN NOW() ELSE completed_at END
+		WHERE id = $3 AND status = ANY($4)
+	`, to, reason, id, from)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// Status returns the current status of a campaign
+func (r *CampaignRepository) Status(id int) (string, error) {
+	var status string
+	err := r.db.QueryRow(context.Background(), "SELECT status FROM campaigns WHERE id = $1", id).Scan(&status)
+	return status, err
+}
+
+// BuildAudience snapshots the matching, non-opted-out contacts as pending recipients.
+// Safe to call again: existing recipients are kept.
+func (r *CampaignRepository) BuildAudience(c *Campaign) (int64, error) {
+	contacts := qualifyTable(c.SchemaName, "contacts")
+	tag, err := r.db.Exec(context.Background(), fmt.Sprintf(`
+		INSERT INTO campaign_recipients (campaign_id, platform, chat_id, user_id)
+		SELECT $1, platform, chat_id, COALESCE(user_id, 0)
+		FROM %s
+		WHERE platform = $2 AND NOT opted_out AND (cardinality($3::text[]) = 0 OR tags && $3::text[])
+		ON CONFLICT (campaign_id, platform, chat_id) DO NOTHING
+	`, contacts), c.ID, c.Platform, c.AudienceTags)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// PendingRecipients returns the next batch of recipients still to be sent
+func (r *CampaignRepository) PendingRecipients(campaignID, limit int) ([]CampaignRecipient, error) {
+	rows, err := r.db.Query(context.Background(), `
+		SELECT id, campaign_id, platform, chat_id, COALESCE(user_id, 0), status, COALESCE(error, ''), sent_at
+		FROM campaign_recipients
+		WHERE campaign_id = $1 AND status = $2
+		ORDER BY id
+		LIMIT $3
+	`, campaignID, RecipientPending, limit)
+	if err != nil {
+		return nil, err
+	}
+	return collectRecipients(rows)
+}
+
+// ListRecipients returns a page of a campaign's recipients, optionally filtered by status
+func (r *CampaignRepository) ListRecipients(campaignID int, status string, limit, offset int) ([]CampaignRecipient, error) {
+	rows, err := r.db.Query(context.Background(), `
+		SELECT id, campaign_id, platform, chat_id, COALESCE(user_id, 0), status, COALESCE(error, ''), sent_at
+		FROM campaign_recipients
+		WHERE campaign_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY id
+		LIMIT $3 OFFSET $4
+	`, campaignID, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return collectRecipients(rows)
+}
+
+func collectRecipients(rows pgx.Rows) ([]CampaignRecipient, error) {
+	defer rows.Close()
+	recipients := []CampaignRecipient{}
+	for rows.Next() {
+		var rc CampaignRecipient
+		if err := rows.Scan(&rc.ID, &rc.CampaignID, &rc.Platform, &rc.ChatID, &rc.UserID, &rc.Status, &rc.Error, &rc.SentAt); err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, rc)
+	}
+	return recipients, rows.Err()
+}
+
+// MarkRecipient records the delivery result for one recipient
+func (r *CampaignRepository) MarkRecipient(id int64, status, errMsg string) error {
+	_, err := r.db.Exec(context.Background(), `
+		UPDATE campaign_recipients
+		SET status = $1, error = NULLIF($2, ''), sent_at = CASE WHEN $1 = 'sent' THEN NOW() ELSE sent_at END
+		WHERE id = $3
+	`, status, errMsg, id)
+	return err
+}
+
+// Stats counts a campaign's recipients by status
+func (r *CampaignRepository) Stats(campaignID int) (CampaignStats, error) {
+	var s CampaignStats
+	err := r.db.QueryRow(context.Background(), `
+		SELECT COUNT(*),
+			COUNT(*) FILTER (WHERE status = 'pending'),
+			COUNT(*) FILTER (WHERE status = 'sent'),
+			COUNT(*) FILTER (WHERE status = 'failed'),
+			COUNT(*) FILTER (WHERE status = 'skipped')
+		FROM campaign_recipients WHERE campaign_id = $1
+	`, campaignID).Scan(&s.Total, &s.Pending, &s.Sent, &s.Failed, &s.Skipped)
+	return s, err
+}
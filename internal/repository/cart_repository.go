@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// CartItem is a product line in a chat's cart. Prices are not stored: the
+// cart is re-priced from the dataset and pricing rules whenever it is shown.
+type CartItem struct {
+	ID          int       `json:"id"`
+	Platform    string    `json:"platform"`
+	ChatID      string    `json:"chat_id"`
+	TableName   string    `json:"table_name"`
+	Product     string    `json:"product"` // As typed; looked up like a calculation
+	Quantity    float64   `json:"quantity"`
+	Unit        string    `json:"unit,omitempty"` // Unit the quantity was typed in; "" = the product's pricing unit
+	WeightGrams float64   `json:"weight_grams,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Errors returned by cart changes
+var (
+	ErrCartFull    = errors.New("cart is full")                // Adding would give the cart more lines than allowed
+	ErrCartChanged = errors.New("cart was ordered or changed") // The lines being ordered are no longer in the cart
+)
+
+type CartRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewCartRepository(db *pgxpool.Pool) *CartRepository {
+	return &CartRepository{db: db}
+}
+
+// CartItemsTableDDL creates the chat carts of a schema
+func CartItemsTableDDL(schemaName string) string {
+	return fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %[1]s.cart_items (
+			id SERIAL PRIMARY KEY,
+			platform VARCHAR(20) NOT NULL,
+			chat_id VARCHAR(100) NOT NULL,
+			table_name VARCHAR(128) NOT NULL,
+			product VARCHAR(256) NOT NULL,
+			quantity NUMERIC NOT NULL,
+			unit VARCHAR(16) NOT NULL DEFAULT '',
+			weight_grams NUMERIC NOT NULL DEFAULT 0,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		ALTER TABLE %[1]s.cart_items ADD COLUMN IF NOT EXISTS unit VARCHAR(16) NOT NULL DEFAULT '';
+		CREATE INDEX IF NOT EXISTS idx_cart_items_chat ON %[1]s.cart_items(platform, chat_id, id)
+	`, schemaName)
+}
+
+// List returns a chat's cart in the order items were added
+func (r *CartRepository) List(schemaName, platform, chatID string) ([]CartItem, error) {
+	rows, err := r.db.Query(context.Background(), fmt.Sprintf(`
+		SELECT id, platform, chat_id, table_name, product, quantity::float8, unit, weight_grams::float8, created_at
+		FROM %s WHERE platform = $1 AND chat_id = $2 ORDER BY id
+	`, qualifyTable(schemaName, "cart_items")), platform, chatID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []CartItem{}
+	for rows.Next() {
+		var item CartItem
+		if err := rows.Scan(&item.ID, &item.Platform, &item.ChatID, &item.TableName, &item.Product, &item.Quantity, &item.Unit, &item.WeightGrams, &item.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// Add puts items in a chat's cart in one transaction. A line for the same
+// product (unit and weight) of the same dataset has its quantity increased
+// instead. Nothing is added if the cart would end up with more than maxLines
+// lines (ErrCartFull).
+func (r *CartRepository) Add(schemaName, platform, chatID string, items []CartItem, maxLines int) error {
+	ctx := context.Background()
+	table := qualifyTable(schemaName, "cart_items")
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Adds to one cart wait for each other, so the line count below holds
+	if err := lockCart(ctx, tx, table, platform, chatID); err != nil {
+		return err
+	}
+	for i := range items {
+		items[i].Platform, items[i].ChatID = platform, chatID
+		if err := addCartItem(ctx, tx, table, &items[i]); err != nil {
+			return err
+		}
+	}
+	var lines int
+	if err := tx.QueryRow(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE platform = $1 AND chat_id = $2", table), platform, chatID).Scan(&lines); err != nil {
+		return err
+	}
+	if lines > maxLines {
+		return ErrCartFull
+	}
+	return tx.Commit(ctx)
+}
+
+// lockCart serializes the transactions that change one chat's cart until
+// the transaction ends. Row locks cannot do this for an empty cart.
+func lockCart(ctx context.Context, tx pgx.Tx, table, platform, chatID string) error {
+	_, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", table+":"+platform+":"+chatID)
+	return err
+}
+
+// takeCartItems removes the given lines of a chat's cart inside tx, locking
+// them first. Fails with ErrCartChanged unless every line is still there, so
+// a cart is ordered once however often the order is confirmed.
+func takeCartItems(ctx context.Context, tx pgx.Tx, schemaName, platform, chatID string, ids []int) error {
+	table := qualifyTable(schemaName, "cart_items")
+	if err := lockCart(ctx, tx, table, platform, chatID); err != nil {
+		return err
+	}
+	var locked int
+	err := tx.QueryRow(ctx, fmt.Sprintf(`
+		SELECT COUNT(*) FROM (
+			SELECT id FROM %s WHERE id = ANY($1) AND platform = $2 AND chat_id = $3 FOR UPDATE
+		) AS lines
+	`, table), ids, platform, chatID).Scan(&locked)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 || locked != len(ids) {
+		return ErrCartChanged
+	}
+	_, err = tx.Exec(ctx, fmt.Sprintf("DELETE FROM %s WHERE id = ANY($1)", table), ids)
+	return err
+}
+
+func addCartItem(ctx context.Context, tx pgx.Tx, table string, item *CartItem) error {
+	err := tx.QueryRow(ctx, fmt.Sprintf(`
+		UPDATE %[1]s SET quantity = quantity + $1
+		WHERE id = (
+			SELECT id FROM %[1]s
+			WHERE platform = $2 AND chat_id = $3 AND table_name = $4 AND LOWER(product) = LOWER($5) AND weight_grams = $6 AND unit = $7
+			ORDER BY id LIMIT 1
+		)
+		RETURNING id, quantity::float8, created_at
+	`, table), item.Quantity, item.Platform, item.ChatID, item.TableName, item.Product, item.WeightGrams, item.Unit).Scan(&item.ID, &item.Quantity, &item.CreatedAt)
+	if err != pgx.ErrNoRows {
+		return err
+	}
+	return tx.QueryRow(ctx, fmt.Sprintf(`
+		INSERT INTO %s (platform, chat_id, table_name, product, quantity, unit, weight_grams)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`, table), item.Platform, item.ChatID, item.TableName, item.Product, item.Quantity, item.Unit, item.WeightGrams).Scan(&item.ID, &item.CreatedAt)
+}
+
+// Remove deletes one item of a chat's cart. Returns false if it does not exist.
+func (r *CartRepository) Remove(schemaName, platform, chatID string, id int) (bool, error) {
+	tag, err := r.db.Exec(context.Background(),
+		fmt.Sprintf("DELETE FROM %s WHERE id = $1 AND platform = $2 AND chat_id = $3", qualifyTable(schemaName, "cart_items")), id, platform, chatID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// Clear empties a chat's cart
+func (r *CartRepository) Clear(schemaName, platform, chatID string) error {
+	_, err := r.db.Exec(context.Background(),
+		fmt.Sprintf("DELETE FROM %s WHERE platform = $1 AND chat_id = $2", qualifyTable(schemaName, "cart_items")), platform, chatID)
+	return err
+}
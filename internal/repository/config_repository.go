@@ -16,6 +16,17 @@ type BotConfig struct {
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// Menu item actions
+const (
+	MenuActionViewTable = "view_table"
+	MenuActionReply     = "reply"
+	MenuActionCalculate = "calculate_from_table"
+	MenuActionSubmenu   = "submenu" // Payload is the slug of the menu to open
+	MenuActionBack      = "back"    // Return to the parent menu
+	MenuActionHome      = "home"    // Return to main_menu
+	MenuActionHandoff   = "handoff" // Hand the chat over to a human agent
+)
+
 type MenuItem struct {
 	Label   string `json:"label"`
 	Action  string `json:"action"`
@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Contact is an end customer who has messaged a tenant's bot
+type Contact struct {
+	ID          int       `json:"id"`
+	Platform    string    `json:"platform"`
+	ChatID      string    `json:"chat_id"`
+	UserID      int       `json:"user_id"`      // Bot owner that talks to this contact (0 = platform bot)
+	DisplayName string    `json:"display_name"` // WhatsApp push name or Telegram username
+	Language    string    `json:"language"`
+	Tags        []string  `json:"tags"`
+	OptedOut    bool      `json:"opted_out"`
+	FirstSeenAt time.Time `json:"first_seen_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+}
+
+// ContactFilter narrows a contact listing; zero values match everything
+type ContactFilter struct {
+	Platform string
+	Tag      string
+	Search   string // Matches chat ID or display name
+	Limit    int    // 0 = no limit
+	Offset   int
+}
+
+type ContactRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewContactRepository(db *pgxpool.Pool) *ContactRepository {
+	return &ContactRepository{db: db}
+}
+
+const contactColumns = `id, platform, chat_id, COALESCE(user_id, 0), COALESCE(display_name, ''), COALESCE(language, ''),
+	COALESCE(tags, '{}'), COALESCE(opted_out, false), first_seen_at, last_seen_at`
+
+func scanContact(row pgx.Row) (*Contact, error) {
+	var c Contact
+	err := row.Scan(&c.ID, &c.Platform, &c.ChatID, &c.UserID, &c.DisplayName, &c.Language,
+		&c.Tags, &c.OptedOut, &c.FirstSeenAt, &c.LastSeenAt)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Touch records that a contact was seen now, creating it on first contact.
+// Empty name/language keep the stored values.
+func (r *ContactRepository) Touch(schemaName, platform, chatID string, userID int, displayName, language string) error {
+	table := qualifyTable(schemaName, "contacts")
+	_, err := r.db.Exec(context.Background(), fmt.Sprintf(`
+		INSERT INTO %[1]s (platform, chat_id, user_id, display_name, language)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''))
+		ON CONFLICT (platform, chat_id)
+		DO UPDATE SET
+			user_id = EXCLUDED.user_id,
+			display_name = COALESCE(EXCLUDED.display_name, %[1]s.display_name),
+			language = COALESCE(EXCLUDED.language, %[1]s.language),
+			last_seen_at = NOW()
+	`, table), platform, chatID, userID, displayName, language)
+	return err
+}
+
+// List returns contacts matching the filter, most recently seen first
+func (r *ContactRepository) List(schemaName string, f ContactFilter) ([]Contact, error) {
+	where, args := contactWhere(f)
+	query := fmt.Sprintf("SELECT %s FROM %s %s ORDER BY last_seen_at DESC, id DESC",
+		contactColumns, qualifyTable(schemaName, "contacts"), where)
+	if f.Limit > 0 {
+		args = append(args, f.Limit, f.Offset)
+		query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+	}
+
+	rows, err := r.db.Query(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	contacts := []Contact{}
+	for rows.Next() {
+		c, err := scanContact(rows)
+		if err != nil {
+			return nil, err
+		}
+		contacts = append(contacts, *c)
+	}
+	return contacts, rows.Err()
+}
+
+// Count returns how many contacts match the filter (ignoring limit/offset)
+func (r *ContactRepository) Count(schemaName string, f ContactFilter) (int, error) {
+	where, args := contactWhere(f)
+	var n int
+	err := r.db.QueryRow(context.Background(),
+		fmt.Sprintf("SELECT COUNT(*) FROM %s %s", qualifyTable(schemaName, "contacts"), where), args...).Scan(&n)
+	return n, err
+}
+
+func contactWhere(f ContactFilter) (string, []any) {
+	var conds []string
+	var args []any
+	if f.Platform != "" {
+		args = append(args, f.Platform)
+		conds = append(conds, fmt.Sprintf("platform = $%d", len(args)))
+	}
+	if f.Tag != "" {
+		args = append(args, f.Tag)
+		conds = append(conds, fmt.Sprintf("$%d = ANY(tags)", len(args)))
+	}
+	if f.Search != "" {
+		args = append(args, "%"+f.Search+"%")
+		conds = append(conds, fmt.Sprintf("(chat_id ILIKE $%[1]d OR display_name ILIKE $%[1]d)", len(args)))
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return "WHERE " + strings.Join(conds, " AND "), args
+}
+
+// Get returns a contact by ID (nil if not found)
+func (r *ContactRepository) Get(schemaName string, id int) (*Contact, error) {
+	c, err := scanContact(r.db.QueryRow(context.Background(),
+		fmt.Sprintf("SELECT %s FROM %s WHERE id = $1", contactColumns, qualifyTable(schemaName, "contacts")), id))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return c, err
+}
+
+// Create adds a contact manually (e.g. imported from another system)
+func (r *ContactRepository) Create(schemaName string, c *Contact) error {
+	if c.Tags == nil {
+		c.Tags = []string{}
+	}
+	return r.db.QueryRow(context.Background(), fmt.Sprintf(`
+		INSERT INTO %s (platform, chat_id, user_id, display_name, language, tags, opted_out)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6, $7)
+		RETURNING id, first_seen_at, last_seen_at
+	`, qualifyTable(schemaName, "contacts")),
+		c.Platform, c.ChatID, c.UserID, c.DisplayName, c.Language, c.Tags, c.OptedOut,
+	).Scan(&c.ID, &c.FirstSeenAt, &c.LastSeenAt)
+}
+
+// Update saves the editable fields of a contact. Returns false if it does not exist.
+func (r *ContactRepository) Update(schemaName string, c *Contact) (bool, error) {
+	if c.Tags == nil {
+		c.Tags = []string{}
+	}
+	tag, err := r.db.Exec(context.Background(), fmt.Sprintf(`
+		UPDATE %s SET display_name = NULLIF($1, ''), language = NULLIF($2, ''), tags = $3, opted_out = $4
+		WHERE id = $5
+	`, qualifyTable(schemaName, "contacts")), c.DisplayName, c.Language, c.Tags, c.OptedOut, c.ID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// Delete removes a contact. Returns false if it does not exist.
+func (r *ContactRepository) Delete(schemaName string, id int) (bool, error) {
+	tag, err := r.db.Exec(context.Background(),
+		fmt.Sprintf("DELETE FROM %s WHERE id = $1", qualifyTable(schemaName, "contacts")), id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// Find returns the contact for a chat (nil if never seen)
+func (r *ContactRepository) Find(schemaName, platform, chatID string) (*Contact, error) {
+	c, err := scanContact(r.db.QueryRow(context.Background(),
+		fmt.Sprintf("SELECT %s FROM %s WHERE platform = $1 AND chat_id = $2", contactColumns, qualifyTable(schemaName, "contacts")),
+		platform, chatID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return c, err
+}
+
+// SetOptOut marks whether a contact refuses broadcast messages
+func (r *ContactRepository) SetOptOut(schemaName, platform, chatID string, optedOut bool) error {
+	table := qualifyTable(schemaName, "contacts")
+	_, err := r.db.Exec(context.Background(), fmt.Sprintf(`
+		UPDATE %s SET opted_out = $1 WHERE platform = $2 AND chat_id = $3
+	`, table), optedOut, platform, chatID)
+	return err
+}
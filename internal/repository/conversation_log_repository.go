@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Conversation log directions
+const (
+	DirectionIncoming = "incoming"
+	DirectionOutgoing = "outgoing"
+)
+
+// ConversationLog is one inbound or outbound chat message
+type ConversationLog struct {
+	ID         int64     `json:"id"`
+	UserID     int       `json:"user_id"` // Tenant owner (0 = platform bot)
+	SchemaName string    `json:"schema_name"`
+	Platform   string    `json:"platform"`
+	ChatID     string    `json:"chat_id"`
+	Direction  string    `json:"direction"`
+	Content    string    `json:"content"`
+	MenuAction string    `json:"menu_action,omitempty"`
+	LatencyMs  *int      `json:"latency_ms,omitempty"` // Outgoing only: time since the inbound message
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ChatSummary is one chat in a tenant's conversation list
+type ChatSummary struct {
+	Platform      string    `json:"platform"`
+	ChatID        string    `json:"chat_id"`
+	MessageCount  int       `json:"message_count"`
+	LastMessage   string    `json:"last_message"`
+	LastDirection string    `json:"last_direction"`
+	LastAt        time.Time `json:"last_at"`
+}
+
+type ConversationLogRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewConversationLogRepository(db *pgxpool.Pool) *ConversationLogRepository {
+	return &ConversationLogRepository{db: db}
+}
+
+// Insert records a message
+func (r *ConversationLogRepository) Insert(l *ConversationLog) error {
+	var userID *int
+	if l.UserID != 0 {
+		userID = &l.UserID
+	}
+	var menuAction *string
+	if l.MenuAction != "" {
+		menuAction = &l.MenuAction
+	}
+	return r.db.QueryRow(context.Background(), `
+		INSERT INTO conversation_logs (user_id, schema_name, platform, chat_id, direction, content, menu_action, latency_ms)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`, userID, l.SchemaName, l.Platform, l.ChatID, l.Direction, l.Content, menuAction, l.LatencyMs).Scan(&l.ID, &l.CreatedAt)
+}
+
+// ListChats returns the tenant's chats, most recently active first.
+// platform is optional.
+func (r *ConversationLogRepository) ListChats(schemaName, platform string, limit, offset int) ([]ChatSummary, error) {
+	rows, err := r.db.Query(context.Background(), `
+		SELECT platform, chat_id, message_count, content, direction, created_at
+		FROM (
+			SELECT DISTINCT ON (platform, chat_id)
+				platform, chat_id,
+				COUNT(*) OVER (PARTITION BY platform, chat_id) AS message_count,
+				content, direction, created_at
+			FROM conversation_logs
+			WHERE schema_name = $1 AND ($2 = '' OR platform = $2)
+			ORDER BY platform, chat_id, created_at DESC, id DESC
+		) latest
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4
+	`, schemaName, platform, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	chats := []ChatSummary{}
+	for rows.Next() {
+		var c ChatSummary
+		if err := rows.Scan(&c.Platform, &c.ChatID, &c.MessageCount, &c.LastMessage, &c.LastDirection, &c.LastAt); err != nil {
+			return nil, err
+		}
+		chats = append(chats, c)
+	}
+	return chats, rows.Err()
+}
+
+// Transcript returns a page of one chat's messages in chronological order.
+// beforeID pages backwards from an earlier result (0 = latest messages).
+func (r *ConversationLogRepository) Transcript(schemaName, platform, chatID string, beforeID int64, limit int) ([]ConversationLog, error) {
+	rows, err := r.db.Query(context.Background(), `
+		SELECT * FROM (
+			SELECT id, COALESCE(user_id, 0), schema_name, platform, chat_id, direction, content,
+				COALESCE(menu_action, ''), latency_ms, created_at
+			FROM conversation_logs
+			WHERE schema_name = $1 AND platform = $2 AND chat_id = $3 AND ($4 = 0 OR id < $4)
+			ORDER BY id DESC
+			LIMIT $5
+		) page ORDER BY id ASC
+	`, schemaName, platform, chatID, beforeID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanConversationLogs(rows)
+}
+
+// LastInbound returns the most recent message received from a chat (nil if none)
+func (r *ConversationLogRepository) LastInbound(schemaName, platform, chatID string) (*ConversationLog, error) {
+	rows, err := r.db.Query(context.Background(), `
+		SELECT id, COALESCE(user_id, 0), schema_name, platform, chat_id, direction, content,
+			COALESCE(menu_action, ''), latency_ms, created_at
+		FROM conversation_logs
+		WHERE schema_name = $1 AND platform = $2 AND chat_id = $3 AND direction = $4
+		ORDER BY id DESC
+		LIMIT 1
+	`, schemaName, platform, chatID, DirectionIncoming)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	logs, err := scanConversationLogs(rows)
+	if err != nil || len(logs) == 0 {
+		return nil, err
+	}
+	return &logs[0], nil
+}
+
+// Search runs a full-text search over the tenant's messages, best matches first
+func (r *ConversationLogRepository) Search(schemaName, query string, limit, offset int) ([]ConversationLog, error) {
+	rows, err := r.db.Query(context.Background(), `
+		SELECT id, COALESCE(user_id, 0), schema_name, platform, chat_id, direction, content,
+			COALESCE(menu_action, ''), latency_ms, created_at
+		FROM conversation_logs
+		WHERE schema_name = $1 AND search_vector @@ plainto_tsquery('simple', $2)
+		ORDER BY ts_rank(search_vector, plainto_tsquery('simple', $2)) DESC, created_at DESC
+		LIMIT $3 OFFSET $4
+	`, schemaName, query, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanConversationLogs(rows)
+}
+
+func scanConversationLogs(rows pgx.Rows) ([]ConversationLog, error) {
+	logs := []ConversationLog{}
+	for rows.Next() {
+		var l ConversationLog
+		if err := rows.Scan(&l.ID, &l.UserID, &l.SchemaName, &l.Platform, &l.ChatID, &l.Direction, &l.Content, &l.MenuAction, &l.LatencyMs, &l.CreatedAt); err != nil {
+			return nil, err
+		}
+		logs = append(logs, l)
+	}
+	return logs, rows.Err()
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ConversationState is the persisted state of one chat with a tenant's bot
+type ConversationState struct {
+	SchemaName string            `json:"schema_name"`
+	Platform   string            `json:"platform"`
+	ChatID     string            `json:"chat_id"`
+	State      string            `json:"state"`
+	Data       map[string]string `json:"data"`
+	ExpiresAt  *time.Time        `json:"expires_at"` // nil = never expires
+	UpdatedAt  time.Time         `json:"updated_at"`
+}
+
+// Expired reports whether the state has passed its timeout
+func (s *ConversationState) Expired(now time.Time) bool {
+	return s.ExpiresAt != nil && now.After(*s.ExpiresAt)
+}
+
+type ConversationRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewConversationRepository(db *pgxpool.Pool) *ConversationRepository {
+	return &ConversationRepository{db: db}
+}
+
+// Get returns the stored state for a chat (nil if none)
+func (r *ConversationRepository) Get(schemaName, platform, chatID string) (*ConversationState, error) {
+	s := ConversationState{SchemaName: schemaName, Platform: platform, ChatID: chatID}
+	var data []byte
+	err := r.db.QueryRow(context.Background(), `
+		SELECT state, data, expires_at, updated_at
+		FROM conversation_states
+		WHERE schema_name = $1 AND platform = $2 AND chat_id = $3
+	`, schemaName, platform, chatID).Scan(&s.State, &data, &s.ExpiresAt, &s.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.Data = map[string]string{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &s.Data); err != nil {
+			return nil, err
+		}
+	}
+	return &s, nil
+}
+
+// Save upserts the state for a chat
+func (r *ConversationRepository) Save(s *ConversationState) error {
+	data, err := json.Marshal(s.Data)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(context.Background(), `
+		INSERT INTO conversation_states (schema_name, platform, chat_id, state, data, expires_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		ON CONFLICT (schema_name, platform, chat_id)
+		DO UPDATE SET state = EXCLUDED.state, data = EXCLUDED.data, expires_at = EXCLUDED.expires_at, updated_at = NOW()
+	`, s.SchemaName, s.Platform, s.ChatID, s.State, data, s.ExpiresAt)
+	return err
+}
+
+// ListByState returns a tenant's unexpired chats in any of the given states, oldest update first
+func (r *ConversationRepository) ListByState(schemaName string, states []string) ([]*ConversationState, error) {
+	rows, err := r.db.Query(context.Background(), `
+		SELECT platform, chat_id, state, data, expires_at, updated_at
+		FROM conversation_states
+		WHERE schema_name = $1 AND state = ANY($2) AND (expires_at IS NULL OR expires_at > NOW())
+		ORDER BY updated_at ASC
+	`, schemaName, states)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []*ConversationState{}
+	for rows.Next() {
+		s := ConversationState{SchemaName: schemaName, Data: map[string]string{}}
+		var data []byte
+		if err := rows.Scan(&s.Platform, &s.ChatID, &s.State, &data, &s.ExpiresAt, &s.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &s.Data); err != nil {
+				return nil, err
+			}
+		}
+		result = append(result, &s)
+	}
+	return result, rows.Err()
+}
+
+// Delete removes the state for a chat
+func (r *ConversationRepository) Delete(schemaName, platform, chatID string) error {
+	_, err := r.db.Exec(context.Background(), `
+		DELETE FROM conversation_states
+		WHERE schema_name = $1 AND platform = $2 AND chat_id = $3
+	`, schemaName, platform, chatID)
+	return err
+}
+
+// DeleteExpired removes all states whose timeout has passed
+func (r *ConversationRepository) DeleteExpired() (int64, error) {
+	tag, err := r.db.Exec(context.Background(), `
+		DELETE FROM conversation_states WHERE expires_at IS NOT NULL AND expires_at < NOW()
+	`)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ExchangeRate converts between two currencies: 1 Base = Rate Quote
+type ExchangeRate struct {
+	ID        int       `json:"id"`
+	Base      string    `json:"base"`  // ISO 4217 code, e.g. USD
+	Quote     string    `json:"quote"` // ISO 4217 code, e.g. IDR
+	Rate      float64   `json:"rate"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ExchangeRateRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewExchangeRateRepository(db *pgxpool.Pool) *ExchangeRateRepository {
+	return &ExchangeRateRepository{db: db}
+}
+
+// ExchangeRatesTableDDL creates the exchange rates table of a schema
+func ExchangeRatesTableDDL(schemaName string) string {
+	return fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %[1]s.exchange_rates (
+			id SERIAL PRIMARY KEY,
+			base_currency VARCHAR(3) NOT NULL,
+			quote_currency VARCHAR(3) NOT NULL,
+			rate NUMERIC NOT NULL CHECK (rate > 0), -- 1 base = rate quote
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (base_currency, quote_currency)
+		)
+	`, schemaName)
+}
+
+const exchangeRateColumns = `id, base_currency, quote_currency, rate::float8, updated_at`
+
+func scanExchangeRate(row pgx.Row) (*ExchangeRate, error) {
+	var r ExchangeRate
+	if err := row.Scan(&r.ID, &r.Base, &r.Quote, &r.Rate, &r.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// List returns a tenant's rates by currency pair
+func (r *ExchangeRateRepository) List(schemaName string) ([]ExchangeRate, error) {
+	rows, err := r.db.Query(context.Background(), fmt.Sprintf("SELECT %s FROM %s ORDER BY base_currency, quote_currency",
+		exchangeRateColumns, qualifyTable(schemaName, "exchange_rates")))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rates := []ExchangeRate{}
+	for rows.Next() {
+		rate, err := scanExchangeRate(rows)
+		if err != nil {
+			return nil, err
+		}
+		rates = append(rates, *rate)
+	}
+	return rates, rows.Err()
+}
+
+// Find returns the rate of a currency pair in either direction (nil if not found)
+func (r *ExchangeRateRepository) Find(schemaName, from, to string) (*ExchangeRate, error) {
+	rate, err := scanExchangeRate(r.db.QueryRow(context.Background(), fmt.Sprintf(`
+		SELECT %s FROM %s
+		WHERE (base_currency = $1 AND quote_currency = $2) OR (base_currency = $2 AND quote_currency = $1)
+		ORDER BY base_currency = $1 DESC
+		LIMIT 1
+	`, exchangeRateColumns, qualifyTable(schemaName, "exchange_rates")), from, to))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return rate, err
+}
+
+// Upsert sets the rate of a currency pair, replacing any earlier rate
+func (r *ExchangeRateRepository) Upsert(schemaName string, rate *ExchangeRate) error {
+	return r.db.QueryRow(context.Background(), fmt.Sprintf(`
+		INSERT INTO %s (base_currency, quote_currency, rate, updated_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (base_currency, quote_currency) DO UPDATE SET rate = EXCLUDED.rate, updated_at = NOW()
+		RETURNING id, updated_at
+	`, qualifyTable(schemaName, "exchange_rates")), rate.Base, rate.Quote, rate.Rate).Scan(&rate.ID, &rate.UpdatedAt)
+}
+
+// Delete removes a rate. Returns false if it does not exist.
+func (r *ExchangeRateRepository) Delete(schemaName string, id int) (bool, error) {
+	tag, err := r.db.Exec(context.Background(),
+		fmt.Sprintf("DELETE FROM %s WHERE id = $1", qualifyTable(schemaName, "exchange_rates")), id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
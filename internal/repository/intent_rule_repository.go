@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Intent rule match types
+const (
+	MatchExact    = "exact"
+	MatchContains = "contains"
+	MatchPrefix   = "prefix"
+	MatchRegex    = "regex"
+)
+
+// IntentRule maps a keyword or pattern in an incoming message to a bot action.
+// Rules are evaluated by priority (highest first); the first match wins.
+type IntentRule struct {
+	ID        int       `json:"id"`
+	Pattern   string    `json:"pattern"`
+	MatchType string    `json:"match_type"`
+	Priority  int       `json:"priority"`
+	Language  string    `json:"language"` // Empty = any language
+	Action    string    `json:"action"`
+	Payload   string    `json:"payload"`
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type IntentRuleRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewIntentRuleRepository(db *pgxpool.Pool) *IntentRuleRepository {
+	return &IntentRuleRepository{db: db}
+}
+
+// IntentRulesTableDDL creates the keyword/regex rules table of a schema
+func IntentRulesTableDDL(schemaName string) string {
+	return fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %[1]s.intent_rules (
+			id SERIAL PRIMARY KEY,
+			pattern VARCHAR(512) NOT NULL,
+			match_type VARCHAR(16) NOT NULL DEFAULT 'contains', -- exact, contains, prefix, regex
+			priority INTEGER NOT NULL DEFAULT 0,
+			language VARCHAR(16), -- NULL = any language
+			action VARCHAR(50) NOT NULL,
+			payload TEXT,
+			enabled BOOLEAN DEFAULT TRUE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_intent_rules_priority ON %[1]s.intent_rules(priority DESC, id)
+	`, schemaName)
+}
+
+const intentRuleColumns = `id, pattern, match_type, priority, COALESCE(language, ''), action, COALESCE(payload, ''), enabled, created_at`
+
+func scanIntentRule(row pgx.Row) (*IntentRule, error) {
+	var r IntentRule
+	if err := row.Scan(&r.ID, &r.Pattern, &r.MatchType, &r.Priority, &r.Language, &r.Action, &r.Payload, &r.Enabled, &r.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// List returns a tenant's rules in evaluation order
+func (r *IntentRuleRepository) List(schemaName string, enabledOnly bool) ([]IntentRule, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s", intentRuleColumns, qualifyTable(schemaName, "intent_rules"))
+	if enabledOnly {
+		query += " WHERE enabled"
+	}
+	rows, err := r.db.Query(context.Background(), query+" ORDER BY priority DESC, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []IntentRule{}
+	for rows.Next() {
+		rule, err := scanIntentRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, *rule)
+	}
+	return rules, rows.Err()
+}
+
+// Get returns a rule by ID (nil if not found)
+func (r *IntentRuleRepository) Get(schemaName string, id int) (*IntentRule, error) {
+	rule, err := scanIntentRule(r.db.QueryRow(context.Background(),
+		fmt.Sprintf("SELECT %s FROM %s WHERE id = $1", intentRuleColumns, qualifyTable(schemaName, "intent_rules")), id))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return rule, err
+}
+
+// Create adds a rule
+func (r *IntentRuleRepository) Create(schemaName string, rule *IntentRule) error {
+	return r.db.QueryRow(context.Background(), fmt.Sprintf(`
+		INSERT INTO %s (pattern, match_type, priority, language, action, payload, enabled)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, NULLIF($6, ''), $7)
+		RETURNING id, created_at
+	`, qualifyTable(schemaName, "intent_rules")),
+		rule.Pattern, rule.MatchType, rule.Priority, rule.Language, rule.Action, rule.Payload, rule.Enabled,
+	).Scan(&rule.ID, &rule.CreatedAt)
+}
+
+// Update saves a rule. Returns false if it does not exist.
+func (r *IntentRuleRepository) Update(schemaName string, rule *IntentRule) (bool, error) {
+	tag, err := r.db.Exec(context.Background(), fmt.Sprintf(`
+		UPDATE %s SET pattern = $1, match_type = $2, priority = $3, language = NULLIF($4, ''),
+			action = $5, payload = NULLIF($6, ''), enabled = $7
+		WHERE id = $8
+	`, qualifyTable(schemaName, "intent_rules")),
+		rule.Pattern, rule.MatchType, rule.Priority, rule.Language, rule.Action, rule.Payload, rule.Enabled, rule.ID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// Delete removes a rule. Returns false if it does not exist.
+func (r *IntentRuleRepository) Delete(schemaName string, id int) (bool, error) {
+	tag, err := r.db.Exec(context.Background(),
+		fmt.Sprintf("DELETE FROM %s WHERE id = $1", qualifyTable(schemaName, "intent_rules")), id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// ReplaceAll swaps a tenant's rules for the given set in one transaction
+func (r *IntentRuleRepository) ReplaceAll(schemaName string, rules []IntentRule) error {
+	ctx := context.Background()
+	table := qualifyTable(schemaName, "intent_rules")
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "DELETE FROM "+table); err != nil {
+		return err
+	}
+	for _, rule := range rules {
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`
+			INSERT INTO %s (pattern, match_type, priority, language, action, payload, enabled)
+			VALUES ($1, $2, $3, NULLIF($4, ''), $5, NULLIF($6, ''), $7)
+		`, table), rule.Pattern, rule.MatchType, rule.Priority, rule.Language, rule.Action, rule.Payload, rule.Enabled); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
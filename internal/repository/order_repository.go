@@ -0,0 +1,341 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Order statuses
+const (
+	OrderNew       = "new" // Confirmed by the customer in chat, not yet by staff
+	OrderConfirmed = "confirmed"
+	OrderPaid      = "paid"
+	OrderShipped   = "shipped"
+	OrderCancelled = "cancelled"
+)
+
+// OrderItem is a priced product line, frozen when the order is placed
+type OrderItem struct {
+	Product   string  `json:"product"`
+	TableName string  `json:"table_name"`
+	Quantity  float64 `json:"quantity"`
+	Unit      string  `json:"unit"`
+	ListPrice float64 `json:"list_price"`
+	UnitPrice float64 `json:"unit_price"`
+	Tier      string  `json:"tier,omitempty"`
+	Amount    float64 `json:"amount"`
+}
+
+// OrderLine is a discount (negative) or tax line of an order
+type OrderLine struct {
+	Label  string  `json:"label"`
+	Amount float64 `json:"amount"`
+}
+
+// Order is a quote a customer confirmed in chat
+type Order struct {
+	ID          int         `json:"id"`
+	Number      string      `json:"number"` // ORD-YYMMDD-00001, shown to the customer
+	Platform    string      `json:"platform"`
+	ChatID      string      `json:"chat_id"`
+	UserID      int         `json:"user_id"` // Bot owner that received the order (0 = platform bot)
+	ContactName string      `json:"contact_name"`
+	Items       []OrderItem `json:"items"`
+	Discounts   []OrderLine `json:"discounts"`
+	Taxes       []OrderLine `json:"taxes"`
+	Currency    string      `json:"currency"`
+	Subtotal    float64     `json:"subtotal"`
+	Total       float64     `json:"total"`
+	Status      string      `json:"status"`
+	StatusNote  string      `json:"status_note,omitempty"` // Staff note sent with the last status change
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+
+	History []OrderStatusChange `json:"history,omitempty"` // Loaded by Get, oldest first
+}
+
+// OrderStatusChange is one entry of an order's status history
+type OrderStatusChange struct {
+	Status    string    `json:"status"`
+	Note      string    `json:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// OrderFilter narrows an order listing; zero values match everything
+type OrderFilter struct {
+	Status   string
+	Platform string
+	ChatID   string
+	Search   string // Matches order number, contact name or chat ID
+	Limit    int    // 0 = no limit
+	Offset   int
+}
+
+type OrderRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewOrderRepository(db *pgxpool.Pool) *OrderRepository {
+	return &OrderRepository{db: db}
+}
+
+// OrdersTableDDL creates the orders table of a schema
+func OrdersTableDDL(schemaName string) string {
+	return fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %[1]s.orders (
+			id SERIAL PRIMARY KEY,
+			number VARCHAR(32) UNIQUE,
+			platform VARCHAR(20) NOT NULL,
+			chat_id VARCHAR(100) NOT NULL,
+			user_id INTEGER,
+			contact_name VARCHAR(255),
+			items JSONB NOT NULL DEFAULT '[]',
+			discounts JSONB NOT NULL DEFAULT '[]',
+			taxes JSONB NOT NULL DEFAULT '[]',
+			currency VARCHAR(10) NOT NULL,
+			subtotal NUMERIC NOT NULL,
+			total NUMERIC NOT NULL,
+			status VARCHAR(16) NOT NULL DEFAULT 'new', -- new, confirmed, paid, shipped, cancelled
+			status_note TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_orders_chat ON %[1]s.orders(platform, chat_id, id);
+		CREATE INDEX IF NOT EXISTS idx_orders_status ON %[1]s.orders(status, created_at)
+	`, schemaName)
+}
+
+// OrderHistoryTableDDL creates the order status history of a schema
+func OrderHistoryTableDDL(schemaName string) string {
+	return fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %[1]s.order_status_history (
+			id SERIAL PRIMARY KEY,
+			order_id INTEGER NOT NULL REFERENCES %[1]s.orders(id) ON DELETE CASCADE,
+			status VARCHAR(16) NOT NULL,
+			note TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_order_status_history_order ON %[1]s.order_status_history(order_id, id)
+	`, schemaName)
+}
+
+const orderColumns = `id, COALESCE(number, ''), platform, chat_id, COALESCE(user_id, 0), COALESCE(contact_name, ''),
+	items, discounts, taxes, currency, subtotal::float8, total::float8, status, COALESCE(status_note, ''), created_at, updated_at`
+
+func scanOrder(row pgx.Row) (*Order, error) {
+	var o Order
+	err := row.Scan(&o.ID, &o.Number, &o.Platform, &o.ChatID, &o.UserID, &o.ContactName,
+		&o.Items, &o.Discounts, &o.Taxes, &o.Currency, &o.Subtotal, &o.Total, &o.Status, &o.StatusNote, &o.CreatedAt, &o.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+func collectOrders(rows pgx.Rows) ([]Order, error) {
+	defer rows.Close()
+	orders := []Order{}
+	for rows.Next() {
+		o, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, *o)
+	}
+	return orders, rows.Err()
+}
+
+// Create stores a new order and assigns its number. An order placed from a
+// cart passes the cart lines it was priced from; they are removed in the same
+// transaction, or nothing is stored (ErrCartChanged).
+func (r *OrderRepository) Create(schemaName string, o *Order, cartItemIDs []int) error {
+	ctx := context.Background()
+	table := qualifyTable(schemaName, "orders")
+	if o.Items == nil {
+		o.Items = []OrderItem{}
+	}
+	if o.Discounts == nil {
+		o.Discounts = []OrderLine{}
+	}
+	if o.Taxes == nil {
+		o.Taxes = []OrderLine{}
+	}
+	if o.Status == "" {
+		o.Status = OrderNew
+	}
+	itemsJSON, err := json.Marshal(o.Items)
+	if err != nil {
+		return err
+	}
+	discountsJSON, err := json.Marshal(o.Discounts)
+	if err != nil {
+		return err
+	}
+	taxesJSON, err := json.Marshal(o.Taxes)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if cartItemIDs != nil {
+		if err := takeCartItems(ctx, tx, schemaName, o.Platform, o.ChatID, cartItemIDs); err != nil {
+			return err
+		}
+	}
+	err = tx.QueryRow(ctx, fmt.Sprintf(`
+		INSERT INTO %s (platform, chat_id, user_id, contact_name, items, discounts, taxes, currency, subtotal, total, status)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, created_at, updated_at
+	`, table), o.Platform, o.ChatID, o.UserID, o.ContactName, itemsJSON, discountsJSON, taxesJSON, o.Currency, o.Subtotal, o.Total, o.Status,
+	).Scan(&o.ID, &o.CreatedAt, &o.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	err = tx.QueryRow(ctx, fmt.Sprintf(`
+		UPDATE %s SET number = 'ORD-' || to_char(created_at, 'YYMMDD') || '-' || lpad(id::text, 5, '0')
+		WHERE id = $1 RETURNING number
+	`, table), o.ID).Scan(&o.Number)
+	if err != nil {
+		return err
+	}
+	if err := addOrderHistory(ctx, tx, schemaName, o.ID, o.Status, ""); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// List returns orders matching the filter, newest first
+func (r *OrderRepository) List(schemaName string, f OrderFilter) ([]Order, error) {
+	where, args := orderWhere(f)
+	query := fmt.Sprintf("SELECT %s FROM %s %s ORDER BY id DESC", orderColumns, qualifyTable(schemaName, "orders"), where)
+	if f.Limit > 0 {
+		args = append(args, f.Limit, f.Offset)
+		query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+	}
+	rows, err := r.db.Query(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return collectOrders(rows)
+}
+
+// Count returns how many orders match the filter (ignoring limit/offset)
+func (r *OrderRepository) Count(schemaName string, f OrderFilter) (int, error) {
+	where, args := orderWhere(f)
+	var n int
+	err := r.db.QueryRow(context.Background(),
+		fmt.Sprintf("SELECT COUNT(*) FROM %s %s", qualifyTable(schemaName, "orders"), where), args...).Scan(&n)
+	return n, err
+}
+
+func orderWhere(f OrderFilter) (string, []any) {
+	var conds []string
+	var args []any
+	if f.Status != "" {
+		args = append(args, f.Status)
+		conds = append(conds, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if f.Platform != "" {
+		args = append(args, f.Platform)
+		conds = append(conds, fmt.Sprintf("platform = $%d", len(args)))
+	}
+	if f.ChatID != "" {
+		args = append(args, f.ChatID)
+		conds = append(conds, fmt.Sprintf("chat_id = $%d", len(args)))
+	}
+	if f.Search != "" {
+		args = append(args, "%"+f.Search+"%")
+		conds = append(conds, fmt.Sprintf("(number ILIKE $%[1]d OR contact_name ILIKE $%[1]d OR chat_id ILIKE $%[1]d)", len(args)))
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return "WHERE " + strings.Join(conds, " AND "), args
+}
+
+// Get returns an order by ID (nil if not found)
+func (r *OrderRepository) Get(schemaName string, id int) (*Order, error) {
+	o, err := scanOrder(r.db.QueryRow(context.Background(),
+		fmt.Sprintf("SELECT %s FROM %s WHERE id = $1", orderColumns, qualifyTable(schemaName, "orders")), id))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return o, err
+}
+
+// GetByNumber returns an order by its number, case-insensitively (nil if not found)
+func (r *OrderRepository) GetByNumber(schemaName, number string) (*Order, error) {
+	o, err := scanOrder(r.db.QueryRow(context.Background(),
+		fmt.Sprintf("SELECT %s FROM %s WHERE UPPER(number) = UPPER($1)", orderColumns, qualifyTable(schemaName, "orders")), number))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return o, err
+}
+
+// History returns an order's status changes, oldest first
+func (r *OrderRepository) History(schemaName string, orderID int) ([]OrderStatusChange, error) {
+	rows, err := r.db.Query(context.Background(), fmt.Sprintf(`
+		SELECT status, COALESCE(note, ''), created_at FROM %s WHERE order_id = $1 ORDER BY id
+	`, qualifyTable(schemaName, "order_status_history")), orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []OrderStatusChange{}
+	for rows.Next() {
+		var h OrderStatusChange
+		if err := rows.Scan(&h.Status, &h.Note, &h.CreatedAt); err != nil {
+			return nil, err
+		}
+		history = append(history, h)
+	}
+	return history, rows.Err()
+}
+
+// SetStatus moves an order to a new status if it is currently in one of
+// from, and records the change in its history. Returns false if the order
+// does not exist or is in another status.
+func (r *OrderRepository) SetStatus(schemaName string, id int, from []string, to, note string) (bool, error) {
+	ctx := context.Background()
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, fmt.Sprintf(`
+		UPDATE %s SET status = $1, status_note = NULLIF($2, ''), updated_at = NOW()
+		WHERE id = $3 AND status = ANY($4)
+	`, qualifyTable(schemaName, "orders")), to, note, id, from)
+	if err != nil {
+		return false, err
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+	if err := addOrderHistory(ctx, tx, schemaName, id, to, note); err != nil {
+		return false, err
+	}
+	return true, tx.Commit(ctx)
+}
+
+// addOrderHistory records a status change of an order
+func addOrderHistory(ctx context.Context, tx pgx.Tx, schemaName string, orderID int, status, note string) error {
+	_, err := tx.Exec(ctx, fmt.Sprintf(`
+		INSERT INTO %s (order_id, status, note) VALUES ($1, $2, NULLIF($3, ''))
+	`, qualifyTable(schemaName, "order_status_history")), orderID, status, note)
+	return err
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Pricing rule kinds
+const (
+	PricingTier     = "tier"     // Volume price from min_qty: value is the unit price (fixed) or % off it
+	PricingDiscount = "discount" // From min_qty: percent off each item, or a fixed amount off the order
+	PricingMinOrder = "moq"      // Minimum order quantity: value is the quantity
+	PricingTax      = "tax"      // Added to the order after discounts (e.g. PPN 11%): percent or fixed
+)
+
+// Pricing rule value types
+const (
+	PricingPercent = "percent"
+	PricingFixed   = "fixed"
+)
+
+// PricingRule adjusts the price of matching quote items. Empty table_name or
+// product matches every dataset or product; product matches part of the name.
+type PricingRule struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"` // Shown on the quote line ("PPN", "Diskon grosir")
+	Kind      string    `json:"kind"`
+	TableName string    `json:"table_name"`
+	Product   string    `json:"product"`
+	MinQty    float64   `json:"min_qty"`
+	MaxQty    float64   `json:"max_qty"` // 0 = no upper bound
+	Value     float64   `json:"value"`
+	ValueType string    `json:"value_type"`
+	Priority  int       `json:"priority"` // Higher is applied first
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type PricingRuleRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPricingRuleRepository(db *pgxpool.Pool) *PricingRuleRepository {
+	return &PricingRuleRepository{db: db}
+}
+
+// PricingRulesTableDDL creates the pricing rules table of a schema
+func PricingRulesTableDDL(schemaName string) string {
+	return fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %[1]s.pricing_rules (
+			id SERIAL PRIMARY KEY,
+			name VARCHAR(128) NOT NULL,
+			kind VARCHAR(16) NOT NULL, -- tier, discount, moq, tax
+			table_name VARCHAR(128), -- NULL = every dataset
+			product VARCHAR(256), -- NULL = every product
+			min_qty NUMERIC NOT NULL DEFAULT 0,
+			max_qty NUMERIC, -- NULL = no upper bound
+			value NUMERIC NOT NULL,
+			value_type VARCHAR(10) NOT NULL DEFAULT 'percent', -- percent, fixed
+			priority INTEGER NOT NULL DEFAULT 0,
+			enabled BOOLEAN DEFAULT TRUE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`, schemaName)
+}
+
+const pricingRuleColumns = `id, name, kind, COALESCE(table_name, ''), COALESCE(product, ''), min_qty::float8, COALESCE(max_qty, 0)::float8, value::float8, value_type, priority, enabled, created_at`
+
+func scanPricingRule(row pgx.Row) (*PricingRule, error) {
+	var r PricingRule
+	if err := row.Scan(&r.ID, &r.Name, &r.Kind, &r.TableName, &r.Product, &r.MinQty, &r.MaxQty, &r.Value, &r.ValueType, &r.Priority, &r.Enabled, &r.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// List returns a tenant's rules in evaluation order
+func (r *PricingRuleRepository) List(schemaName string, enabledOnly bool) ([]PricingRule, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s", pricingRuleColumns, qualifyTable(schemaName, "pricing_rules"))
+	if enabledOnly {
+		query += " WHERE enabled"
+	}
+	rows, err := r.db.Query(context.Background(), query+" ORDER BY priority DESC, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []PricingRule{}
+	for rows.Next() {
+		rule, err := scanPricingRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, *rule)
+	}
+	return rules, rows.Err()
+}
+
+// Get returns a rule by ID (nil if not found)
+func (r *PricingRuleRepository) Get(schemaName string, id int) (*PricingRule, error) {
+	rule, err := scanPricingRule(r.db.QueryRow(context.Background(),
+		fmt.Sprintf("SELECT %s FROM %s WHERE id = $1", pricingRuleColumns, qualifyTable(schemaName, "pricing_rules")), id))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return rule, err
+}
+
+// Create adds a rule
+func (r *PricingRuleRepository) Create(schemaName string, rule *PricingRule) error {
+	return r.db.QueryRow(context.Background(), fmt.Sprintf(`
+		INSERT INTO %s (name, kind, table_name, product, min_qty, max_qty, value, value_type, priority, enabled)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5, NULLIF($6::numeric, 0), $7, $8, $9, $10)
+		RETURNING id, created_at
+	`, qualifyTable(schemaName, "pricing_rules")),
+		rule.Name, rule.Kind, rule.TableName, rule.Product, rule.MinQty, rule.MaxQty, rule.Value, rule.ValueType, rule.Priority, rule.Enabled,
+	).Scan(&rule.ID, &rule.CreatedAt)
+}
+
+// Update saves a rule. Returns false if it does not exist.
+func (r *PricingRuleRepository) Update(schemaName string, rule *PricingRule) (bool, error) {
+	tag, err := r.db.Exec(context.Background(), fmt.Sprintf(`
+		UPDATE %s SET name = $1, kind = $2, table_name = NULLIF($3, ''), product = NULLIF($4, ''),
+			min_qty = $5, max_qty = NULLIF($6::numeric, 0), value = $7, value_type = $8, priority = $9, enabled = $10
+		WHERE id = $11
+	`, qualifyTable(schemaName, "pricing_rules")),
+		rule.Name, rule.Kind, rule.TableName, rule.Product, rule.MinQty, rule.MaxQty, rule.Value, rule.ValueType, rule.Priority, rule.Enabled, rule.ID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// Delete removes a rule. Returns false if it does not exist.
+func (r *PricingRuleRepository) Delete(schemaName string, id int) (bool, error) {
+	tag, err := r.db.Exec(context.Background(),
+		fmt.Sprintf("DELETE FROM %s WHERE id = $1", qualifyTable(schemaName, "pricing_rules")), id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
@@ -0,0 +1,391 @@
+package repository
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Dataset file formats for import and export
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+	FormatJSON = "json"
+)
+
+// ErrUnsupportedFormat is returned for file formats other than CSV, XLSX and JSON
+var ErrUnsupportedFormat = errors.New("unsupported file format")
+
+// DetectFormat picks the file format from a file name, defaulting to CSV
+func DetectFormat(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".xlsx", ".xlsm":
+		return FormatXLSX
+	case ".json":
+		return FormatJSON
+	}
+	return FormatCSV
+}
+
+// ReadRows parses an import file into a header row and data rows.
+// sheet selects the XLSX worksheet (default: the first one).
+func ReadRows(format, sheet string, data io.Reader) ([]string, [][]string, error) {
+	var rows [][]string
+	var err error
+	switch format {
+	case FormatCSV, "":
+		rows, err = csv.NewReader(data).ReadAll()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+	case FormatXLSX:
+		rows, err = readXLSX(sheet, data)
+		if err != nil {
+			return nil, nil, err
+		}
+	case FormatJSON:
+		return readJSON(data)
+	default:
+		return nil, nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+	if len(rows) < 1 {
+		return nil, nil, fmt.Errorf("file is empty")
+	}
+	return rows[0], rows[1:], nil
+}
+
+func readXLSX(sheet string, data io.Reader) ([][]string, error) {
+	f, err := excelize.OpenReader(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read XLSX: %w", err)
+	}
+	defer f.Close()
+
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, fmt.Errorf("workbook has no sheets")
+	}
+	if sheet == "" {
+		sheet = sheets[0]
+	}
+	rows, err := f.GetRows(sheet)
+	if err != nil {
+		return nil, fmt.Errorf("sheet '%s' not found (sheets: %s)", sheet, strings.Join(sheets, ", "))
+	}
+	// Spreadsheets often carry blank rows at the end
+	for len(rows) > 0 && strings.TrimSpace(strings.Join(rows[len(rows)-1], "")) == "" {
+		rows = rows[:len(rows)-1]
+	}
+	return rows, nil
+}
+
+// readJSON reads an array of flat objects. Headers are the keys in order of
+// first appearance; nested values are kept as JSON text.
+func readJSON(data io.Reader) ([]string, [][]string, error) {
+	objects, err := newJSONObjects(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var headers []string
+	index := map[string]int{}
+	var records []map[string]string
+	for {
+		keys, record, err := objects.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, key := range keys {
+			if _, ok := index[key]; !ok {
+				index[key] = len(headers)
+				headers = append(headers, key)
+			}
+		}
+		records = append(records, record)
+	}
+	if len(headers) == 0 {
+		return nil, nil, fmt.Errorf("file is empty")
+	}
+
+	rows := make([][]string, len(records))
+	for i, rec := range records {
+		row := make([]string, len(headers))
+		for k, v := range rec {
+			row[index[k]] = v
+		}
+		rows[i] = row
+	}
+	return headers, rows, nil
+}
+
+// jsonObjects decodes the objects of a JSON array one at a time
+type jsonObjects struct {
+	dec *json.Decoder
+}
+
+func newJSONObjects(data io.Reader) (*jsonObjects, error) {
+	dec := json.NewDecoder(data)
+	dec.UseNumber()
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return nil, fmt.Errorf("JSON import must be an array of objects")
+	}
+	return &jsonObjects{dec: dec}, nil
+}
+
+// next returns the keys of the next object in order, and its cells.
+// It returns io.EOF at the end of the array.
+func (j *jsonObjects) next() ([]string, map[string]string, error) {
+	if !j.dec.More() {
+		return nil, nil, io.EOF
+	}
+	if tok, err := j.dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, nil, fmt.Errorf("JSON import must be an array of objects")
+	}
+	var keys []string
+	record := map[string]string{}
+	for j.dec.More() {
+		tok, err := j.dec.Token()
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		key := tok.(string)
+		var raw json.RawMessage
+		if err := j.dec.Decode(&raw); err != nil {
+			return nil, nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		if _, ok := record[key]; !ok {
+			keys = append(keys, key)
+		}
+		record[key] = jsonCell(raw)
+	}
+	if _, err := j.dec.Token(); err != nil { // closing }
+		return nil, nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	return keys, record, nil
+}
+
+func jsonCell(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	if string(raw) == "null" {
+		return ""
+	}
+	return string(raw)
+}
+
+// ReimportFile merges a CSV, XLSX or JSON file into an existing dataset
+func (m *TableManager) ReimportFile(schemaName, nameOrDisplay, format, sheet string, data io.Reader, opts ReimportOptions) (*ReimportResult, error) {
+	headers, rows, err := ReadRows(format, sheet, data)
+	if err != nil {
+		return nil, err
+	}
+	return m.reimportRows(schemaName, nameOrDisplay, headers, rows, opts)
+}
+
+// ExportTable streams a dataset as CSV, XLSX or JSON. Columns use their
+// original headers so the file can be re-imported as is.
+func (m *TableManager) ExportTable(schemaName, nameOrDisplay, format string, w io.Writer) error {
+	if format != FormatCSV && format != FormatXLSX && format != FormatJSON {
+		return fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+	ctx := context.Background()
+	if schemaName == "" {
+		schemaName = "public"
+	}
+	tableName, err := m.resolveTable(ctx, m.db, schemaName, nameOrDisplay)
+	if err != nil {
+		return err
+	}
+	cols, err := m.GetColumns(schemaName, tableName)
+	if err != nil {
+		return err
+	}
+
+	headers := make([]string, len(cols))
+	names := make([]string, len(cols))
+	for i, c := range cols {
+		headers[i] = c.Header
+		if headers[i] == "" {
+			headers[i] = c.Name
+		}
+		names[i] = quoteIdent(c.Name)
+	}
+
+	rows, err := m.db.Query(ctx, fmt.Sprintf("SELECT %s FROM %s ORDER BY id", strings.Join(names, ", "), qualifyTable(schemaName, tableName)))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var out rowWriter
+	switch format {
+	case FormatCSV:
+		out = &csvRowWriter{w: csv.NewWriter(w)}
+	case FormatJSON:
+		out = &jsonRowWriter{w: w, headers: headers}
+	case FormatXLSX:
+		out = &xlsxRowWriter{w: w}
+	}
+	if err := out.Header(headers); err != nil {
+		return err
+	}
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return err
+		}
+		for i := range values {
+			values[i] = displayValue(values[i])
+		}
+		if err := out.Row(values); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// rowWriter writes exported rows in one file format
+type rowWriter interface {
+	Header(headers []string) error
+	Row(values []any) error
+	Close() error
+}
+
+// cellText formats a value for text formats; NULL becomes empty
+func cellText(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case bool:
+		return strconv.FormatBool(val)
+	}
+	return keyString(v)
+}
+
+type csvRowWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvRowWriter) Header(headers []string) error {
+	return c.w.Write(headers)
+}
+
+func (c *csvRowWriter) Row(values []any) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		record[i] = cellText(v)
+	}
+	return c.w.Write(record)
+}
+
+func (c *csvRowWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type jsonRowWriter struct {
+	w       io.Writer
+	headers []string
+	count   int
+}
+
+func (j *jsonRowWriter) Header(headers []string) error {
+	_, err := io.WriteString(j.w, "[")
+	return err
+}
+
+// Row writes one object with keys in column order
+func (j *jsonRowWriter) Row(values []any) error {
+	var sb strings.Builder
+	if j.count > 0 {
+		sb.WriteString(",")
+	}
+	sb.WriteString("\n{")
+	for i, v := range values {
+		key, _ := json.Marshal(j.headers[i])
+		val, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.Write(key)
+		sb.WriteString(":")
+		sb.Write(val)
+	}
+	sb.WriteString("}")
+	j.count++
+	_, err := io.WriteString(j.w, sb.String())
+	return err
+}
+
+func (j *jsonRowWriter) Close() error {
+	_, err := io.WriteString(j.w, "\n]\n")
+	return err
+}
+
+// xlsxRowWriter uses excelize's stream writer so large datasets are not held
+// as cell objects; the workbook is written out on Close
+type xlsxRowWriter struct {
+	w      io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+func (x *xlsxRowWriter) Header(headers []string) error {
+	x.file = excelize.NewFile()
+	stream, err := x.file.NewStreamWriter("Sheet1")
+	if err != nil {
+		return err
+	}
+	x.stream = stream
+	cells := make([]any, len(headers))
+	for i, h := range headers {
+		cells[i] = h
+	}
+	return x.Row(cells)
+}
+
+func (x *xlsxRowWriter) Row(values []any) error {
+	x.row++
+	cell, err := excelize.CoordinatesToCellName(1, x.row)
+	if err != nil {
+		return err
+	}
+	cells := make([]any, len(values))
+	for i, v := range values {
+		if t, ok := v.(time.Time); ok {
+			cells[i] = t.Format("2006-01-02")
+			continue
+		}
+		cells[i] = v
+	}
+	return x.stream.SetRow(cell, cells)
+}
+
+func (x *xlsxRowWriter) Close() error {
+	defer x.file.Close()
+	if err := x.stream.Flush(); err != nil {
+		return err
+	}
+	return x.file.Write(x.w)
+}
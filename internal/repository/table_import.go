@@ -0,0 +1,454 @@
+package repository
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/xuri/excelize/v2"
+)
+
+// ImportSource is an uploaded dataset file on disk. Imports read it twice,
+// once to type the columns and once to load them, without holding its rows.
+type ImportSource struct {
+	Path   string
+	Format string
+	Sheet  string // XLSX worksheet (default: the first one)
+}
+
+// Preview limits
+const (
+	previewSampleRows    = 10
+	maxPreviewMismatches = 20
+	importProgressEvery  = 1000 // Rows between progress callbacks
+)
+
+// ImportPreview is the dry run of an import; nothing is written
+type ImportPreview struct {
+	Headers          []string       `json:"headers"`
+	Columns          []ColumnSchema `json:"columns"`
+	Rows             int            `json:"rows"`
+	SampleRows       [][]string     `json:"sample_rows"`
+	Mismatches       []RowMismatch  `json:"mismatches"`
+	MismatchCount    int            `json:"mismatch_count"`
+	ConversionErrors []string       `json:"conversion_errors"` // Values that do not fit a requested column type
+}
+
+// RowMismatch is a row whose field count differs from the header. Short rows
+// are padded with empty cells and long rows truncated on import.
+type RowMismatch struct {
+	Row      int `json:"row"` // Counts the header line, like a spreadsheet
+	Fields   int `json:"fields"`
+	Expected int `json:"expected"`
+}
+
+// rowReader streams the data rows of an import file
+type rowReader interface {
+	Read() ([]string, error) // io.EOF after the last row
+	Close() error
+}
+
+// openRows opens an import file and returns its header row
+func openRows(src ImportSource) ([]string, rowReader, error) {
+	switch src.Format {
+	case FormatCSV, "":
+		return openCSVRows(src.Path)
+	case FormatXLSX:
+		return openXLSXRows(src.Path, src.Sheet)
+	case FormatJSON:
+		return openJSONRows(src.Path)
+	}
+	return nil, nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, src.Format)
+}
+
+type csvRows struct {
+	file *os.File
+	r    *csv.Reader
+}
+
+func openCSVRows(path string) ([]string, rowReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1 // Row length mismatches are reported, not fatal
+	headers, err := r.Read()
+	if err == io.EOF {
+		f.Close()
+		return nil, nil, fmt.Errorf("file is empty")
+	}
+	if err != nil {
+		f.Close()
+		return nil, nil, fmt.Errorf("failed to read CSV: %w", err)
+	}
+	return headers, &csvRows{file: f, r: r}, nil
+}
+
+func (c *csvRows) Read() ([]string, error) {
+	row, err := c.r.Read()
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read CSV: %w", err)
+	}
+	return row, err
+}
+
+func (c *csvRows) Close() error {
+	return c.file.Close()
+}
+
+// xlsxRows iterates a worksheet without loading all of its cells. Blank rows
+// are skipped and short rows padded, since spreadsheets drop empty cells.
+type xlsxRows struct {
+	file  *excelize.File
+	rows  *excelize.Rows
+	width int
+}
+
+func openXLSXRows(path, sheet string) ([]string, rowReader, error) {
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read XLSX: %w", err)
+	}
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		f.Close()
+		return nil, nil, fmt.Errorf("workbook has no sheets")
+	}
+	if sheet == "" {
+		sheet = sheets[0]
+	}
+	rows, err := f.Rows(sheet)
+	if err != nil {
+		f.Close()
+		return nil, nil, fmt.Errorf("sheet '%s' not found (sheets: %s)", sheet, strings.Join(sheets, ", "))
+	}
+	x := &xlsxRows{file: f, rows: rows}
+	headers, err := x.Read()
+	if err == io.EOF {
+		x.Close()
+		return nil, nil, fmt.Errorf("file is empty")
+	}
+	if err != nil {
+		x.Close()
+		return nil, nil, err
+	}
+	x.width = len(headers)
+	return headers, x, nil
+}
+
+func (x *xlsxRows) Read() ([]string, error) {
+	for x.rows.Next() {
+		row, err := x.rows.Columns()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read XLSX: %w", err)
+		}
+		if strings.TrimSpace(strings.Join(row, "")) == "" {
+			continue
+		}
+		for len(row) < x.width {
+			row = append(row, "")
+		}
+		return row, nil
+	}
+	if err := x.rows.Error(); err != nil {
+		return nil, fmt.Errorf("failed to read XLSX: %w", err)
+	}
+	return nil, io.EOF
+}
+
+func (x *xlsxRows) Close() error {
+	x.rows.Close()
+	return x.file.Close()
+}
+
+// jsonRows aligns each object to the keys of the whole file, which a first
+// pass collects, so objects may omit keys or introduce new ones
+type jsonRows struct {
+	file    *os.File
+	objects *jsonObjects
+	index   map[string]int
+}
+
+func openJSONRows(path string) ([]string, rowReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	objects, err := newJSONObjects(f)
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	var headers []string
+	index := map[string]int{}
+	for {
+		keys, _, err := objects.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+		for _, k := range keys {
+			if _, ok := index[k]; !ok {
+				index[k] = len(headers)
+				headers = append(headers, k)
+			}
+		}
+	}
+	if len(headers) == 0 {
+		f.Close()
+		return nil, nil, fmt.Errorf("file is empty")
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	if objects, err = newJSONObjects(f); err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return headers, &jsonRows{file: f, objects: objects, index: index}, nil
+}
+
+func (j *jsonRows) Read() ([]string, error) {
+	_, record, err := j.objects.next()
+	if err != nil {
+		return nil, err
+	}
+	row := make([]string, len(j.index))
+	for k, v := range record {
+		row[j.index[k]] = v
+	}
+	return row, nil
+}
+
+func (j *jsonRows) Close() error {
+	return j.file.Close()
+}
+
+// analyzeImport reads a file once to count rows, infer column types, check
+// requested types and collect row length mismatches
+func analyzeImport(src ImportSource, columnTypes map[string]string) (*ImportPreview, error) {
+	headers, rows, err := openRows(src)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	if len(headers) == 0 {
+		return nil, fmt.Errorf("no headers found")
+	}
+
+	// Columns with a requested type are checked rather than inferred
+	inferred := make([]string, len(headers))
+	requested, err := buildColumns(headers, inferred, columnTypes)
+	if err != nil {
+		return nil, err
+	}
+	guesses := make([]*typeGuess, len(headers))
+	for i := range guesses {
+		guesses[i] = newTypeGuess()
+	}
+
+	preview := &ImportPreview{Headers: headers, SampleRows: [][]string{}, Mismatches: []RowMismatch{}, ConversionErrors: []string{}}
+	for {
+		row, err := rows.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		preview.Rows++
+		line := preview.Rows + 1
+
+		if len(row) != len(headers) {
+			preview.MismatchCount++
+			if len(preview.Mismatches) < maxPreviewMismatches {
+				preview.Mismatches = append(preview.Mismatches, RowMismatch{Row: line, Fields: len(row), Expected: len(headers)})
+			}
+			row = fitRow(row, len(headers))
+		}
+		if len(preview.SampleRows) < previewSampleRows {
+			preview.SampleRows = append(preview.SampleRows, row)
+		}
+
+		for i, v := range row {
+			// Requested number columns still need their number format
+			guesses[i].add(v)
+			if requested[i].Type == "" {
+				continue
+			}
+			if _, err := convertValue(v, requested[i].Type); err != nil && len(preview.ConversionErrors) < maxConversionErrors {
+				preview.ConversionErrors = append(preview.ConversionErrors, fmt.Sprintf("row %d column %s: %v", line, headers[i], err))
+			}
+		}
+	}
+
+	for i, g := range guesses {
+		inferred[i] = g.result()
+	}
+	if preview.Columns, err = buildColumns(headers, inferred, columnTypes); err != nil {
+		return nil, err
+	}
+	for i := range preview.Columns {
+		preview.Columns[i].DecimalPoint = guesses[i].decimalPoint(preview.Columns[i].Type)
+	}
+	return preview, nil
+}
+
+// fitRow pads or truncates a row to the header width
+func fitRow(row []string, width int) []string {
+	for len(row) < width {
+		row = append(row, "")
+	}
+	return row[:width]
+}
+
+// PreviewImport reports what importing a file would create: headers, inferred
+// column types, sample rows and rows whose length differs from the header
+func (m *TableManager) PreviewImport(src ImportSource, columnTypes map[string]string) (*ImportPreview, error) {
+	return analyzeImport(src, columnTypes)
+}
+
+// ImportStream creates a typed dataset from a CSV, XLSX or JSON file, loading
+// rows with COPY in one transaction. Column types are inferred from the data;
+// columnTypes overrides them by header or column name (e.g. {"price": "decimal"}).
+// progress, if set, is called with rows loaded so far and the file's total.
+// The import is the dataset's first revision, recorded for authorID.
+func (m *TableManager) ImportStream(schemaName, displayName string, src ImportSource, columnTypes map[string]string, authorID int, progress func(done, total int)) (*TableMetadata, error) {
+	ctx := context.Background()
+	tableName := "dt_" + sanitizeTableName(displayName) + "_" + time.Now().Format("20060102150405")
+
+	// Use tenant schema or fall back to public
+	if schemaName == "" {
+		schemaName = "public"
+	}
+	qualifiedTable := qualifyTable(schemaName, tableName)
+	registryTable := qualifyTable(schemaName, "dynamic_tables")
+
+	preview, err := analyzeImport(src, columnTypes)
+	if err != nil {
+		return nil, err
+	}
+	if len(preview.ConversionErrors) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrColumnConversion, strings.Join(preview.ConversionErrors, "; "))
+	}
+	cols := preview.Columns
+
+	_, rows, err := openRows(src)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tx, err := m.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// 1. Create Table
+	colDefs := make([]string, len(cols))
+	colNames := make([]string, len(cols))
+	for i, col := range cols {
+		colNames[i] = col.Name
+		colDefs[i] = quoteIdent(col.Name) + " " + columnSQLTypes[col.Type]
+	}
+	createSQL := fmt.Sprintf("CREATE TABLE %s (id SERIAL PRIMARY KEY, %s);", qualifiedTable, strings.Join(colDefs, ", "))
+	if _, err := tx.Exec(ctx, createSQL); err != nil {
+		return nil, fmt.Errorf("failed to create table %s: %w", qualifiedTable, err)
+	}
+
+	// 2. Copy Data
+	source := &copyRows{rows: rows, cols: cols}
+	if progress != nil {
+		source.progress = func(done int) { progress(done, preview.Rows) }
+	}
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{schemaName, tableName}, colNames, source); err != nil {
+		return nil, fmt.Errorf("failed to load rows: %w", err)
+	}
+
+	// 3. Register Table in Registry (schema-specific) with its inferred schema and suggested roles
+	meta := &TableMetadata{TableName: tableName, DisplayName: displayName, Columns: cols}
+	colsJSON, err := json.Marshal(cols)
+	if err != nil {
+		return nil, err
+	}
+	meta.Roles = SuggestRoles(cols)
+	rolesJSON, err := json.Marshal(meta.Roles)
+	if err != nil {
+		return nil, err
+	}
+	err = tx.QueryRow(ctx, fmt.Sprintf("INSERT INTO %s (table_name, display_name, columns, roles) VALUES ($1, $2, $3, $4) RETURNING id, created_at", registryTable),
+		tableName, displayName, colsJSON, rolesJSON).Scan(&meta.ID, &meta.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register table: %w", err)
+	}
+	summary := fmt.Sprintf("Imported %d rows", source.line)
+	if _, err := recordRevision(ctx, tx, schemaName, tableName, RevisionImport, summary, authorID, nil); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	if progress != nil {
+		progress(source.line, preview.Rows)
+	}
+	return meta, nil
+}
+
+// copyRows feeds file rows to COPY, converting each value to its column type
+type copyRows struct {
+	rows     rowReader
+	cols     []ColumnSchema
+	progress func(done int)
+	line     int
+	values   []any
+	err      error
+}
+
+func (c *copyRows) Next() bool {
+	row, err := c.rows.Read()
+	if err != nil {
+		if err != io.EOF {
+			c.err = err
+		}
+		return false
+	}
+	c.line++
+	row = fitRow(row, len(c.cols))
+
+	c.values = make([]any, len(c.cols))
+	for j, col := range c.cols {
+		v, err := convertCell(row[j], col)
+		if err != nil {
+			// Row numbers count the header line, like a spreadsheet
+			c.err = fmt.Errorf("%w: row %d column %s: %v", ErrColumnConversion, c.line+1, col.Header, err)
+			return false
+		}
+		c.values[j] = v
+	}
+	if c.progress != nil && c.line%importProgressEvery == 0 {
+		c.progress(c.line)
+	}
+	return true
+}
+
+func (c *copyRows) Values() ([]any, error) {
+	return c.values, nil
+}
+
+func (c *copyRows) Err() error {
+	return c.err
+}
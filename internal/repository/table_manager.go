@@ -1,22 +1,24 @@
 package repository
 
 import (
+	"bytes"
 	"context"
-	"encoding/csv"
 	"fmt"
-	"io"
 	"regexp"
 	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type TableMetadata struct {
-	ID          int       `json:"id"`
-	TableName   string    `json:"table_name"`
-	DisplayName string    `json:"display_name"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID          int            `json:"id"`
+	TableName   string         `json:"table_name"`
+	DisplayName string         `json:"display_name"`
+	Columns     []ColumnSchema `json:"columns"`
+	Roles       ColumnRoles    `json:"roles"`
+	CreatedAt   time.Time      `json:"created_at"`
 }
 
 type TableManager struct {
@@ -41,102 +43,35 @@ func qualifyTable(schema, table string) string {
 	return fmt.Sprintf("%s.%s", schema, table)
 }
 
-// ImportCSV creates a dynamic table and imports CSV data transactionally
-// schemaName allows tenant-specific table creation
-func (m *TableManager) ImportCSV(schemaName, displayName string, csvData io.Reader) error {
-	ctx := context.Background()
-	tableName := "dt_" + sanitizeTableName(displayName) + "_" + time.Now().Format("20060102150405")
-	
-	// Use tenant schema or fall back to public
-	if schemaName == "" {
-		schemaName = "public"
-	}
-	qualifiedTable := qualifyTable(schemaName, tableName)
-	registryTable := qualifyTable(schemaName, "dynamic_tables")
-
-	// Parse CSV
-	reader := csv.NewReader(csvData)
-	rows, err := reader.ReadAll()
-	if err != nil {
-		return fmt.Errorf("failed to read CSV: %w", err)
-	}
-	if len(rows) < 1 {
-		return fmt.Errorf("csv is empty")
-	}
-
-	headers := rows[0]
-	if len(headers) == 0 {
-		return fmt.Errorf("no headers found")
-	}
-
-	// Start Transaction
-	tx, err := m.db.Begin(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
-	}
-	defer tx.Rollback(ctx)
-
-	// 1. Create Table
-	var colDefs []string
-	safeHeaders := make([]string, len(headers))
+// buildColumns names and types the columns of an import. Names are
+// sanitized and de-duplicated; types come from columnTypes (by header or
+// name) or fall back to the inferred ones.
+func buildColumns(headers, inferred []string, columnTypes map[string]string) ([]ColumnSchema, error) {
+	cols := make([]ColumnSchema, len(headers))
+	used := map[string]bool{"id": true}
 	for i, h := range headers {
 		safeH := sanitizeTableName(h)
-		if safeH == "" {
+		if safeH == "" || safeH == "_" {
 			safeH = fmt.Sprintf("col_%d", i)
 		}
-		safeHeaders[i] = safeH
-		colDefs = append(colDefs, fmt.Sprintf("%s TEXT", safeH)) // Default to TEXT for flexibility
-	}
-
-	createSQL := fmt.Sprintf("CREATE TABLE %s (id SERIAL PRIMARY KEY, %s);", qualifiedTable, strings.Join(colDefs, ", "))
-	if _, err := tx.Exec(ctx, createSQL); err != nil {
-		return fmt.Errorf("failed to create table %s: %w", qualifiedTable, err)
-	}
-
-	// 2. Insert Data
-	colNames := strings.Join(safeHeaders, ", ")
-	placeholders := make([]string, len(safeHeaders))
-	for i := range placeholders {
-		placeholders[i] = fmt.Sprintf("$%d", i+1)
-	}
-	insertSQL := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", qualifiedTable, colNames, strings.Join(placeholders, ", "))
-
-	for i := 1; i < len(rows); i++ {
-		row := rows[i]
-		// Handle row length mismatch
-		if len(row) != len(headers) {
-			if len(row) < len(headers) {
-				for len(row) < len(headers) {
-					row = append(row, "")
-				}
-			} else {
-				row = row[:len(headers)]
-			}
+		for n := 2; used[safeH]; n++ {
+			safeH = fmt.Sprintf("%s_%d", sanitizeTableName(h), n)
 		}
+		used[safeH] = true
 
-		// Convert []string to []interface{}
-		args := make([]interface{}, len(row))
-		for j, v := range row {
-			args[j] = v
+		colType, ok := columnTypes[h]
+		if !ok {
+			colType, ok = columnTypes[safeH]
 		}
-
-		if _, err := tx.Exec(ctx, insertSQL, args...); err != nil {
-			return fmt.Errorf("row %d insert failed: %w", i, err)
+		if ok && !ValidColumnType(colType) {
+			return nil, fmt.Errorf("%w: type '%s' for column %s", ErrInvalidColumn, colType, h)
 		}
+		if !ok {
+			colType = inferred[i]
+		}
+		cols[i] = ColumnSchema{Name: safeH, Type: colType, Header: h}
 	}
-
-	// 3. Register Table in Registry (schema-specific)
-	_, err = tx.Exec(ctx, fmt.Sprintf("INSERT INTO %s (table_name, display_name) VALUES ($1, $2)", registryTable), tableName, displayName)
-	if err != nil {
-		return fmt.Errorf("failed to register table: %w", err)
-	}
-
-	// Commit
-	if err := tx.Commit(ctx); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
-	}
-
-	return nil
+	return cols, nil
 }
 
 // ListTables returns all registered dynamic tables for the given schema
@@ -146,7 +81,7 @@ func (m *TableManager) ListTables(schemaName string) ([]TableMetadata, error) {
 	}
 	registryTable := qualifyTable(schemaName, "dynamic_tables")
 	
-	query := fmt.Sprintf("SELECT id, table_name, display_name, created_at FROM %s ORDER BY created_at DESC", registryTable)
+	query := fmt.Sprintf("SELECT id, table_name, display_name, COALESCE(columns, '[]'), COALESCE(roles, '{}'), created_at FROM %s ORDER BY created_at DESC", registryTable)
 	rows, err := m.db.Query(context.Background(), query)
 	if err != nil {
 		return nil, err
@@ -156,59 +91,17 @@ func (m *TableManager) ListTables(schemaName string) ([]TableMetadata, error) {
 	tables := []TableMetadata{}
 	for rows.Next() {
 		var t TableMetadata
-		if err := rows.Scan(&t.ID, &t.TableName, &t.DisplayName, &t.CreatedAt); err != nil {
+		if err := rows.Scan(&t.ID, &t.TableName, &t.DisplayName, &t.Columns, &t.Roles, &t.CreatedAt); err != nil {
 			return nil, err
 		}
+		if t.Roles.IsZero() {
+			t.Roles = SuggestRoles(t.Columns)
+		}
 		tables = append(tables, t)
 	}
 	return tables, nil
 }
 
-// GetTableData fetches data from a dynamic table within the given schema
-// Supports lookup by table_name (dt_xxx_timestamp) OR display_name (user-friendly name)
-func (m *TableManager) GetTableData(schemaName, tableNameOrDisplay string) ([]map[string]interface{}, error) {
-	if schemaName == "" {
-		schemaName = "public"
-	}
-	registryTable := qualifyTable(schemaName, "dynamic_tables")
-	
-	// Try to find table by table_name first, then by display_name
-	var actualTableName string
-	err := m.db.QueryRow(context.Background(), 
-		fmt.Sprintf("SELECT table_name FROM %s WHERE table_name=$1 OR display_name=$1 LIMIT 1", registryTable), 
-		tableNameOrDisplay).Scan(&actualTableName)
-	if err != nil {
-		return nil, fmt.Errorf("table not found or unauthorized")
-	}
-	
-	qualifiedTable := qualifyTable(schemaName, actualTableName)
-
-	// Fetch data
-	rows, err := m.db.Query(context.Background(), fmt.Sprintf("SELECT * FROM %s", qualifiedTable))
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	fieldDescs := rows.FieldDescriptions()
-	var results []map[string]interface{}
-
-	for rows.Next() {
-		values, err := rows.Values()
-		if err != nil {
-			return nil, err
-		}
-
-		rowMap := make(map[string]interface{})
-		for i, fd := range fieldDescs {
-			colName := string(fd.Name)
-			rowMap[colName] = values[i]
-		}
-		results = append(results, rowMap)
-	}
-	return results, nil
-}
-
 // DeleteTable removes a dynamic table and its registry entry within the given schema
 func (m *TableManager) DeleteTable(schemaName, tableName string) error {
 	ctx := context.Background()
@@ -245,11 +138,20 @@ func (m *TableManager) DeleteTable(schemaName, tableName string) error {
 		return fmt.Errorf("failed to remove registry entry: %w", err)
 	}
 
+	// Forget its history, so a later dataset with the same name starts clean
+	if _, err := tx.Exec(ctx, fmt.Sprintf("DELETE FROM %s WHERE table_name=$1", qualifyTable(schemaName, "dataset_revisions")), tableName); err != nil {
+		return fmt.Errorf("failed to remove revisions: %w", err)
+	}
+	if _, err := tx.Exec(ctx, fmt.Sprintf("DELETE FROM %s WHERE table_name=$1", qualifyTable(schemaName, "dataset_search")), tableName); err != nil {
+		return fmt.Errorf("failed to remove search index: %w", err)
+	}
+
 	return tx.Commit(ctx)
 }
 
-// UpdateRow updates a single row in a dynamic table within the given schema
-func (m *TableManager) UpdateRow(schemaName, tableName string, rowID int, data map[string]interface{}) error {
+// UpdateRow updates a single row in a dynamic table within the given schema,
+// recording the change as a revision by authorID
+func (m *TableManager) UpdateRow(schemaName, tableName string, rowID int, data map[string]interface{}, authorID int) error {
 	ctx := context.Background()
 	if schemaName == "" {
 		schemaName = "public"
@@ -269,6 +171,11 @@ func (m *TableManager) UpdateRow(schemaName, tableName string, rowID int, data m
 		return fmt.Errorf("no data provided")
 	}
 
+	cols, err := m.recordedColumns(ctx, schemaName, tableName)
+	if err != nil {
+		return err
+	}
+
 	var setClauses []string
 	var args []interface{}
 	i := 1
@@ -278,7 +185,15 @@ func (m *TableManager) UpdateRow(schemaName, tableName string, rowID int, data m
 		if safeCol == "" || safeCol == "id" {
 			continue // Skip ID or invalid columns
 		}
-		setClauses = append(setClauses, fmt.Sprintf("%s = $%d", safeCol, i))
+		// Text input for typed columns is converted like on import ("Rp 15.000")
+		if s, ok := val.(string); ok && cols[safeCol].Type != "" {
+			v, err := convertCell(s, cols[safeCol])
+			if err != nil {
+				return fmt.Errorf("%w: column %s: %v", ErrColumnConversion, safeCol, err)
+			}
+			val = v
+		}
+		setClauses = append(setClauses, fmt.Sprintf("%s = $%d", quoteIdent(safeCol), i))
 		args = append(args, val)
 		i++
 	}
@@ -288,14 +203,36 @@ func (m *TableManager) UpdateRow(schemaName, tableName string, rowID int, data m
 	}
 
 	args = append(args, rowID)
-	updateSQL := fmt.Sprintf("UPDATE %s SET %s WHERE id = $%d", qualifiedTable, strings.Join(setClauses, ", "), i)
+	updateSQL := fmt.Sprintf("UPDATE %[1]s AS t SET %[2]s FROM (SELECT * FROM %[1]s WHERE id = $%[3]d) AS old WHERE t.id = old.id RETURNING to_jsonb(old), to_jsonb(t)",
+		qualifiedTable, strings.Join(setClauses, ", "), i)
 
-	_, err = m.db.Exec(ctx, updateSQL, args...)
-	return err
+	tx, err := m.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	change := rowChange{rowID: rowID}
+	err = tx.QueryRow(ctx, updateSQL, args...).Scan(&change.before, &change.after)
+	if err == pgx.ErrNoRows {
+		return ErrRowNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(change.before, change.after) {
+		return nil // Nothing changed, nothing to record
+	}
+	summary := fmt.Sprintf("Edited row %d", rowID)
+	if _, err := recordRevision(ctx, tx, schemaName, tableName, RevisionRowUpdate, summary, authorID, []rowChange{change}); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
 }
 
-// DeleteRow deletes a single row from a dynamic table within the given schema
-func (m *TableManager) DeleteRow(schemaName, tableName string, rowID int) error {
+// DeleteRow deletes a single row from a dynamic table within the given schema,
+// recording the change as a revision by authorID
+func (m *TableManager) DeleteRow(schemaName, tableName string, rowID int, authorID int) error {
 	ctx := context.Background()
 	if schemaName == "" {
 		schemaName = "public"
@@ -310,6 +247,23 @@ func (m *TableManager) DeleteRow(schemaName, tableName string, rowID int) error
 		return fmt.Errorf("table not found")
 	}
 
-	_, err = m.db.Exec(ctx, fmt.Sprintf("DELETE FROM %s WHERE id = $1", qualifiedTable), rowID)
-	return err
+	tx, err := m.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	change := rowChange{rowID: rowID}
+	err = tx.QueryRow(ctx, fmt.Sprintf("DELETE FROM %s AS t WHERE id = $1 RETURNING to_jsonb(t)", qualifiedTable), rowID).Scan(&change.before)
+	if err == pgx.ErrNoRows {
+		return ErrRowNotFound
+	}
+	if err != nil {
+		return err
+	}
+	summary := fmt.Sprintf("Deleted row %d", rowID)
+	if _, err := recordRevision(ctx, tx, schemaName, tableName, RevisionRowDelete, summary, authorID, []rowChange{change}); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
 }
@@ -0,0 +1,304 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Filter operators for dataset queries
+const (
+	FilterEquals   = "eq"
+	FilterNot      = "ne"
+	FilterContains = "contains"
+	FilterGT       = "gt"
+	FilterGTE      = "gte"
+	FilterLT       = "lt"
+	FilterLTE      = "lte"
+	FilterBetween  = "between"
+)
+
+var filterSQL = map[string]string{
+	FilterEquals: "=",
+	FilterNot:    "<>",
+	FilterGT:     ">",
+	FilterGTE:    ">=",
+	FilterLT:     "<",
+	FilterLTE:    "<=",
+}
+
+// Dataset page size limits
+const (
+	defaultTablePageSize = 50
+	maxTablePageSize     = 1000
+)
+
+// TableFilter restricts a column. Values are converted with the column's type,
+// so "Rp 15.000" filters a decimal column numerically.
+type TableFilter struct {
+	Column string `json:"column"`
+	Op     string `json:"op"`
+	Value  string `json:"value"`
+	Value2 string `json:"value2,omitempty"` // Upper bound for "between"
+}
+
+// SortField orders results by one column
+type SortField struct {
+	Column string `json:"column"`
+	Desc   bool   `json:"desc"`
+}
+
+// TableQuery selects a page of dataset rows
+type TableQuery struct {
+	Filters       []TableFilter
+	Search        string   // Case-insensitive match in any column (or SearchColumns)
+	SearchColumns []string // Columns Search looks in; missing ones are ignored
+	Sort          []SortField
+	Limit         int // Default 50, max 1000
+	Offset        int
+	AfterID       int // Cursor: rows with a greater id (only with the default id order)
+}
+
+// TableSchema is a dataset's columns and roles, read once for several lookups
+type TableSchema struct {
+	TableName string
+	Columns   []ColumnSchema
+	Roles     ColumnRoles
+}
+
+// TablePage is one page of dataset rows
+type TablePage struct {
+	TableName  string                   `json:"table_name"`
+	Columns    []ColumnSchema           `json:"columns"`
+	Rows       []map[string]interface{} `json:"rows"`
+	Total      int                      `json:"total"`
+	Limit      int                      `json:"limit"`
+	Offset     int                      `json:"offset"`
+	NextCursor int                      `json:"next_cursor,omitempty"` // Pass as AfterID for the next page
+}
+
+// QueryTable reads a page of a dataset with typed filters and sorting
+func (m *TableManager) QueryTable(schemaName, nameOrDisplay string, q TableQuery) (*TablePage, error) {
+	ctx := context.Background()
+	if schemaName == "" {
+		schemaName = "public"
+	}
+	tableName, err := m.resolveTable(ctx, m.db, schemaName, nameOrDisplay)
+	if err != nil {
+		return nil, err
+	}
+	cols, err := m.tableColumns(ctx, schemaName, tableName)
+	if err != nil {
+		return nil, err
+	}
+	types := map[string]string{"id": ColumnInteger}
+	for _, c := range cols {
+		types[c.Name] = c.Type
+	}
+
+	where, args, err := tableWhere(q, cols, types)
+	if err != nil {
+		return nil, err
+	}
+
+	orderBy, err := tableOrder(q.Sort, types)
+	if err != nil {
+		return nil, err
+	}
+	cursor := q.AfterID > 0 && len(q.Sort) == 0
+	if cursor {
+		args = append(args, q.AfterID)
+		where = append(where, fmt.Sprintf("id > $%d", len(args)))
+	}
+
+	whereSQL := ""
+	if len(where) > 0 {
+		whereSQL = " WHERE " + strings.Join(where, " AND ")
+	}
+	table := qualifyTable(schemaName, tableName)
+
+	page := &TablePage{TableName: tableName, Columns: cols, Rows: []map[string]interface{}{}, Limit: q.Limit, Offset: q.Offset}
+	if page.Limit <= 0 {
+		page.Limit = defaultTablePageSize
+	}
+	page.Limit = min(page.Limit, maxTablePageSize)
+	page.Offset = max(page.Offset, 0)
+	if cursor {
+		page.Offset = 0
+	}
+
+	// The total ignores the cursor so it stays the size of the whole result
+	countWhere, countArgs := whereSQL, args
+	if cursor {
+		countWhere = ""
+		if len(where) > 1 {
+			countWhere = " WHERE " + strings.Join(where[:len(where)-1], " AND ")
+		}
+		countArgs = args[:len(args)-1]
+	}
+	if err := m.db.QueryRow(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s%s", table, countWhere), countArgs...).Scan(&page.Total); err != nil {
+		return nil, err
+	}
+
+	args = append(args, page.Limit, page.Offset)
+	query := fmt.Sprintf("SELECT * FROM %s%s ORDER BY %s LIMIT $%d OFFSET $%d", table, whereSQL, orderBy, len(args)-1, len(args))
+	rows, err := m.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	fieldDescs := rows.FieldDescriptions()
+	lastID := 0
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return nil, err
+		}
+		rowMap := make(map[string]interface{}, len(values))
+		for i, fd := range fieldDescs {
+			rowMap[fd.Name] = displayValue(values[i])
+			if id, ok := values[i].(int32); ok && fd.Name == "id" {
+				lastID = int(id)
+			}
+		}
+		page.Rows = append(page.Rows, rowMap)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(q.Sort) == 0 && len(page.Rows) == page.Limit {
+		page.NextCursor = lastID
+	}
+	return page, nil
+}
+
+// Schema reads a dataset's columns and roles for repeated lookups
+func (m *TableManager) Schema(schemaName, nameOrDisplay string) (*TableSchema, error) {
+	ctx := context.Background()
+	if schemaName == "" {
+		schemaName = "public"
+	}
+	tableName, err := m.resolveTable(ctx, m.db, schemaName, nameOrDisplay)
+	if err != nil {
+		return nil, err
+	}
+	cols, err := m.tableColumns(ctx, schemaName, tableName)
+	if err != nil {
+		return nil, err
+	}
+	roles, err := loadRoles(ctx, m.db, schemaName, tableName)
+	if err != nil {
+		return nil, err
+	}
+	return &TableSchema{TableName: tableName, Columns: cols, Roles: roles}, nil
+}
+
+// tableColumns reads a resolved dataset's columns, recording them first for
+// datasets imported before schemas were
+func (m *TableManager) tableColumns(ctx context.Context, schemaName, tableName string) ([]ColumnSchema, error) {
+	cols, recorded, err := readColumns(ctx, m.db, schemaName, tableName)
+	if err != nil || recorded {
+		return cols, err
+	}
+	return m.GetColumns(schemaName, tableName)
+}
+
+// tableWhere builds the WHERE conditions of a dataset query
+func tableWhere(q TableQuery, cols []ColumnSchema, types map[string]string) ([]string, []any, error) {
+	var where []string
+	var args []any
+
+	for _, f := range q.Filters {
+		colType, ok := types[f.Column]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: %s", ErrColumnNotFound, f.Column)
+		}
+		col := quoteIdent(f.Column)
+		if f.Op == "" {
+			f.Op = FilterEquals
+		}
+
+		switch f.Op {
+		case FilterContains:
+			args = append(args, "%"+f.Value+"%")
+			where = append(where, fmt.Sprintf("%s::text ILIKE $%d", col, len(args)))
+		case FilterBetween:
+			lo, err := filterValue(f.Column, f.Value, colType)
+			if err != nil {
+				return nil, nil, err
+			}
+			hi, err := filterValue(f.Column, f.Value2, colType)
+			if err != nil {
+				return nil, nil, err
+			}
+			args = append(args, lo, hi)
+			where = append(where, fmt.Sprintf("%s BETWEEN $%d AND $%d", col, len(args)-1, len(args)))
+		default:
+			op, ok := filterSQL[f.Op]
+			if !ok {
+				return nil, nil, fmt.Errorf("%w: unknown filter '%s'", ErrInvalidColumn, f.Op)
+			}
+			v, err := filterValue(f.Column, f.Value, colType)
+			if err != nil {
+				return nil, nil, err
+			}
+			args = append(args, v)
+			if colType == ColumnText && (f.Op == FilterEquals || f.Op == FilterNot) {
+				// Text equality ignores case, like the bot's product lookups
+				where = append(where, fmt.Sprintf("LOWER(%s) %s LOWER($%d)", col, op, len(args)))
+			} else {
+				where = append(where, fmt.Sprintf("%s %s $%d", col, op, len(args)))
+			}
+		}
+	}
+
+	if q.Search != "" {
+		searchCols := q.SearchColumns
+		if len(searchCols) == 0 {
+			for _, c := range cols {
+				searchCols = append(searchCols, c.Name)
+			}
+		}
+		args = append(args, "%"+q.Search+"%")
+		var matches []string
+		for _, name := range searchCols {
+			if _, ok := types[name]; ok && name != "id" {
+				matches = append(matches, fmt.Sprintf("%s::text ILIKE $%d", quoteIdent(name), len(args)))
+			}
+		}
+		if len(matches) == 0 {
+			where = append(where, "FALSE")
+		} else {
+			where = append(where, "("+strings.Join(matches, " OR ")+")")
+		}
+	}
+	return where, args, nil
+}
+
+func filterValue(column, raw, colType string) (any, error) {
+	v, err := convertValue(raw, colType)
+	if err != nil {
+		return nil, fmt.Errorf("%w: filter on %s: %v", ErrInvalidColumn, column, err)
+	}
+	if v == nil {
+		return nil, fmt.Errorf("%w: filter on %s needs a value", ErrInvalidColumn, column)
+	}
+	return v, nil
+}
+
+// tableOrder builds ORDER BY, always ending with id so pages are stable
+func tableOrder(sort []SortField, types map[string]string) (string, error) {
+	var parts []string
+	for _, s := range sort {
+		if _, ok := types[s.Column]; !ok {
+			return "", fmt.Errorf("%w: %s", ErrColumnNotFound, s.Column)
+		}
+		dir := "ASC"
+		if s.Desc {
+			dir = "DESC"
+		}
+		parts = append(parts, fmt.Sprintf("%s %s NULLS LAST", quoteIdent(s.Column), dir))
+	}
+	return strings.Join(append(parts, "id ASC"), ", "), nil
+}
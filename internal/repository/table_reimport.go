@@ -0,0 +1,280 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ReimportOptions controls how a file is merged into an existing dataset
+type ReimportOptions struct {
+	KeyColumn     string            // Header or column name that identifies a row
+	DeleteMissing bool              // Remove rows whose key is not in the file
+	ColumnTypes   map[string]string // Types for columns the file adds (inferred if absent)
+	AuthorID      int               // User recorded on the dataset revision
+}
+
+// ReimportResult summarizes what a re-import changed
+type ReimportResult struct {
+	TableName    string   `json:"table_name"`
+	Added        int      `json:"added"`
+	Updated      int      `json:"updated"`
+	Unchanged    int      `json:"unchanged"`
+	Removed      int      `json:"removed"`
+	AddedKeys    []string `json:"added_keys"`
+	UpdatedKeys  []string `json:"updated_keys"`
+	RemovedKeys  []string `json:"removed_keys"`
+	AddedColumns []string `json:"added_columns"`
+}
+
+// maxDiffKeys caps how many keys of each kind a re-import result lists
+const maxDiffKeys = 100
+
+func appendKey(keys []string, key string) []string {
+	if len(keys) < maxDiffKeys {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// ReimportCSV merges CSV data into an existing dataset, keeping its table name
+// so menu items that reference it keep working
+func (m *TableManager) ReimportCSV(schemaName, nameOrDisplay string, csvData io.Reader, opts ReimportOptions) (*ReimportResult, error) {
+	return m.ReimportFile(schemaName, nameOrDisplay, FormatCSV, "", csvData, opts)
+}
+
+// reimportRows upserts rows by key column in one transaction: new keys are
+// inserted, changed rows updated and, optionally, rows missing from the file deleted
+func (m *TableManager) reimportRows(schemaName, nameOrDisplay string, headers []string, rows [][]string, opts ReimportOptions) (*ReimportResult, error) {
+	ctx := context.Background()
+	if schemaName == "" {
+		schemaName = "public"
+	}
+	if len(headers) == 0 {
+		return nil, fmt.Errorf("no headers found")
+	}
+
+	tx, err := m.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tableName, err := m.resolveTable(ctx, tx, schemaName, nameOrDisplay)
+	if err != nil {
+		return nil, err
+	}
+	table := qualifyTable(schemaName, tableName)
+	cols, err := m.loadColumns(ctx, tx, schemaName, tableName)
+	if err != nil {
+		return nil, err
+	}
+	result := &ReimportResult{TableName: tableName, AddedKeys: []string{}, UpdatedKeys: []string{}, RemovedKeys: []string{}, AddedColumns: []string{}}
+
+	for i, row := range rows {
+		for len(row) < len(headers) {
+			row = append(row, "")
+		}
+		rows[i] = row[:len(headers)]
+	}
+
+	// Map file headers to dataset columns, adding columns the dataset lacks
+	fileCols := make([]ColumnSchema, len(headers))
+	for i, h := range headers {
+		idx := -1
+		for j, c := range cols {
+			if c.Header == h || c.Name == sanitizeTableName(h) {
+				idx = j
+				break
+			}
+		}
+		if idx >= 0 {
+			fileCols[i] = cols[idx]
+			continue
+		}
+
+		name := sanitizeTableName(h)
+		if name == "" || name == "_" || name == "id" {
+			return nil, fmt.Errorf("%w: header '%s'", ErrInvalidColumn, h)
+		}
+		guess := newTypeGuess()
+		for _, row := range rows {
+			guess.add(row[i])
+		}
+		colType, ok := opts.ColumnTypes[h]
+		if !ok {
+			colType = guess.result()
+		}
+		if !ValidColumnType(colType) {
+			return nil, fmt.Errorf("%w: type '%s' for column %s", ErrInvalidColumn, colType, h)
+		}
+		if _, err := tx.Exec(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, quoteIdent(name), columnSQLTypes[colType])); err != nil {
+			return nil, fmt.Errorf("failed to add column %s: %w", name, err)
+		}
+		col := ColumnSchema{Name: name, Type: colType, Header: h, DecimalPoint: guess.decimalPoint(colType)}
+		cols = append(cols, col)
+		fileCols[i] = col
+		result.AddedColumns = append(result.AddedColumns, name)
+	}
+
+	keyIdx := -1
+	for i, c := range fileCols {
+		if c.Name == opts.KeyColumn || c.Header == opts.KeyColumn || c.Name == sanitizeTableName(opts.KeyColumn) {
+			keyIdx = i
+			break
+		}
+	}
+	if keyIdx < 0 {
+		return nil, fmt.Errorf("%w: key column '%s' is not in the file", ErrColumnNotFound, opts.KeyColumn)
+	}
+	keyCol := fileCols[keyIdx]
+
+	// Existing rows by key
+	existing := map[string][]int{}
+	dbRows, err := tx.Query(ctx, fmt.Sprintf("SELECT id, %s FROM %s", quoteIdent(keyCol.Name), table))
+	if err != nil {
+		return nil, err
+	}
+	for dbRows.Next() {
+		var id int
+		var key any
+		if err := dbRows.Scan(&id, &key); err != nil {
+			dbRows.Close()
+			return nil, err
+		}
+		if key != nil {
+			k := keyString(displayValue(key))
+			existing[k] = append(existing[k], id)
+		}
+	}
+	dbRows.Close()
+	if err := dbRows.Err(); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(fileCols))
+	current := make([]string, len(fileCols))
+	params := make([]string, len(fileCols))
+	for i, c := range fileCols {
+		names[i] = quoteIdent(c.Name)
+		current[i] = "t." + names[i]
+		params[i] = fmt.Sprintf("$%d::%s", i+1, columnSQLTypes[c.Type])
+	}
+	// Statements return row images for the revision history
+	insertSQL := fmt.Sprintf("INSERT INTO %s AS t (%s) VALUES (%s) RETURNING id, to_jsonb(t)", table, strings.Join(names, ", "), strings.Join(params, ", "))
+	// Only rows whose values differ count as updated
+	updateSQL := fmt.Sprintf(`UPDATE %[1]s AS t SET (%[2]s) = ROW(%[3]s) FROM (SELECT * FROM %[1]s WHERE id = $%[5]d) AS old
+		WHERE t.id = old.id AND (%[4]s) IS DISTINCT FROM (%[3]s) RETURNING to_jsonb(old), to_jsonb(t)`,
+		table, strings.Join(names, ", "), strings.Join(params, ", "), strings.Join(current, ", "), len(fileCols)+1)
+	if len(fileCols) == 1 {
+		updateSQL = fmt.Sprintf(`UPDATE %[1]s AS t SET %[2]s = %[3]s FROM (SELECT * FROM %[1]s WHERE id = $2) AS old
+			WHERE t.id = old.id AND %[4]s IS DISTINCT FROM %[3]s RETURNING to_jsonb(old), to_jsonb(t)`, table, names[0], params[0], current[0])
+	}
+	var changes []rowChange
+
+	seen := map[string]bool{}
+	for r, row := range rows {
+		args := make([]any, len(fileCols))
+		for j, c := range fileCols {
+			v, err := convertCell(row[j], c)
+			if err != nil {
+				return nil, fmt.Errorf("%w: row %d column %s: %v", ErrColumnConversion, r+2, headers[j], err)
+			}
+			args[j] = v
+		}
+		if args[keyIdx] == nil {
+			return nil, fmt.Errorf("%w: row %d has an empty key", ErrInvalidColumn, r+2)
+		}
+		key := keyString(displayValue(args[keyIdx]))
+		if seen[key] {
+			return nil, fmt.Errorf("%w: key '%s' appears more than once in the file", ErrInvalidColumn, key)
+		}
+		seen[key] = true
+
+		ids, ok := existing[key]
+		if !ok {
+			change := rowChange{}
+			if err := tx.QueryRow(ctx, insertSQL, args...).Scan(&change.rowID, &change.after); err != nil {
+				return nil, fmt.Errorf("row %d insert failed: %w", r+2, err)
+			}
+			changes = append(changes, change)
+			result.Added++
+			result.AddedKeys = appendKey(result.AddedKeys, key)
+			continue
+		}
+
+		changed := false
+		for _, id := range ids {
+			change := rowChange{rowID: id}
+			err := tx.QueryRow(ctx, updateSQL, append(args, id)...).Scan(&change.before, &change.after)
+			if err == pgx.ErrNoRows {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("row %d update failed: %w", r+2, err)
+			}
+			changes = append(changes, change)
+			changed = true
+		}
+		if changed {
+			result.Updated++
+			result.UpdatedKeys = appendKey(result.UpdatedKeys, key)
+		} else {
+			result.Unchanged++
+		}
+	}
+
+	if opts.DeleteMissing {
+		for key, ids := range existing {
+			if seen[key] {
+				continue
+			}
+			for _, id := range ids {
+				change := rowChange{rowID: id}
+				if err := tx.QueryRow(ctx, fmt.Sprintf("DELETE FROM %s AS t WHERE id = $1 RETURNING to_jsonb(t)", table), id).Scan(&change.before); err != nil {
+					return nil, fmt.Errorf("failed to delete row %d: %w", id, err)
+				}
+				changes = append(changes, change)
+			}
+			result.Removed++
+			result.RemovedKeys = appendKey(result.RemovedKeys, key)
+		}
+	}
+
+	if err := m.saveColumns(ctx, tx, schemaName, tableName, cols); err != nil {
+		return nil, err
+	}
+	summary := fmt.Sprintf("Re-imported by %s: %d added, %d updated, %d removed", keyCol.Name, result.Added, result.Updated, result.Removed)
+	if len(result.AddedColumns) > 0 {
+		summary += fmt.Sprintf(", new columns %s", strings.Join(result.AddedColumns, ", "))
+	}
+	if _, err := recordRevision(ctx, tx, schemaName, tableName, RevisionReimport, summary, opts.AuthorID, changes); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return result, nil
+}
+
+// keyString normalizes a key value so file and database keys compare equal
+func keyString(v any) string {
+	switch val := v.(type) {
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case int32:
+		return strconv.FormatInt(int64(val), 10)
+	case time.Time:
+		return val.Format("2006-01-02")
+	case string:
+		return strings.TrimSpace(val)
+	}
+	return fmt.Sprint(v)
+}
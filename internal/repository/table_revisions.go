@@ -0,0 +1,417 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Dataset revision kinds
+const (
+	RevisionImport    = "import"
+	RevisionReimport  = "reimport"
+	RevisionRowUpdate = "row_update"
+	RevisionRowDelete = "row_delete"
+	RevisionColumns   = "columns"
+	RevisionRollback  = "rollback"
+)
+
+// Errors returned by revision history
+var (
+	ErrRevisionNotFound = errors.New("revision not found")
+	ErrRowNotFound      = errors.New("row not found")
+	ErrRollbackBlocked  = errors.New("cannot roll back past a column change")
+)
+
+// DatasetRevisionsTableDDL creates the dataset change history of a schema.
+// Each revision keeps the before/after image of every row it changed, so
+// revisions can be diffed and rolled back.
+func DatasetRevisionsTableDDL(schemaName string) string {
+	return fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %[1]s.dataset_revisions (
+			id SERIAL PRIMARY KEY,
+			table_name VARCHAR(128) NOT NULL,
+			kind VARCHAR(20) NOT NULL, -- import, reimport, row_update, row_delete, columns, rollback
+			summary TEXT,
+			author_id INTEGER, -- users.id; NULL for system changes
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_dataset_revisions_table ON %[1]s.dataset_revisions(table_name, id);
+		CREATE TABLE IF NOT EXISTS %[1]s.dataset_row_changes (
+			id BIGSERIAL PRIMARY KEY,
+			revision_id INTEGER NOT NULL REFERENCES %[1]s.dataset_revisions(id) ON DELETE CASCADE,
+			row_id INTEGER NOT NULL,
+			before JSONB, -- NULL when the row was inserted
+			after JSONB   -- NULL when the row was deleted
+		);
+		CREATE INDEX IF NOT EXISTS idx_dataset_row_changes_revision ON %[1]s.dataset_row_changes(revision_id)
+	`, schemaName)
+}
+
+// DatasetRevision is one recorded change to a dataset
+type DatasetRevision struct {
+	ID          int       `json:"id"`
+	TableName   string    `json:"table_name"`
+	Kind        string    `json:"kind"`
+	Summary     string    `json:"summary"`
+	AuthorID    int       `json:"author_id"`
+	Author      string    `json:"author"` // Username, empty for system changes
+	RowsChanged int       `json:"rows_changed"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// rowChange is the before/after image of one row (JSON objects, NULL when absent)
+type rowChange struct {
+	rowID  int
+	before []byte
+	after  []byte
+}
+
+// recordRevision stores a revision and its row changes in the caller's
+// transaction, and brings the search index up to date with them
+func recordRevision(ctx context.Context, tx pgx.Tx, schemaName, tableName, kind, summary string, authorID int, changes []rowChange) (int, error) {
+	// Imports touch every row (nil ids); schemaChange reindexes after column changes
+	var indexIDs []int
+	switch kind {
+	case RevisionImport:
+	case RevisionColumns:
+		indexIDs = []int{}
+	default:
+		indexIDs = changedIDs(changes)
+	}
+	if err := indexRows(ctx, tx, schemaName, tableName, indexIDs); err != nil {
+		return 0, err
+	}
+
+	var author any
+	if authorID > 0 {
+		author = authorID
+	}
+	var id int
+	err := tx.QueryRow(ctx, fmt.Sprintf("INSERT INTO %s (table_name, kind, summary, author_id) VALUES ($1, $2, $3, $4) RETURNING id", qualifyTable(schemaName, "dataset_revisions")),
+		tableName, kind, summary, author).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record revision: %w", err)
+	}
+	if len(changes) == 0 {
+		return id, nil
+	}
+
+	rows := make([][]any, len(changes))
+	for i, c := range changes {
+		rows[i] = []any{id, c.rowID, jsonOrNil(c.before), jsonOrNil(c.after)}
+	}
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{schemaName, "dataset_row_changes"}, []string{"revision_id", "row_id", "before", "after"}, pgx.CopyFromRows(rows))
+	if err != nil {
+		return 0, fmt.Errorf("failed to record row changes: %w", err)
+	}
+	return id, nil
+}
+
+func jsonOrNil(b []byte) any {
+	if b == nil {
+		return nil
+	}
+	return json.RawMessage(b)
+}
+
+// maxRevisionPage caps how many revisions one listing returns
+const maxRevisionPage = 100
+
+// ListRevisions returns a dataset's revisions, newest first
+func (m *TableManager) ListRevisions(schemaName, nameOrDisplay string, limit, offset int) ([]DatasetRevision, int, error) {
+	ctx := context.Background()
+	if schemaName == "" {
+		schemaName = "public"
+	}
+	tableName, err := m.resolveTable(ctx, m.db, schemaName, nameOrDisplay)
+	if err != nil {
+		return nil, 0, err
+	}
+	revisions := qualifyTable(schemaName, "dataset_revisions")
+	if limit <= 0 || limit > maxRevisionPage {
+		limit = maxRevisionPage
+	}
+
+	var total int
+	if err := m.db.QueryRow(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE table_name=$1", revisions), tableName).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	rows, err := m.db.Query(ctx, fmt.Sprintf(`
+		SELECT r.id, r.table_name, r.kind, COALESCE(r.summary, ''), COALESCE(r.author_id, 0), COALESCE(u.username, ''),
+			(SELECT COUNT(*) FROM %s c WHERE c.revision_id = r.id), r.created_at
+		FROM %s r LEFT JOIN users u ON u.id = r.author_id
+		WHERE r.table_name=$1 ORDER BY r.id DESC LIMIT $2 OFFSET $3
+	`, qualifyTable(schemaName, "dataset_row_changes"), revisions), tableName, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	list := []DatasetRevision{}
+	for rows.Next() {
+		var r DatasetRevision
+		if err := rows.Scan(&r.ID, &r.TableName, &r.Kind, &r.Summary, &r.AuthorID, &r.Author, &r.RowsChanged, &r.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		list = append(list, r)
+	}
+	return list, total, rows.Err()
+}
+
+// RowDiff is how one row differs between two revisions
+type RowDiff struct {
+	RowID   int            `json:"row_id"`
+	Change  string         `json:"change"` // added, updated or removed
+	Before  map[string]any `json:"before,omitempty"`
+	After   map[string]any `json:"after,omitempty"`
+	Columns []string       `json:"columns,omitempty"` // Changed columns of an updated row
+}
+
+// RevisionDiff is the net row difference between two revisions
+type RevisionDiff struct {
+	From    int       `json:"from"`
+	To      int       `json:"to"`
+	Added   int       `json:"added"`
+	Updated int       `json:"updated"`
+	Removed int       `json:"removed"`
+	Rows    []RowDiff `json:"rows"` // At most 100
+}
+
+// netChange is a row's state before a range of revisions and after it
+type netChange struct {
+	before, after []byte
+}
+
+// netChanges folds the row changes of revisions (from, to] into the state of
+// each touched row at from and at to, in order of first change
+func netChanges(ctx context.Context, q pgx.Tx, schemaName, tableName string, from, to int) ([]int, map[int]*netChange, error) {
+	rows, err := q.Query(ctx, fmt.Sprintf(`
+		SELECT c.row_id, c.before, c.after FROM %s c
+		JOIN %s r ON r.id = c.revision_id
+		WHERE r.table_name = $1 AND r.id > $2 AND r.id <= $3
+		ORDER BY r.id, c.id
+	`, qualifyTable(schemaName, "dataset_row_changes"), qualifyTable(schemaName, "dataset_revisions")), tableName, from, to)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var order []int
+	changes := map[int]*netChange{}
+	for rows.Next() {
+		var rowID int
+		var before, after []byte
+		if err := rows.Scan(&rowID, &before, &after); err != nil {
+			return nil, nil, err
+		}
+		c, ok := changes[rowID]
+		if !ok {
+			c = &netChange{before: before}
+			changes[rowID] = c
+			order = append(order, rowID)
+		}
+		c.after = after
+	}
+	return order, changes, rows.Err()
+}
+
+// revisionExists checks that id is one of the dataset's revisions
+func revisionExists(ctx context.Context, q pgxQuerier, schemaName, tableName string, id int) error {
+	var exists bool
+	err := q.QueryRow(ctx, fmt.Sprintf("SELECT EXISTS(SELECT 1 FROM %s WHERE id=$1 AND table_name=$2)", qualifyTable(schemaName, "dataset_revisions")), id, tableName).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%w: %d", ErrRevisionNotFound, id)
+	}
+	return nil
+}
+
+// DiffRevisions compares a dataset at revision from with revision to. from may
+// be 0 (before the first revision) and greater than to (a backwards diff).
+func (m *TableManager) DiffRevisions(schemaName, nameOrDisplay string, from, to int) (*RevisionDiff, error) {
+	ctx := context.Background()
+	if schemaName == "" {
+		schemaName = "public"
+	}
+	tx, err := m.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	tableName, err := m.resolveTable(ctx, tx, schemaName, nameOrDisplay)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range []int{from, to} {
+		if id == 0 {
+			continue
+		}
+		if err := revisionExists(ctx, tx, schemaName, tableName, id); err != nil {
+			return nil, err
+		}
+	}
+
+	lo, hi := min(from, to), max(from, to)
+	order, changes, err := netChanges(ctx, tx, schemaName, tableName, lo, hi)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &RevisionDiff{From: from, To: to, Rows: []RowDiff{}}
+	for _, rowID := range order {
+		c := changes[rowID]
+		before, after := c.before, c.after
+		if from > to {
+			before, after = after, before
+		}
+		d := RowDiff{RowID: rowID}
+		switch {
+		case before == nil && after == nil:
+			continue // Added and removed again
+		case before == nil:
+			d.Change = "added"
+			diff.Added++
+		case after == nil:
+			d.Change = "removed"
+			diff.Removed++
+		default:
+			if bytes.Equal(before, after) {
+				continue
+			}
+			d.Change = "updated"
+			diff.Updated++
+		}
+		if len(diff.Rows) >= maxDiffKeys {
+			continue
+		}
+		json.Unmarshal(before, &d.Before)
+		json.Unmarshal(after, &d.After)
+		if d.Change == "updated" {
+			for k, v := range d.After {
+				if !jsonEqual(v, d.Before[k]) {
+					d.Columns = append(d.Columns, k)
+				}
+			}
+			sort.Strings(d.Columns)
+		}
+		diff.Rows = append(diff.Rows, d)
+	}
+	return diff, nil
+}
+
+func jsonEqual(a, b any) bool {
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	return bytes.Equal(ja, jb)
+}
+
+// Rollback restores a dataset's rows to their state at an earlier revision.
+// The rollback is itself recorded as a revision, so it can be undone.
+func (m *TableManager) Rollback(schemaName, nameOrDisplay string, revisionID, authorID int) (*DatasetRevision, error) {
+	ctx := context.Background()
+	if schemaName == "" {
+		schemaName = "public"
+	}
+	tx, err := m.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	tableName, err := m.resolveTable(ctx, tx, schemaName, nameOrDisplay)
+	if err != nil {
+		return nil, err
+	}
+	if err := revisionExists(ctx, tx, schemaName, tableName, revisionID); err != nil {
+		return nil, err
+	}
+	table := qualifyTable(schemaName, tableName)
+	if _, err := tx.Exec(ctx, fmt.Sprintf("LOCK TABLE %s IN EXCLUSIVE MODE", table)); err != nil {
+		return nil, err
+	}
+
+	// Row images only fit the columns they were taken with
+	var blocking int
+	err = tx.QueryRow(ctx, fmt.Sprintf("SELECT COALESCE(MAX(id), 0) FROM %s WHERE table_name=$1 AND id > $2 AND kind = $3", qualifyTable(schemaName, "dataset_revisions")),
+		tableName, revisionID, RevisionColumns).Scan(&blocking)
+	if err != nil {
+		return nil, err
+	}
+	if blocking > 0 {
+		return nil, fmt.Errorf("%w (revision %d)", ErrRollbackBlocked, blocking)
+	}
+
+	cols, err := m.loadColumns(ctx, tx, schemaName, tableName)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(cols))
+	for i, c := range cols {
+		names[i] = quoteIdent(c.Name)
+	}
+	restoreSQL := fmt.Sprintf(`UPDATE %[1]s AS t SET (%[2]s) = (SELECT %[2]s FROM jsonb_populate_record(NULL::%[1]s, $1))
+		FROM (SELECT * FROM %[1]s WHERE id = $2) AS old WHERE t.id = old.id RETURNING to_jsonb(old), to_jsonb(t)`, table, strings.Join(names, ", "))
+	insertSQL := fmt.Sprintf("INSERT INTO %[1]s AS t SELECT * FROM jsonb_populate_record(NULL::%[1]s, $1) RETURNING to_jsonb(t)", table)
+	deleteSQL := fmt.Sprintf("DELETE FROM %s AS t WHERE id = $1 RETURNING to_jsonb(t)", table)
+
+	order, changes, err := netChanges(ctx, tx, schemaName, tableName, revisionID, math.MaxInt32)
+	if err != nil {
+		return nil, err
+	}
+	var applied []rowChange
+	for _, rowID := range order {
+		target := changes[rowID].before
+		if target == nil {
+			// The row did not exist yet
+			var current []byte
+			err := tx.QueryRow(ctx, deleteSQL, rowID).Scan(&current)
+			if err == pgx.ErrNoRows {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to remove row %d: %w", rowID, err)
+			}
+			applied = append(applied, rowChange{rowID: rowID, before: current})
+			continue
+		}
+
+		var before, after []byte
+		err := tx.QueryRow(ctx, restoreSQL, target, rowID).Scan(&before, &after)
+		if err == pgx.ErrNoRows {
+			// Deleted since: put it back with its old id
+			if err := tx.QueryRow(ctx, insertSQL, target).Scan(&after); err != nil {
+				return nil, fmt.Errorf("failed to restore row %d: %w", rowID, err)
+			}
+			applied = append(applied, rowChange{rowID: rowID, after: after})
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore row %d: %w", rowID, err)
+		}
+		if !bytes.Equal(before, after) {
+			applied = append(applied, rowChange{rowID: rowID, before: before, after: after})
+		}
+	}
+
+	summary := fmt.Sprintf("Rolled back to revision %d (%d rows)", revisionID, len(applied))
+	id, err := recordRevision(ctx, tx, schemaName, tableName, RevisionRollback, summary, authorID, applied)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &DatasetRevision{ID: id, TableName: tableName, Kind: RevisionRollback, Summary: summary, AuthorID: authorID, RowsChanged: len(applied), CreatedAt: time.Now()}, nil
+}
@@ -0,0 +1,304 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ColumnRoles says which dataset columns hold what the bot needs: the product
+// name, what search looks in, and the pricing fields. Empty roles are unset.
+type ColumnRoles struct {
+	Name       string   `json:"name,omitempty"`        // Display name of a row
+	SearchKeys []string `json:"search_keys,omitempty"` // Columns search looks in (all columns if empty)
+	UnitPrice  string   `json:"unit_price,omitempty"`
+	Currency   string   `json:"currency,omitempty"`
+	Unit       string   `json:"unit,omitempty"` // pcs, kg, meter, ...
+	Weight     string   `json:"weight,omitempty"`
+	MinOrder   string   `json:"min_order,omitempty"`
+	Image      string   `json:"image,omitempty"` // Image URL
+}
+
+// IsZero reports whether no role is set
+func (r ColumnRoles) IsZero() bool {
+	return r.Name == "" && len(r.SearchKeys) == 0 && r.UnitPrice == "" && r.Currency == "" &&
+		r.Unit == "" && r.Weight == "" && r.MinOrder == "" && r.Image == ""
+}
+
+// single lists the one-column roles with their JSON names
+func (r *ColumnRoles) single() map[string]*string {
+	return map[string]*string{
+		"name":       &r.Name,
+		"unit_price": &r.UnitPrice,
+		"currency":   &r.Currency,
+		"unit":       &r.Unit,
+		"weight":     &r.Weight,
+		"min_order":  &r.MinOrder,
+		"image":      &r.Image,
+	}
+}
+
+// numericRoles must be number (or numeric text) columns
+var numericRoles = map[string]bool{"unit_price": true, "weight": true, "min_order": true}
+
+// roleHints are the column names suggested for each role, best first. Names
+// match whole or as a word of the column name ("harga_satuan" has "harga").
+var roleHints = []struct {
+	role  string
+	names []string
+}{
+	// Price before unit so "unit_price" and "harga_satuan" are prices
+	{"unit_price", []string{"unit_price", "harga_satuan", "price", "harga", "harga_jual", "cost", "biaya", "tarif"}},
+	{"name", []string{"name", "nama", "nama_barang", "nama_produk", "product", "produk", "item", "barang", "title", "judul"}},
+	{"currency", []string{"currency", "mata_uang", "kurs", "ccy"}},
+	{"min_order", []string{"min_order", "minimum_order", "moq", "min_qty", "minimal_order", "min_pembelian", "minimum", "minimal"}},
+	{"weight", []string{"weight", "berat", "bobot"}},
+	{"image", []string{"image", "image_url", "gambar", "foto", "photo", "img", "picture"}},
+	{"unit", []string{"unit", "satuan", "uom"}},
+}
+
+// searchKeyHints are columns besides the name that are worth searching
+var searchKeyHints = []string{"sku", "kode", "code", "category", "kategori", "brand", "merek", "merk", "type", "tipe", "jenis", "description", "deskripsi", "keterangan"}
+
+// SuggestRoles guesses column roles from column names and types
+func SuggestRoles(cols []ColumnSchema) ColumnRoles {
+	var roles ColumnRoles
+	used := map[string]bool{}
+	fields := roles.single()
+	for _, hint := range roleHints {
+		col := suggestColumn(cols, used, hint.names, numericRoles[hint.role])
+		if col != "" {
+			*fields[hint.role] = col
+			used[col] = true
+		}
+	}
+
+	if roles.Name != "" {
+		roles.SearchKeys = append(roles.SearchKeys, roles.Name)
+	}
+	for _, c := range cols {
+		if c.Type == ColumnText && !used[c.Name] && matchesHint(c.Name, searchKeyHints) {
+			roles.SearchKeys = append(roles.SearchKeys, c.Name)
+		}
+	}
+	return roles
+}
+
+// suggestColumn picks the first unused column named like one of names,
+// preferring exact names over word matches
+func suggestColumn(cols []ColumnSchema, used map[string]bool, names []string, numeric bool) string {
+	usable := func(c ColumnSchema) bool {
+		return !used[c.Name] && (!numeric || roleColumnType(c.Type, true))
+	}
+	for _, name := range names {
+		for _, c := range cols {
+			if usable(c) && c.Name == name {
+				return c.Name
+			}
+		}
+	}
+	for _, name := range names {
+		for _, c := range cols {
+			if usable(c) && matchesHint(c.Name, []string{name}) {
+				return c.Name
+			}
+		}
+	}
+	return ""
+}
+
+// matchesHint reports whether a column name is one of names or has one as a word
+func matchesHint(column string, names []string) bool {
+	words := strings.Split(column, "_")
+	for _, name := range names {
+		if column == name {
+			return true
+		}
+		for _, w := range words {
+			if w == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// roleColumnType reports whether a column type can hold a role
+func roleColumnType(colType string, numeric bool) bool {
+	if !numeric {
+		return true
+	}
+	return colType == ColumnInteger || colType == ColumnDecimal || colType == ColumnText
+}
+
+// validateRoles checks every role names an existing column of a fitting type
+func validateRoles(roles ColumnRoles, cols []ColumnSchema) error {
+	types := make(map[string]string, len(cols))
+	for _, c := range cols {
+		types[c.Name] = c.Type
+	}
+	for role, col := range roles.single() {
+		if *col == "" {
+			continue
+		}
+		colType, ok := types[*col]
+		if !ok {
+			return fmt.Errorf("%w: %s (%s)", ErrColumnNotFound, *col, role)
+		}
+		if !roleColumnType(colType, numericRoles[role]) {
+			return fmt.Errorf("%w: %s column %s must be a number", ErrInvalidColumn, role, *col)
+		}
+	}
+	for _, col := range roles.SearchKeys {
+		if _, ok := types[col]; !ok {
+			return fmt.Errorf("%w: %s (search_keys)", ErrColumnNotFound, col)
+		}
+	}
+	return nil
+}
+
+// followColumns keeps roles pointing at the same columns after a schema
+// change: renamed columns (same position, new name) are followed and
+// dropped ones are unset
+func (r ColumnRoles) followColumns(before, after []ColumnSchema) ColumnRoles {
+	renamed := map[string]string{}
+	if len(before) == len(after) {
+		for i := range before {
+			renamed[before[i].Name] = after[i].Name
+		}
+	}
+	exists := map[string]bool{}
+	for _, c := range after {
+		exists[c.Name] = true
+	}
+	follow := func(col string) string {
+		if to, ok := renamed[col]; ok {
+			col = to
+		}
+		if !exists[col] {
+			return ""
+		}
+		return col
+	}
+
+	for _, col := range r.single() {
+		if *col != "" {
+			*col = follow(*col)
+		}
+	}
+	keys := r.SearchKeys
+	r.SearchKeys = nil
+	for _, col := range keys {
+		if col = follow(col); col != "" {
+			r.SearchKeys = append(r.SearchKeys, col)
+		}
+	}
+	return r
+}
+
+// storedRoles reads the roles saved for a dataset (zero if never set)
+func storedRoles(ctx context.Context, q pgxQuerier, schemaName, tableName string) (ColumnRoles, error) {
+	var roles ColumnRoles
+	err := q.QueryRow(ctx, fmt.Sprintf("SELECT COALESCE(roles, '{}') FROM %s WHERE table_name=$1", qualifyTable(schemaName, "dynamic_tables")), tableName).Scan(&roles)
+	if err == pgx.ErrNoRows {
+		return roles, ErrTableNotFound
+	}
+	return roles, err
+}
+
+// loadRoles returns a dataset's roles; datasets without saved roles
+// (imported before roles existed) get suggested ones
+func loadRoles(ctx context.Context, q pgxQuerier, schemaName, tableName string) (ColumnRoles, error) {
+	roles, err := storedRoles(ctx, q, schemaName, tableName)
+	if err != nil || !roles.IsZero() {
+		return roles, err
+	}
+	var cols []ColumnSchema
+	err = q.QueryRow(ctx, fmt.Sprintf("SELECT COALESCE(columns, '[]') FROM %s WHERE table_name=$1", qualifyTable(schemaName, "dynamic_tables")), tableName).Scan(&cols)
+	if err != nil {
+		return roles, err
+	}
+	return SuggestRoles(cols), nil
+}
+
+func saveRoles(ctx context.Context, tx pgx.Tx, schemaName, tableName string, roles ColumnRoles) error {
+	raw, err := json.Marshal(roles)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, fmt.Sprintf("UPDATE %s SET roles=$1 WHERE table_name=$2", qualifyTable(schemaName, "dynamic_tables")), raw, tableName)
+	return err
+}
+
+// GetRoles returns a dataset's column roles and the roles suggested for its columns
+func (m *TableManager) GetRoles(schemaName, nameOrDisplay string) (roles, suggested ColumnRoles, err error) {
+	ctx := context.Background()
+	if schemaName == "" {
+		schemaName = "public"
+	}
+	tableName, err := m.resolveTable(ctx, m.db, schemaName, nameOrDisplay)
+	if err != nil {
+		return roles, suggested, err
+	}
+	cols, err := m.GetColumns(schemaName, tableName)
+	if err != nil {
+		return roles, suggested, err
+	}
+	roles, err = loadRoles(ctx, m.db, schemaName, tableName)
+	return roles, SuggestRoles(cols), err
+}
+
+// SetRoles replaces a dataset's column roles. The search index is rebuilt
+// when the search keys change.
+func (m *TableManager) SetRoles(schemaName, nameOrDisplay string, roles ColumnRoles) error {
+	ctx := context.Background()
+	if schemaName == "" {
+		schemaName = "public"
+	}
+	tx, err := m.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tableName, err := m.resolveTable(ctx, tx, schemaName, nameOrDisplay)
+	if err != nil {
+		return err
+	}
+	cols, err := m.loadColumns(ctx, tx, schemaName, tableName)
+	if err != nil {
+		return err
+	}
+	if err := validateRoles(roles, cols); err != nil {
+		return err
+	}
+	old, err := loadRoles(ctx, tx, schemaName, tableName)
+	if err != nil {
+		return err
+	}
+	if err := saveRoles(ctx, tx, schemaName, tableName, roles); err != nil {
+		return err
+	}
+	if strings.Join(old.SearchKeys, ",") != strings.Join(roles.SearchKeys, ",") {
+		if err := indexRows(ctx, tx, schemaName, tableName, nil); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// Roles returns the column roles the bot uses for a dataset
+func (m *TableManager) Roles(schemaName, nameOrDisplay string) (ColumnRoles, error) {
+	ctx := context.Background()
+	if schemaName == "" {
+		schemaName = "public"
+	}
+	tableName, err := m.resolveTable(ctx, m.db, schemaName, nameOrDisplay)
+	if err != nil {
+		return ColumnRoles{}, err
+	}
+	return loadRoles(ctx, m.db, schemaName, tableName)
+}
@@ -0,0 +1,651 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Dataset column types
+const (
+	ColumnText    = "text"
+	ColumnInteger = "integer"
+	ColumnDecimal = "decimal"
+	ColumnBoolean = "boolean"
+	ColumnDate    = "date"
+)
+
+// columnSQLTypes maps dataset column types to PostgreSQL types
+var columnSQLTypes = map[string]string{
+	ColumnText:    "TEXT",
+	ColumnInteger: "BIGINT",
+	ColumnDecimal: "NUMERIC",
+	ColumnBoolean: "BOOLEAN",
+	ColumnDate:    "DATE",
+}
+
+// Errors returned by dataset schema operations
+var (
+	ErrTableNotFound    = errors.New("table not found")
+	ErrColumnNotFound   = errors.New("column not found")
+	ErrColumnExists     = errors.New("column already exists")
+	ErrInvalidColumn    = errors.New("invalid column")
+	ErrColumnConversion = errors.New("values cannot be converted")
+)
+
+// ColumnSchema describes one column of an imported dataset
+type ColumnSchema struct {
+	Name   string `json:"name"`             // SQL column name
+	Type   string `json:"type"`             // text, integer, decimal, boolean, date
+	Header string `json:"header,omitempty"` // Original header in the imported file
+	// DecimalPoint reads a lone "." or "," as a decimal point ("2.125" is
+	// 2.125, not 2125); set by import for columns without digit grouping
+	DecimalPoint bool `json:"decimal_point,omitempty"`
+}
+
+// ValidColumnType reports whether t is a supported dataset column type
+func ValidColumnType(t string) bool {
+	_, ok := columnSQLTypes[t]
+	return ok
+}
+
+// quoteIdent quotes a column or table identifier for SQL
+func quoteIdent(name string) string {
+	return pgx.Identifier{name}.Sanitize()
+}
+
+var dateLayouts = []string{"2006-01-02", "02/01/2006", "2006/01/02", "02-01-2006", "2 Jan 2006", "2 January 2006"}
+
+var (
+	currencyNoise   = regexp.MustCompile(`(?i)^(rp\.?|idr|usd|us\$|\$|€|eur|sgd|s\$)\s*|\s*(rp\.?|idr|usd|eur|sgd)$`)
+	thousandsDots   = regexp.MustCompile(`^-?\d{1,3}(\.\d{3})+$`)
+	thousandsCommas = regexp.MustCompile(`^-?\d{1,3}(,\d{3})+$`)
+)
+
+// ParseNumber parses a price or amount as written in spreadsheets:
+// "Rp 15.000", "15.000 IDR", "$1,250.00", "1.250,50", "12,5". Groups of
+// exactly three digits after "." or "," are thousands ("15.000", "15,000")
+// unless the number starts with 0 ("0,500"); otherwise the last of "." or
+// "," is the decimal separator.
+func ParseNumber(s string) (float64, bool) {
+	return parseNumber(s, false)
+}
+
+// cleanNumber strips currency marks and spaces from a written number
+func cleanNumber(s string) string {
+	s = strings.TrimSpace(s)
+	s = strings.TrimSuffix(strings.TrimSuffix(s, ",-"), ".-") // "Rp 15.000,-"
+	s = strings.TrimSpace(currencyNoise.ReplaceAllString(s, ""))
+	return strings.ReplaceAll(s, " ", "")
+}
+
+// parseNumber is ParseNumber; with decimalPoint a single "." or "," is always
+// the decimal separator, so only repeated ones group thousands
+func parseNumber(s string, decimalPoint bool) (float64, bool) {
+	s = cleanNumber(s)
+	if s == "" {
+		return 0, false
+	}
+
+	leadingZero := strings.HasPrefix(strings.TrimPrefix(s, "-"), "0")
+	dot, comma := strings.LastIndex(s, "."), strings.LastIndex(s, ",")
+	switch {
+	case dot >= 0 && comma >= 0:
+		if comma > dot { // 1.250,50
+			s = strings.ReplaceAll(s, ".", "")
+			s = strings.Replace(s, ",", ".", 1)
+		} else { // 1,250.50
+			s = strings.ReplaceAll(s, ",", "")
+		}
+	case comma >= 0:
+		if thousandsCommas.MatchString(s) && !leadingZero && !(decimalPoint && strings.Count(s, ",") == 1) {
+			s = strings.ReplaceAll(s, ",", "")
+		} else {
+			s = strings.Replace(s, ",", ".", 1)
+		}
+	case dot >= 0:
+		if thousandsDots.MatchString(s) && !leadingZero && !(decimalPoint && strings.Count(s, ".") == 1) {
+			s = strings.ReplaceAll(s, ".", "")
+		}
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	return f, err == nil
+}
+
+// parseBool accepts English and Indonesian yes/no spellings
+func parseBool(s string) (bool, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "true", "yes", "y", "ya", "iya", "benar":
+		return true, true
+	case "false", "no", "n", "tidak", "salah":
+		return false, true
+	}
+	return false, false
+}
+
+func parseDate(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// groupedNumber reports numbers that show digit grouping: a repeated
+// separator ("1.250.000") or both separators ("1.250,50")
+func groupedNumber(s string) bool {
+	s = cleanNumber(s)
+	dots, commas := strings.Count(s, "."), strings.Count(s, ",")
+	return dots > 1 || commas > 1 || (dots > 0 && commas > 0)
+}
+
+// convertValue turns a raw cell into the Go value stored for a column type.
+// Empty cells become NULL.
+func convertValue(raw, colType string) (any, error) {
+	return convertCell(raw, ColumnSchema{Type: colType})
+}
+
+// convertCell is convertValue following the column's number format
+func convertCell(raw string, col ColumnSchema) (any, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	switch col.Type {
+	case ColumnInteger:
+		if f, ok := parseNumber(raw, col.DecimalPoint); ok && f == float64(int64(f)) {
+			return int64(f), nil
+		}
+	case ColumnDecimal:
+		if f, ok := parseNumber(raw, col.DecimalPoint); ok {
+			return f, nil
+		}
+	case ColumnBoolean:
+		if b, ok := parseBool(raw); ok {
+			return b, nil
+		}
+	case ColumnDate:
+		if t, ok := parseDate(raw); ok {
+			return t, nil
+		}
+	default:
+		return raw, nil
+	}
+	return nil, fmt.Errorf("'%s' is not a valid %s", raw, col.Type)
+}
+
+// InferColumnType picks the narrowest type every non-empty value fits.
+// Columns with no values, or mixed values, stay text.
+func InferColumnType(values []string) string {
+	g := newTypeGuess()
+	for _, v := range values {
+		g.add(v)
+	}
+	return g.result()
+}
+
+// typeGuess infers a column type one value at a time, so imports can type
+// columns while streaming rows instead of holding them all. A value like
+// "2.125" is 2125 only if the column also shows digit grouping elsewhere;
+// otherwise it is a decimal, so candidates are kept for both readings.
+type typeGuess struct {
+	candidates        []string // Single three-digit groups are thousands
+	decimalCandidates []string // A lone separator is the decimal point
+	seen              bool
+	grouped           bool // Some value has grouped digits ("1.250.000")
+	ambiguous         bool // Some value reads differently ("2.125")
+}
+
+func newTypeGuess() *typeGuess {
+	return &typeGuess{
+		candidates:        []string{ColumnInteger, ColumnDecimal, ColumnBoolean, ColumnDate},
+		decimalCandidates: []string{ColumnInteger, ColumnDecimal, ColumnBoolean, ColumnDate},
+	}
+}
+
+func (g *typeGuess) add(v string) {
+	if strings.TrimSpace(v) == "" || len(g.candidates)+len(g.decimalCandidates) == 0 {
+		return
+	}
+	g.seen = true
+	if looksLikeCode(v) {
+		g.candidates, g.decimalCandidates = nil, nil
+		return
+	}
+	if f, ok := parseNumber(v, false); ok {
+		g.grouped = g.grouped || groupedNumber(v)
+		if d, ok := parseNumber(v, true); ok && d != f {
+			g.ambiguous = true
+		}
+	}
+	g.candidates = keepTypes(g.candidates, v, false)
+	g.decimalCandidates = keepTypes(g.decimalCandidates, v, true)
+}
+
+// keepTypes filters the types v converts to
+func keepTypes(types []string, v string, decimalPoint bool) []string {
+	kept := types[:0]
+	for _, t := range types {
+		if _, err := convertCell(v, ColumnSchema{Type: t, DecimalPoint: decimalPoint}); err == nil {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
+
+// decimalPoint reports whether a number column of this type reads lone
+// separators as decimal points
+func (g *typeGuess) decimalPoint(colType string) bool {
+	return (colType == ColumnInteger || colType == ColumnDecimal) && g.ambiguous && !g.grouped
+}
+
+func (g *typeGuess) result() string {
+	candidates := g.candidates
+	if g.ambiguous && !g.grouped {
+		candidates = g.decimalCandidates
+	}
+	if !g.seen || len(candidates) == 0 {
+		return ColumnText
+	}
+	return candidates[0]
+}
+
+// looksLikeCode reports numbers that must stay text, like phone numbers
+// and zero-padded SKUs ("0812...", "007")
+func looksLikeCode(v string) bool {
+	v = strings.TrimSpace(v)
+	return len(v) > 1 && v[0] == '0' && v[1] >= '0' && v[1] <= '9'
+}
+
+// displayValue converts database values into JSON/chat friendly values
+func displayValue(v any) any {
+	switch val := v.(type) {
+	case pgtype.Numeric:
+		if !val.Valid {
+			return nil
+		}
+		f, err := val.Float64Value()
+		if err != nil {
+			return nil
+		}
+		return f.Float64
+	case time.Time:
+		return val.Format("2006-01-02")
+	}
+	return v
+}
+
+// resolveTable finds a dataset by table_name or display_name
+func (m *TableManager) resolveTable(ctx context.Context, q pgxQuerier, schemaName, nameOrDisplay string) (string, error) {
+	var tableName string
+	err := q.QueryRow(ctx,
+		fmt.Sprintf("SELECT table_name FROM %s WHERE table_name=$1 OR display_name=$1 LIMIT 1", qualifyTable(schemaName, "dynamic_tables")),
+		nameOrDisplay).Scan(&tableName)
+	if err == pgx.ErrNoRows {
+		return "", ErrTableNotFound
+	}
+	return tableName, err
+}
+
+// pgxQuerier is satisfied by both the pool and a transaction
+type pgxQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// pgxReader runs queries on the pool or a transaction
+type pgxReader interface {
+	pgxQuerier
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// readColumns returns a dataset's recorded schema without writing. Datasets
+// imported before schemas were recorded get one built from the table; the
+// second result is false for those so the caller can save it.
+func readColumns(ctx context.Context, q pgxReader, schemaName, tableName string) ([]ColumnSchema, bool, error) {
+	var raw []byte
+	err := q.QueryRow(ctx, fmt.Sprintf("SELECT COALESCE(columns, '[]') FROM %s WHERE table_name=$1", qualifyTable(schemaName, "dynamic_tables")), tableName).Scan(&raw)
+	if err == pgx.ErrNoRows {
+		return nil, false, ErrTableNotFound
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	var cols []ColumnSchema
+	if err := json.Unmarshal(raw, &cols); err != nil {
+		return nil, false, err
+	}
+	if len(cols) > 0 {
+		return cols, true, nil
+	}
+
+	rows, err := q.Query(ctx, `
+		SELECT column_name, data_type FROM information_schema.columns
+		WHERE table_schema = $1 AND table_name = $2 AND column_name <> 'id'
+		ORDER BY ordinal_position
+	`, schemaName, tableName)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name, dataType string
+		if err := rows.Scan(&name, &dataType); err != nil {
+			return nil, false, err
+		}
+		cols = append(cols, ColumnSchema{Name: name, Type: columnTypeFromSQL(dataType)})
+	}
+	return cols, false, rows.Err()
+}
+
+// loadColumns returns a dataset's recorded schema inside a transaction,
+// saving the one built for datasets imported before schemas were recorded
+func (m *TableManager) loadColumns(ctx context.Context, tx pgx.Tx, schemaName, tableName string) ([]ColumnSchema, error) {
+	cols, recorded, err := readColumns(ctx, tx, schemaName, tableName)
+	if err != nil || recorded {
+		return cols, err
+	}
+	return cols, m.saveColumns(ctx, tx, schemaName, tableName, cols)
+}
+
+func columnTypeFromSQL(dataType string) string {
+	switch dataType {
+	case "bigint", "integer", "smallint":
+		return ColumnInteger
+	case "numeric", "double precision", "real":
+		return ColumnDecimal
+	case "boolean":
+		return ColumnBoolean
+	case "date", "timestamp without time zone", "timestamp with time zone":
+		return ColumnDate
+	}
+	return ColumnText
+}
+
+func (m *TableManager) saveColumns(ctx context.Context, tx pgx.Tx, schemaName, tableName string, cols []ColumnSchema) error {
+	raw, err := json.Marshal(cols)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, fmt.Sprintf("UPDATE %s SET columns=$1 WHERE table_name=$2", qualifyTable(schemaName, "dynamic_tables")), raw, tableName)
+	return err
+}
+
+// recordedColumns returns column name -> schema from the recorded schema (empty if none was recorded)
+func (m *TableManager) recordedColumns(ctx context.Context, schemaName, tableName string) (map[string]ColumnSchema, error) {
+	var cols []ColumnSchema
+	err := m.db.QueryRow(ctx, fmt.Sprintf("SELECT COALESCE(columns, '[]') FROM %s WHERE table_name=$1", qualifyTable(schemaName, "dynamic_tables")), tableName).Scan(&cols)
+	if err == pgx.ErrNoRows {
+		return nil, ErrTableNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]ColumnSchema, len(cols))
+	for _, c := range cols {
+		byName[c.Name] = c
+	}
+	return byName, nil
+}
+
+func findColumn(cols []ColumnSchema, name string) int {
+	for i, c := range cols {
+		if c.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// schemaChange runs fn inside a transaction with the dataset's current columns
+// and saves the columns fn returns. A non-empty summary records the change as
+// a revision by authorID.
+func (m *TableManager) schemaChange(schemaName, nameOrDisplay string, authorID int, summary string, fn func(ctx context.Context, tx pgx.Tx, table string, cols []ColumnSchema) ([]ColumnSchema, error)) error {
+	ctx := context.Background()
+	if schemaName == "" {
+		schemaName = "public"
+	}
+	tx, err := m.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tableName, err := m.resolveTable(ctx, tx, schemaName, nameOrDisplay)
+	if err != nil {
+		return err
+	}
+	cols, err := m.loadColumns(ctx, tx, schemaName, tableName)
+	if err != nil {
+		return err
+	}
+	before := append([]ColumnSchema(nil), cols...)
+	cols, err = fn(ctx, tx, qualifyTable(schemaName, tableName), cols)
+	if err != nil {
+		return err
+	}
+	if err := m.saveColumns(ctx, tx, schemaName, tableName, cols); err != nil {
+		return err
+	}
+	roles, err := storedRoles(ctx, tx, schemaName, tableName)
+	if err != nil {
+		return err
+	}
+	if !roles.IsZero() {
+		if err := saveRoles(ctx, tx, schemaName, tableName, roles.followColumns(before, cols)); err != nil {
+			return err
+		}
+	}
+	// Search documents hold the row's values, so dropped columns must leave them
+	if !slices.Equal(before, cols) {
+		if err := indexRows(ctx, tx, schemaName, tableName, nil); err != nil {
+			return err
+		}
+	}
+	if summary != "" {
+		if _, err := recordRevision(ctx, tx, schemaName, tableName, RevisionColumns, summary, authorID, nil); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// GetColumns returns the schema of a dataset. It only reads, except once
+// for datasets whose schema was never recorded.
+func (m *TableManager) GetColumns(schemaName, nameOrDisplay string) ([]ColumnSchema, error) {
+	ctx := context.Background()
+	if schemaName == "" {
+		schemaName = "public"
+	}
+	tableName, err := m.resolveTable(ctx, m.db, schemaName, nameOrDisplay)
+	if err != nil {
+		return nil, err
+	}
+	cols, recorded, err := readColumns(ctx, m.db, schemaName, tableName)
+	if err != nil || recorded {
+		return cols, err
+	}
+
+	var result []ColumnSchema
+	err = m.schemaChange(schemaName, tableName, 0, "", func(ctx context.Context, tx pgx.Tx, table string, cols []ColumnSchema) ([]ColumnSchema, error) {
+		result = cols
+		return cols, nil
+	})
+	return result, err
+}
+
+// AddColumn appends an empty column to a dataset
+func (m *TableManager) AddColumn(schemaName, nameOrDisplay, column, colType string, authorID int) (*ColumnSchema, error) {
+	name := sanitizeTableName(column)
+	if name == "" || name == "id" {
+		return nil, fmt.Errorf("%w: name '%s'", ErrInvalidColumn, column)
+	}
+	if !ValidColumnType(colType) {
+		return nil, fmt.Errorf("%w: type '%s'", ErrInvalidColumn, colType)
+	}
+	added := ColumnSchema{Name: name, Type: colType, Header: column}
+	summary := fmt.Sprintf("Added column %s (%s)", name, colType)
+	err := m.schemaChange(schemaName, nameOrDisplay, authorID, summary, func(ctx context.Context, tx pgx.Tx, table string, cols []ColumnSchema) ([]ColumnSchema, error) {
+		if findColumn(cols, name) >= 0 {
+			return nil, fmt.Errorf("%w: %s", ErrColumnExists, name)
+		}
+		if _, err := tx.Exec(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, quoteIdent(name), columnSQLTypes[colType])); err != nil {
+			return nil, err
+		}
+		return append(cols, added), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &added, nil
+}
+
+// RenameColumn renames a dataset column
+func (m *TableManager) RenameColumn(schemaName, nameOrDisplay, column, newName string, authorID int) error {
+	name := sanitizeTableName(newName)
+	if name == "" || name == "id" {
+		return fmt.Errorf("%w: name '%s'", ErrInvalidColumn, newName)
+	}
+	summary := fmt.Sprintf("Renamed column %s to %s", column, name)
+	return m.schemaChange(schemaName, nameOrDisplay, authorID, summary, func(ctx context.Context, tx pgx.Tx, table string, cols []ColumnSchema) ([]ColumnSchema, error) {
+		i := findColumn(cols, column)
+		if i < 0 {
+			return nil, fmt.Errorf("%w: %s", ErrColumnNotFound, column)
+		}
+		if name == column {
+			return cols, nil
+		}
+		if findColumn(cols, name) >= 0 {
+			return nil, fmt.Errorf("%w: %s", ErrColumnExists, name)
+		}
+		if _, err := tx.Exec(ctx, fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s", table, quoteIdent(column), quoteIdent(name))); err != nil {
+			return nil, err
+		}
+		cols[i].Name = name
+		return cols, nil
+	})
+}
+
+// maxConversionErrors caps how many failing rows a retype error lists
+const maxConversionErrors = 5
+
+// retypeColumn holds converted values while RetypeColumn swaps a column
+const retypeColumn = "__retype"
+
+// RetypeColumn changes a column's type, converting every existing value with
+// the same rules as import. Fails without changes if any value cannot be converted.
+func (m *TableManager) RetypeColumn(schemaName, nameOrDisplay, column, colType string, authorID int) error {
+	if !ValidColumnType(colType) {
+		return fmt.Errorf("%w: type '%s'", ErrInvalidColumn, colType)
+	}
+	summary := fmt.Sprintf("Changed column %s to %s", column, colType)
+	return m.schemaChange(schemaName, nameOrDisplay, authorID, summary, func(ctx context.Context, tx pgx.Tx, table string, cols []ColumnSchema) ([]ColumnSchema, error) {
+		i := findColumn(cols, column)
+		if i < 0 {
+			return nil, fmt.Errorf("%w: %s", ErrColumnNotFound, column)
+		}
+		if cols[i].Type == colType {
+			return cols, nil
+		}
+
+		// Convert in Go so "Rp 15.000" or "ya" convert the same way as on import
+		rows, err := tx.Query(ctx, fmt.Sprintf("SELECT id, %s::text FROM %s WHERE %[1]s IS NOT NULL ORDER BY id", quoteIdent(column), table))
+		if err != nil {
+			return nil, err
+		}
+		var rawIDs []int
+		var raws []string
+		guess := newTypeGuess()
+		for rows.Next() {
+			var id int
+			var raw string
+			if err := rows.Scan(&id, &raw); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			rawIDs, raws = append(rawIDs, id), append(raws, raw)
+			guess.add(raw)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+
+		// Text is read like an imported file; numbers arrive as "2.125"
+		target := ColumnSchema{Type: colType, DecimalPoint: guess.decimalPoint(colType)}
+		if cols[i].Type == ColumnInteger || cols[i].Type == ColumnDecimal {
+			target.DecimalPoint = true
+		}
+		// Converted values travel as canonical text and are cast by PostgreSQL
+		var ids []int
+		var values []string
+		var failures []string
+		for n, raw := range raws {
+			v, err := convertCell(raw, target)
+			if err != nil {
+				if len(failures) < maxConversionErrors {
+					failures = append(failures, fmt.Sprintf("row %d: %v", rawIDs[n], err))
+				}
+				continue
+			}
+			if v != nil {
+				ids = append(ids, rawIDs[n])
+				values = append(values, keyString(v))
+			}
+		}
+		if len(failures) > 0 {
+			return nil, fmt.Errorf("%w to %s (%s)", ErrColumnConversion, colType, strings.Join(failures, "; "))
+		}
+
+		// A fixed name: column names near the 63-byte limit cannot take a suffix
+		tmp := quoteIdent(retypeColumn)
+		sqlType := columnSQLTypes[colType]
+		if _, err := tx.Exec(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, tmp, sqlType)); err != nil {
+			return nil, err
+		}
+		if len(ids) > 0 {
+			if _, err := tx.Exec(ctx, fmt.Sprintf(`
+				UPDATE %s AS t SET %s = v.value::%s
+				FROM unnest($1::int[], $2::text[]) AS v(id, value)
+				WHERE t.id = v.id
+			`, table, tmp, sqlType), ids, values); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := tx.Exec(ctx, fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", table, quoteIdent(column))); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(ctx, fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s", table, tmp, quoteIdent(column))); err != nil {
+			return nil, err
+		}
+		cols[i].Type, cols[i].DecimalPoint = colType, guess.decimalPoint(colType)
+		return cols, nil
+	})
+}
+
+// DropColumn removes a column and its data
+func (m *TableManager) DropColumn(schemaName, nameOrDisplay, column string, authorID int) error {
+	return m.schemaChange(schemaName, nameOrDisplay, authorID, "Dropped column "+column, func(ctx context.Context, tx pgx.Tx, table string, cols []ColumnSchema) ([]ColumnSchema, error) {
+		i := findColumn(cols, column)
+		if i < 0 {
+			return nil, fmt.Errorf("%w: %s", ErrColumnNotFound, column)
+		}
+		if len(cols) == 1 {
+			return nil, fmt.Errorf("%w: cannot drop the last column", ErrInvalidColumn)
+		}
+		if _, err := tx.Exec(ctx, fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", table, quoteIdent(column))); err != nil {
+			return nil, err
+		}
+		return append(cols[:i], cols[i+1:]...), nil
+	})
+}
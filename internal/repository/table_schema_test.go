@@ -0,0 +1,109 @@
+package repository
+
+import "testing"
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		in   string
+		want float64
+		ok   bool
+	}{
+		{"15000", 15000, true},
+		{"15.000", 15000, true},
+		{"15.500", 15500, true},
+		{"1.250.000", 1250000, true},
+		{"15,000", 15000, true},
+		{"1,250,000", 1250000, true},
+		{"Rp 15.000", 15000, true},
+		{"Rp. 1.250.000", 1250000, true},
+		{"Rp 15.000,-", 15000, true},
+		{"15.000 IDR", 15000, true},
+		{"IDR 15.000", 15000, true},
+		{"$1,250.00", 1250, true},
+		{"1.250,50", 1250.5, true},
+		{"1,250.50", 1250.5, true},
+		{"12,5", 12.5, true},
+		{"12.5", 12.5, true},
+		{"1.25", 1.25, true},
+		{"0,500", 0.5, true},
+		{"0.500", 0.5, true},
+		{"-15.000", -15000, true},
+		{"1.5.0", 0, false},
+		{"", 0, false},
+		{"Rp", 0, false},
+		{"abc", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseNumber(tt.in)
+		if ok != tt.ok || (ok && got != tt.want) {
+			t.Errorf("ParseNumber(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseNumberDecimalPoint(t *testing.T) {
+	tests := []struct {
+		in   string
+		want float64
+		ok   bool
+	}{
+		{"2.125", 2.125, true},
+		{"2,125", 2.125, true},
+		{"0.375", 0.375, true},
+		{"15.000", 15, true},
+		{"1.250.000", 1250000, true},
+		{"1,250,000", 1250000, true},
+		{"1.250,50", 1250.5, true},
+		{"Rp 12,5", 12.5, true},
+		{"1.5.0", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseNumber(tt.in, true)
+		if ok != tt.ok || (ok && got != tt.want) {
+			t.Errorf("parseNumber(%q, true) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestTypeGuess(t *testing.T) {
+	tests := []struct {
+		values       []string
+		want         string
+		decimalPoint bool
+		converted    []any
+	}{
+		// A lone three-digit group is a decimal unless the column groups digits
+		{[]string{"2.125", "0.375", "1.5"}, ColumnDecimal, true, []any{2.125, 0.375, 1.5}},
+		{[]string{"2.125", "3.250"}, ColumnDecimal, true, []any{2.125, 3.25}},
+		{[]string{"2,125", "0,375"}, ColumnDecimal, true, []any{2.125, 0.375}},
+		{[]string{"15.000", "1.250.000"}, ColumnInteger, false, []any{int64(15000), int64(1250000)}},
+		{[]string{"Rp 15.000", "Rp 1.250,50"}, ColumnDecimal, false, []any{15000.0, 1250.5}},
+		{[]string{"15,000", "1,250,000"}, ColumnInteger, false, []any{int64(15000), int64(1250000)}},
+		{[]string{"15000", "2.5"}, ColumnDecimal, false, []any{15000.0, 2.5}},
+		{[]string{"12", "", "7"}, ColumnInteger, false, []any{int64(12), nil, int64(7)}},
+		{[]string{"ya", "tidak"}, ColumnBoolean, false, []any{true, false}},
+		{[]string{"2.125", "abc"}, ColumnText, false, []any{"2.125", "abc"}},
+		{[]string{"0812345678", "0899"}, ColumnText, false, []any{"0812345678", "0899"}},
+		{[]string{"", " "}, ColumnText, false, []any{nil, nil}},
+	}
+	for _, tt := range tests {
+		g := newTypeGuess()
+		for _, v := range tt.values {
+			g.add(v)
+		}
+		got := g.result()
+		col := ColumnSchema{Type: got, DecimalPoint: g.decimalPoint(got)}
+		if col.Type != tt.want || col.DecimalPoint != tt.decimalPoint {
+			t.Errorf("typeGuess(%q) = %v, %v; want %v, %v", tt.values, col.Type, col.DecimalPoint, tt.want, tt.decimalPoint)
+			continue
+		}
+		if InferColumnType(tt.values) != tt.want {
+			t.Errorf("InferColumnType(%q) = %v; want %v", tt.values, InferColumnType(tt.values), tt.want)
+		}
+		for i, v := range tt.values {
+			if got, err := convertCell(v, col); err != nil || got != tt.converted[i] {
+				t.Errorf("convertCell(%q, %+v) = %v, %v; want %v", v, col, got, err, tt.converted[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,329 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Search tuning
+const (
+	defaultSearchLimit = 10
+	maxSearchLimit     = 100
+	// fuzzyThreshold is the pg_trgm word similarity a typo'd query needs
+	// ("bears" finds "beras"); lower matches more loosely
+	fuzzyThreshold = "0.4"
+)
+
+// DatasetSearchTableDDL creates the search index of a schema's datasets: one
+// document per row with a full-text vector and trigram index for fuzzy matches.
+// Requires the pg_trgm extension.
+func DatasetSearchTableDDL(schemaName string) string {
+	return fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %[1]s.dataset_search (
+			table_name VARCHAR(128) NOT NULL,
+			row_id INTEGER NOT NULL,
+			content TEXT NOT NULL, -- Every value of the row but id
+			tsv TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple', content)) STORED,
+			PRIMARY KEY (table_name, row_id)
+		);
+		CREATE INDEX IF NOT EXISTS idx_dataset_search_tsv ON %[1]s.dataset_search USING GIN (tsv);
+		CREATE INDEX IF NOT EXISTS idx_dataset_search_trgm ON %[1]s.dataset_search USING GIN (content gin_trgm_ops)
+	`, schemaName)
+}
+
+// searchContentSQL is the indexed text of a dataset row (aliased t): the
+// values of the search key columns passed as parameter keysParam, or of
+// every column if there are none
+func searchContentSQL(keysParam int) string {
+	return fmt.Sprintf(`COALESCE((SELECT string_agg(value, ' ') FROM jsonb_each_text(to_jsonb(t) - 'id') WHERE cardinality($%[1]d::text[]) = 0 OR key = ANY($%[1]d)), '')`, keysParam)
+}
+
+// indexRows refreshes the search documents of the given rows; rows that no
+// longer exist are dropped. nil ids re-indexes the whole dataset.
+func indexRows(ctx context.Context, tx pgx.Tx, schemaName, tableName string, ids []int) error {
+	index := qualifyTable(schemaName, "dataset_search")
+	table := qualifyTable(schemaName, tableName)
+	roles, err := loadRoles(ctx, tx, schemaName, tableName)
+	if err != nil {
+		return err
+	}
+	keys := roles.SearchKeys
+	if keys == nil {
+		keys = []string{}
+	}
+
+	if ids == nil {
+		if _, err := tx.Exec(ctx, fmt.Sprintf("DELETE FROM %s WHERE table_name = $1", index), tableName); err != nil {
+			return fmt.Errorf("failed to clear search index: %w", err)
+		}
+		_, err := tx.Exec(ctx, fmt.Sprintf("INSERT INTO %s (table_name, row_id, content) SELECT $1, t.id, %s FROM %s t", index, searchContentSQL(2), table), tableName, keys)
+		if err != nil {
+			return fmt.Errorf("failed to index %s: %w", tableName, err)
+		}
+		_, err = tx.Exec(ctx, fmt.Sprintf("UPDATE %s SET search_indexed = TRUE WHERE table_name = $1", qualifyTable(schemaName, "dynamic_tables")), tableName)
+		return err
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+	if _, err := tx.Exec(ctx, fmt.Sprintf("DELETE FROM %s WHERE table_name = $1 AND row_id = ANY($2)", index), tableName, ids); err != nil {
+		return fmt.Errorf("failed to update search index: %w", err)
+	}
+	_, err = tx.Exec(ctx, fmt.Sprintf("INSERT INTO %s (table_name, row_id, content) SELECT $1, t.id, %s FROM %s t WHERE t.id = ANY($2)", index, searchContentSQL(3), table), tableName, ids, keys)
+	if err != nil {
+		return fmt.Errorf("failed to update search index: %w", err)
+	}
+	return nil
+}
+
+// changedIDs lists the rows a revision touched
+func changedIDs(changes []rowChange) []int {
+	ids := make([]int, 0, len(changes))
+	for _, c := range changes {
+		ids = append(ids, c.rowID)
+	}
+	return ids
+}
+
+// BackfillSearchIndex indexes a schema's datasets created before the search
+// index existed. It runs at startup so Search only ever reads; imports and
+// edits keep the index current after that.
+func (m *TableManager) BackfillSearchIndex(schemaName string) error {
+	ctx := context.Background()
+	rows, err := m.db.Query(ctx, fmt.Sprintf("SELECT table_name FROM %s WHERE NOT COALESCE(search_indexed, FALSE)", qualifyTable(schemaName, "dynamic_tables")))
+	if err != nil {
+		return err
+	}
+	tables, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return err
+	}
+
+	for _, tableName := range tables {
+		tx, err := m.db.Begin(ctx)
+		if err != nil {
+			return err
+		}
+		if err := indexRows(ctx, tx, schemaName, tableName, nil); err != nil {
+			tx.Rollback(ctx)
+			return err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SearchHit is one dataset row matching a search
+type SearchHit struct {
+	TableName   string         `json:"table_name"`
+	DisplayName string         `json:"display_name"`
+	RowID       int            `json:"row_id"`
+	Score       float64        `json:"score"`
+	Row         map[string]any `json:"row"`
+}
+
+// SearchResult is one page of ranked search hits across a tenant's datasets
+type SearchResult struct {
+	Query   string                    `json:"query"`
+	Hits    []SearchHit               `json:"hits"`
+	Columns map[string][]ColumnSchema `json:"columns"` // Column order of each dataset with hits
+	Roles   map[string]ColumnRoles    `json:"roles"`   // Column roles of each dataset with hits
+	Total   int                       `json:"total"`
+	Limit   int                       `json:"limit"`
+	Offset  int                       `json:"offset"`
+}
+
+// searchTSQuery turns free text into a prefix tsquery ("ber pand" ->
+// "ber:* & pand:*"), dropping characters tsquery would treat as syntax
+func searchTSQuery(query string) string {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, w := range words {
+		words[i] = w + ":*"
+	}
+	return strings.Join(words, " & ")
+}
+
+// Search finds rows across all of a tenant's datasets. Full-text matches
+// (with word prefixes) rank first; trigram similarity catches typos.
+func (m *TableManager) Search(schemaName, query string, limit, offset int) (*SearchResult, error) {
+	ctx := context.Background()
+	if schemaName == "" {
+		schemaName = "public"
+	}
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	result := &SearchResult{
+		Query:   strings.TrimSpace(query),
+		Hits:    []SearchHit{},
+		Columns: map[string][]ColumnSchema{},
+		Roles:   map[string]ColumnRoles{},
+		Limit:   min(limit, maxSearchLimit),
+		Offset:  max(offset, 0),
+	}
+	tsq := searchTSQuery(result.Query)
+	if tsq == "" {
+		return result, nil
+	}
+	tx, err := m.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+	if _, err := tx.Exec(ctx, "SELECT set_config('pg_trgm.word_similarity_threshold', $1, true)", fuzzyThreshold); err != nil {
+		return nil, err
+	}
+
+	index := qualifyTable(schemaName, "dataset_search")
+	registry := qualifyTable(schemaName, "dynamic_tables")
+	match := "s.tsv @@ to_tsquery('simple', $2) OR $1 <% s.content"
+	if err := tx.QueryRow(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s s WHERE %s", index, match), result.Query, tsq).Scan(&result.Total); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx, fmt.Sprintf(`
+		SELECT s.table_name, COALESCE(d.display_name, s.table_name), s.row_id,
+			ts_rank(s.tsv, to_tsquery('simple', $2)) + word_similarity($1, s.content) AS score
+		FROM %s s JOIN %s d ON d.table_name = s.table_name
+		WHERE %s
+		ORDER BY score DESC, s.table_name, s.row_id
+		LIMIT $3 OFFSET $4
+	`, index, registry, match), result.Query, tsq, result.Limit, result.Offset)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var hit SearchHit
+		var score float32
+		if err := rows.Scan(&hit.TableName, &hit.DisplayName, &hit.RowID, &score); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		hit.Score = float64(score)
+		result.Hits = append(result.Hits, hit)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Load the matched rows, one query per dataset
+	ids := map[string][]int{}
+	for _, hit := range result.Hits {
+		ids[hit.TableName] = append(ids[hit.TableName], hit.RowID)
+	}
+	values := map[string]map[int]map[string]any{}
+	for tableName, tableIDs := range ids {
+		cols, _, err := readColumns(ctx, tx, schemaName, tableName)
+		if err != nil {
+			return nil, err
+		}
+		result.Columns[tableName] = cols
+		if result.Roles[tableName], err = loadRoles(ctx, tx, schemaName, tableName); err != nil {
+			return nil, err
+		}
+		values[tableName], err = loadRows(ctx, tx, qualifyTable(schemaName, tableName), tableIDs)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for i := range result.Hits {
+		result.Hits[i].Row = values[result.Hits[i].TableName][result.Hits[i].RowID]
+	}
+	return result, tx.Commit(ctx)
+}
+
+// loadRows reads rows by id as display values
+func loadRows(ctx context.Context, tx pgx.Tx, table string, ids []int) (map[int]map[string]any, error) {
+	rows, err := tx.Query(ctx, fmt.Sprintf("SELECT * FROM %s WHERE id = ANY($1)", table), ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := map[int]map[string]any{}
+	fieldDescs := rows.FieldDescriptions()
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return nil, err
+		}
+		row := make(map[string]any, len(values))
+		id := 0
+		for i, fd := range fieldDescs {
+			row[fd.Name] = displayValue(values[i])
+			if v, ok := values[i].(int32); ok && fd.Name == "id" {
+				id = int(v)
+			}
+		}
+		result[id] = row
+	}
+	return result, rows.Err()
+}
+
+// FindProduct returns the row of a dataset that best matches a product name
+// in the given columns, nil if none does. Candidates come from the search
+// index; an exact match ranks first, then a prefix match, then trigram
+// similarity, so "tumbler" finds "Tumbler" before "Tumbler Lid".
+func (m *TableManager) FindProduct(schemaName string, schema *TableSchema, name string, columns []string) (map[string]any, error) {
+	ctx := context.Background()
+	if schemaName == "" {
+		schemaName = "public"
+	}
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return nil, nil
+	}
+
+	var exact, prefix []string
+	for _, col := range columns {
+		if findColumn(schema.Columns, col) < 0 {
+			continue // Like TableQuery.SearchColumns, missing columns are ignored
+		}
+		value := fmt.Sprintf("LOWER(t.%s::text)", quoteIdent(col))
+		exact = append(exact, value+" = $3")
+		prefix = append(prefix, value+" LIKE $4")
+	}
+	if len(exact) == 0 {
+		exact, prefix = []string{"FALSE"}, []string{"FALSE"}
+	}
+
+	tx, err := m.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+	if _, err := tx.Exec(ctx, "SELECT set_config('pg_trgm.word_similarity_threshold', $1, true)", fuzzyThreshold); err != nil {
+		return nil, err
+	}
+
+	table := qualifyTable(schemaName, schema.TableName)
+	var id int
+	err = tx.QueryRow(ctx, fmt.Sprintf(`
+		SELECT t.id FROM %s s JOIN %s t ON t.id = s.row_id
+		WHERE s.table_name = $1 AND (s.content ILIKE $2 OR $3 <%% s.content)
+		ORDER BY (%s) DESC, (%s) DESC, word_similarity($3, s.content) DESC, t.id
+		LIMIT 1
+	`, qualifyTable(schemaName, "dataset_search"), table, strings.Join(exact, " OR "), strings.Join(prefix, " OR ")),
+		schema.TableName, "%"+name+"%", name, name+"%").Scan(&id)
+	if err == pgx.ErrNoRows {
+		return nil, tx.Commit(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	rows, err := loadRows(ctx, tx, table, []int{id})
+	if err != nil {
+		return nil, err
+	}
+	return rows[id], tx.Commit(ctx)
+}
@@ -42,7 +42,21 @@ func (t *TenantManager) CreateTenantSchema(userID int) (string, error) {
 	}
 
 	// Create tenant-specific tables
-	tables := []string{
+	tables := tenantTables(schemaName)
+
+	for _, ddl := range tables {
+		if _, err := tx.Exec(ctx, ddl); err != nil {
+			return "", fmt.Errorf("failed to create table: %w", err)
+		}
+	}
+
+	return schemaName, tx.Commit(ctx)
+}
+
+// tenantTables returns the DDL for every table a tenant schema needs.
+// All statements are idempotent so they double as upgrades for older tenants.
+func tenantTables(schemaName string) []string {
+	return []string{
 		fmt.Sprintf(`
 			CREATE TABLE IF NOT EXISTS %s.bot_config (
 				id SERIAL PRIMARY KEY,
@@ -61,22 +75,86 @@ func (t *TenantManager) CreateTenantSchema(userID int) (string, error) {
 			)
 		`, schemaName),
 		fmt.Sprintf(`
-			CREATE TABLE IF NOT EXISTS %s.dynamic_tables (
+			CREATE TABLE IF NOT EXISTS %[1]s.dynamic_tables (
 				id SERIAL PRIMARY KEY,
 				table_name VARCHAR(128) UNIQUE NOT NULL,
 				display_name VARCHAR(256) NOT NULL,
+				columns JSONB DEFAULT '[]', -- [{name, type, header}]
 				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-			)
+			);
+			ALTER TABLE %[1]s.dynamic_tables ADD COLUMN IF NOT EXISTS columns JSONB DEFAULT '[]';
+			ALTER TABLE %[1]s.dynamic_tables ADD COLUMN IF NOT EXISTS search_indexed BOOLEAN DEFAULT FALSE; -- Backfilled into dataset_search
+			ALTER TABLE %[1]s.dynamic_tables ADD COLUMN IF NOT EXISTS roles JSONB DEFAULT '{}' -- ColumnRoles
 		`, schemaName),
+		ContactsTableDDL(schemaName),
+		IntentRulesTableDDL(schemaName),
+		DatasetRevisionsTableDDL(schemaName),
+		DatasetSearchTableDDL(schemaName),
+		PricingRulesTableDDL(schemaName),
+		CartItemsTableDDL(schemaName),
+		OrdersTableDDL(schemaName),
+		OrderHistoryTableDDL(schemaName),
+		ExchangeRatesTableDDL(schemaName),
 	}
+}
 
-	for _, ddl := range tables {
-		if _, err := tx.Exec(ctx, ddl); err != nil {
-			return "", fmt.Errorf("failed to create table: %w", err)
+// ContactsTableDDL creates the end-customer registry of a schema
+func ContactsTableDDL(schemaName string) string {
+	return fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %[1]s.contacts (
+			id SERIAL PRIMARY KEY,
+			platform VARCHAR(20) NOT NULL,
+			chat_id VARCHAR(100) NOT NULL,
+			user_id INTEGER, -- Bot owner that talks to this contact (0 = platform bot)
+			display_name VARCHAR(256), -- WhatsApp push name or Telegram username
+			language VARCHAR(16),
+			tags TEXT[] DEFAULT '{}',
+			opted_out BOOLEAN DEFAULT FALSE,
+			first_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			last_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (platform, chat_id)
+		);
+		ALTER TABLE %[1]s.contacts ADD COLUMN IF NOT EXISTS display_name VARCHAR(256);
+		ALTER TABLE %[1]s.contacts ADD COLUMN IF NOT EXISTS language VARCHAR(16);
+		CREATE INDEX IF NOT EXISTS idx_contacts_tags ON %[1]s.contacts USING GIN(tags);
+		CREATE INDEX IF NOT EXISTS idx_contacts_last_seen ON %[1]s.contacts(last_seen_at)
+	`, schemaName)
+}
+
+// UpgradeTenantSchemas applies the current tenant DDL to every existing tenant
+// so tables added in newer versions exist for old accounts too.
+func (t *TenantManager) UpgradeTenantSchemas() error {
+	ctx := context.Background()
+	schemas, err := t.TenantSchemas()
+	if err != nil {
+		return err
+	}
+	for _, schemaName := range schemas {
+		for _, ddl := range tenantTables(schemaName) {
+			if _, err := t.db.Exec(ctx, ddl); err != nil {
+				return fmt.Errorf("failed to upgrade %s: %w", schemaName, err)
+			}
 		}
 	}
+	return nil
+}
 
-	return schemaName, tx.Commit(ctx)
+// TenantSchemas lists the schemas of every tenant
+func (t *TenantManager) TenantSchemas() ([]string, error) {
+	rows, err := t.db.Query(context.Background(), `SELECT DISTINCT schema_name FROM users WHERE schema_name LIKE 'tenant_%'`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var schemas []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, sanitizeSchemaName(name))
+	}
+	return schemas, rows.Err()
 }
 
 // DropTenantSchema removes a user's schema and all data
@@ -150,13 +150,16 @@ func (r *UsageRepository) GetQuotaStatus(userID int, dailyLimit, monthlyLimit in
 
 // CanSendMessage checks if user can send a message based on quotas
 func (r *UsageRepository) CanSendMessage(userID int, dailyLimit, monthlyLimit int) (bool, string) {
-	todaySent, _, _ := r.GetTodayUsage(userID)
-	monthSent, _, _ := r.GetMonthUsage(userID)
+	status, _ := r.GetQuotaStatus(userID, dailyLimit, monthlyLimit)
+	return status.CanSend()
+}
 
-	if dailyLimit > 0 && todaySent >= dailyLimit {
+// CanSend reports whether another message fits in the quota, with the reason if not
+func (s *UserQuotaStatus) CanSend() (bool, string) {
+	if s.DailyLimit > 0 && s.TodaySent >= s.DailyLimit {
 		return false, "Daily message limit reached"
 	}
-	if monthlyLimit > 0 && monthSent >= monthlyLimit {
+	if s.MonthlyLimit > 0 && s.MonthSent >= s.MonthlyLimit {
 		return false, "Monthly message limit reached"
 	}
 	return true, ""
@@ -0,0 +1,203 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"project_masAde/internal/entities"
+	"project_masAde/internal/infrastructure"
+	"project_masAde/internal/repository"
+	"strconv"
+	"time"
+)
+
+// Errors returned by AgentInbox
+var (
+	ErrChatNotFound     = errors.New("chat not found")
+	ErrChatNotTakenOver = errors.New("chat is not taken over by an agent")
+	ErrChatTakenByOther = errors.New("chat is handled by another agent")
+)
+
+const (
+	handoffRequestedText = "👩‍💼 Permintaan Anda sudah kami teruskan ke tim kami.\nMohon tunggu, staf kami akan segera membalas di sini."
+	handoffReleasedText  = "🤖 Terima kasih! Anda kembali terhubung dengan bot.\nKetik *MENU* untuk melihat pilihan."
+)
+
+// requestHandoff puts the chat in the inbox queue and silences the bot
+func (s *MessageService) requestHandoff(msg entities.Message) error {
+	if s.Conversations == nil {
+		return s.sendReply(msg, "⚠️ Layanan staf belum tersedia.")
+	}
+	err := s.Conversations.Transition(conversationKeyFor(msg), StateHandoffPending, map[string]string{
+		"user_id":      strconv.Itoa(msg.UserID),
+		"requested_at": time.Now().Format(time.RFC3339),
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("[BOT] Handoff requested by %s (%s)\n", msg.From, msg.Platform)
+	publishInboxUpdate(s.Router, conversationKeyFor(msg), StateHandoffPending)
+	return s.sendReply(msg, handoffRequestedText)
+}
+
+// humanHandling reports whether a human owns the chat (queued or taken over).
+// Customer messages do not extend the idle timeout: only staff taking over or
+// replying does, so a chat nobody answers returns to the bot.
+func (s *MessageService) humanHandling(msg entities.Message) bool {
+	if s.Conversations == nil {
+		return false
+	}
+	state := s.Conversations.Get(conversationKeyFor(msg))
+	return state.State == StateHandoffPending || state.State == StateHumanAgent
+}
+
+// InboxChat is a chat waiting for or handled by a human agent
+type InboxChat struct {
+	Platform    string     `json:"platform"`
+	ChatID      string     `json:"chat_id"`
+	State       string     `json:"state"`              // handoff_pending or human_agent
+	AgentID     int        `json:"agent_id,omitempty"` // Staff user who took over
+	RequestedAt string     `json:"requested_at,omitempty"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	ExpiresAt   *time.Time `json:"expires_at"` // Returns to the bot after this
+}
+
+// AgentInbox lets dashboard staff take over chats from the bot and reply
+// through the same channel the customer is using.
+type AgentInbox struct {
+	conversations *ConversationManager
+	router        *ChannelRouter
+	logger        *ConversationLogger
+}
+
+// NewAgentInbox creates the live inbox
+func NewAgentInbox(conversations *ConversationManager, router *ChannelRouter, logger *ConversationLogger) *AgentInbox {
+	return &AgentInbox{
+		conversations: conversations,
+		router:        router,
+		logger:        logger,
+	}
+}
+
+// List returns the tenant's queued and taken-over chats, longest waiting first
+func (a *AgentInbox) List(schema string) ([]InboxChat, error) {
+	states, err := a.conversations.List(schema, StateHandoffPending, StateHumanAgent)
+	if err != nil {
+		return nil, err
+	}
+	chats := make([]InboxChat, 0, len(states))
+	for _, st := range states {
+		agentID, _ := strconv.Atoi(st.Data["agent_id"])
+		chats = append(chats, InboxChat{
+			Platform:    st.Platform,
+			ChatID:      st.ChatID,
+			State:       st.State,
+			AgentID:     agentID,
+			RequestedAt: st.Data["requested_at"],
+			UpdatedAt:   st.UpdatedAt,
+			ExpiresAt:   st.ExpiresAt,
+		})
+	}
+	return chats, nil
+}
+
+// TakeOver assigns a chat to a staff member; the bot stops replying until
+// the chat is released or idles out
+func (a *AgentInbox) TakeOver(schema, platform, chatID string, agentID int) error {
+	key := ConversationKey{Schema: schema, Platform: platform, ChatID: chatID}
+	state := a.conversations.Get(key)
+	if state.State == StateHumanAgent && state.Data["agent_id"] != strconv.Itoa(agentID) {
+		return ErrChatTakenByOther
+	}
+
+	ownerID, ok := state.Data["user_id"]
+	if !ok {
+		// Chat was not queued: find the bot that received it from the transcript
+		last, err := a.logger.LastInbound(schema, platform, chatID)
+		if err != nil {
+			return err
+		}
+		if last == nil {
+			return ErrChatNotFound
+		}
+		ownerID = strconv.Itoa(last.UserID)
+	}
+
+	err := a.conversations.Transition(key, StateHumanAgent, map[string]string{
+		"user_id":      ownerID,
+		"agent_id":     strconv.Itoa(agentID),
+		"requested_at": state.Data["requested_at"],
+	})
+	if err == nil {
+		publishInboxUpdate(a.router, key, StateHumanAgent)
+	}
+	return err
+}
+
+// Reply sends a staff message to a taken-over chat and extends its idle timeout
+func (a *AgentInbox) Reply(schema, platform, chatID string, agentID int, text string) error {
+	key := ConversationKey{Schema: schema, Platform: platform, ChatID: chatID}
+	state, err := a.agentState(key, agentID)
+	if err != nil {
+		return err
+	}
+	if err := a.router.Send(a.chatMessage(key, state), text); err != nil {
+		return err
+	}
+	return a.conversations.Transition(key, StateHumanAgent, state.Data)
+}
+
+// Release hands the chat back to the bot and tells the customer
+func (a *AgentInbox) Release(schema, platform, chatID string, agentID int) error {
+	key := ConversationKey{Schema: schema, Platform: platform, ChatID: chatID}
+	state := a.conversations.Get(key)
+	if state.State != StateHandoffPending && state.State != StateHumanAgent {
+		return ErrChatNotTakenOver
+	}
+	if state.State == StateHumanAgent && state.Data["agent_id"] != strconv.Itoa(agentID) {
+		return ErrChatTakenByOther
+	}
+
+	a.conversations.Reset(key)
+	publishInboxUpdate(a.router, key, StateIdle)
+	return a.router.Send(a.chatMessage(key, state), handoffReleasedText)
+}
+
+// agentState returns the chat's state if agentID currently owns it
+func (a *AgentInbox) agentState(key ConversationKey, agentID int) (*repository.ConversationState, error) {
+	state := a.conversations.Get(key)
+	if state.State != StateHumanAgent {
+		return nil, ErrChatNotTakenOver
+	}
+	if state.Data["agent_id"] != strconv.Itoa(agentID) {
+		return nil, ErrChatTakenByOther
+	}
+	return state, nil
+}
+
+// chatMessage addresses an outbound message to the bot that owns the chat
+func (a *AgentInbox) chatMessage(key ConversationKey, state *repository.ConversationState) entities.Message {
+	ownerID, _ := strconv.Atoi(state.Data["user_id"])
+	return entities.Message{
+		From:       key.ChatID,
+		Platform:   key.Platform,
+		SchemaName: key.Schema,
+		UserID:     ownerID,
+		MenuAction: repository.MenuActionHandoff,
+	}
+}
+
+// publishInboxUpdate tells dashboards that a chat entered or left the inbox
+func publishInboxUpdate(router *ChannelRouter, key ConversationKey, state string) {
+	if router == nil {
+		return
+	}
+	router.Events.Publish(infrastructure.Event{
+		Type:       infrastructure.EventInboxUpdated,
+		SchemaName: key.Schema,
+		Data: map[string]any{
+			"platform": key.Platform,
+			"chat_id":  key.ChatID,
+			"state":    state,
+		},
+	})
+}
@@ -0,0 +1,337 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"project_masAde/internal/entities"
+	"project_masAde/internal/infrastructure"
+	"project_masAde/internal/repository"
+	"strings"
+	"time"
+)
+
+// Errors returned by CampaignService
+var (
+	ErrInvalidCampaign  = errors.New("invalid campaign")
+	ErrCampaignNotFound = errors.New("campaign not found")
+	ErrCampaignState    = errors.New("campaign cannot change to that status")
+)
+
+const (
+	campaignPollInterval = 10 * time.Second
+	campaignBatchSize    = 20
+	campaignMenuAction   = "campaign" // Recorded on outbound conversation logs
+)
+
+// handleSubscriptionCommand lets customers opt out of (or back into) broadcasts
+func (s *MessageService) handleSubscriptionCommand(msg entities.Message, content string) (bool, error) {
+	var optOut bool
+	switch content {
+	case "stop", "berhenti", "unsubscribe":
+		optOut = true
+	case "start promo", "langganan", "subscribe":
+		optOut = false
+	default:
+		return false, nil
+	}
+	if s.Contacts == nil {
+		return false, nil
+	}
+	if err := s.Contacts.SetOptOut(msg.SchemaName, msg.Platform, msg.From, optOut); err != nil {
+		return true, err
+	}
+	if optOut {
+		return true, s.sendReply(msg, "🔕 Anda tidak akan menerima pesan promosi lagi.\nKetik *LANGGANAN* untuk berlangganan kembali.")
+	}
+	return true, s.sendReply(msg, "🔔 Anda kembali berlangganan pesan promosi.\nKetik *STOP* untuk berhenti.")
+}
+
+// CampaignService manages broadcast campaigns and runs the delivery worker.
+// Delivery goes through ChannelRouter, so sends are metered, logged and
+// routed to the tenant's own WhatsApp client or Telegram bot.
+type CampaignService struct {
+	repo        *repository.CampaignRepository
+	contacts    *repository.ContactRepository
+	router      *ChannelRouter
+	userRepo    *repository.UserRepository
+	usageRepo   *repository.UsageRepository
+	rateLimiter *infrastructure.MessageRateLimiter
+}
+
+// NewCampaignService creates the campaign service; call Start to run the worker
+func NewCampaignService(repo *repository.CampaignRepository, contacts *repository.ContactRepository, router *ChannelRouter, userRepo *repository.UserRepository, usageRepo *repository.UsageRepository, rateLimiter *infrastructure.MessageRateLimiter) *CampaignService {
+	return &CampaignService{
+		repo:        repo,
+		contacts:    contacts,
+		router:      router,
+		userRepo:    userRepo,
+		usageRepo:   usageRepo,
+		rateLimiter: rateLimiter,
+	}
+}
+
+// Create schedules a campaign. A zero scheduledAt sends as soon as the worker picks it up.
+func (s *CampaignService) Create(schema string, createdBy int, name, platform string, tags []string, template string, scheduledAt time.Time) (*repository.Campaign, error) {
+	name = strings.TrimSpace(name)
+	template = strings.TrimSpace(template)
+	if name == "" || template == "" {
+		return nil, fmt.Errorf("%w: name and template are required", ErrInvalidCampaign)
+	}
+	if platform != "whatsapp" && platform != "telegram" {
+		return nil, fmt.Errorf("%w: platform must be whatsapp or telegram", ErrInvalidCampaign)
+	}
+	if scheduledAt.IsZero() {
+		scheduledAt = time.Now()
+	}
+
+	cleanTags := []string{}
+	for _, t := range tags {
+		if t = normalizeTag(t); t != "" {
+			cleanTags = append(cleanTags, t)
+		}
+	}
+
+	c := &repository.Campaign{
+		SchemaName:   schema,
+		CreatedBy:    createdBy,
+		Name:         name,
+		Platform:     platform,
+		AudienceTags: cleanTags,
+		Template:     template,
+		Status:       repository.CampaignScheduled,
+		ScheduledAt:  scheduledAt,
+	}
+	if err := s.repo.Create(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// List returns the tenant's campaigns with delivery stats
+func (s *CampaignService) List(schema string) ([]repository.Campaign, error) {
+	return s.repo.List(schema)
+}
+
+// Get returns one of the tenant's campaigns
+func (s *CampaignService) Get(schema string, id int) (*repository.Campaign, error) {
+	c, err := s.repo.Get(schema, id)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, ErrCampaignNotFound
+	}
+	return c, nil
+}
+
+// Recipients returns a page of per-recipient delivery status
+func (s *CampaignService) Recipients(schema string, id int, status string, limit, offset int) ([]repository.CampaignRecipient, error) {
+	if _, err := s.Get(schema, id); err != nil {
+		return nil, err
+	}
+	return s.repo.ListRecipients(id, status, clampLogPageSize(limit), max(offset, 0))
+}
+
+// Pause stops delivery after the message currently being sent
+func (s *CampaignService) Pause(schema string, id int) error {
+	return s.changeStatus(schema, id, []string{repository.CampaignScheduled, repository.CampaignRunning}, repository.CampaignPaused, "paused by staff")
+}
+
+// Resume continues a paused campaign. One paused before it started goes back to
+// scheduled so the worker still builds its audience.
+func (s *CampaignService) Resume(schema string, id int) error {
+	c, err := s.Get(schema, id)
+	if err != nil {
+		return err
+	}
+	to := repository.CampaignRunning
+	if c.StartedAt == nil {
+		to = repository.CampaignScheduled
+	}
+	return s.changeStatus(schema, id, []string{repository.CampaignPaused}, to, "")
+}
+
+// Cancel stops a campaign for good; unsent recipients stay pending
+func (s *CampaignService) Cancel(schema string, id int) error {
+	return s.changeStatus(schema, id, []string{repository.CampaignScheduled, repository.CampaignRunning, repository.CampaignPaused}, repository.CampaignCancelled, "cancelled by staff")
+}
+
+func (s *CampaignService) changeStatus(schema string, id int, from []string, to, reason string) error {
+	c, err := s.Get(schema, id)
+	if err != nil {
+		return err
+	}
+	ok, err := s.repo.SetStatus(id, from, to, reason)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: %s -> %s", ErrCampaignState, c.Status, to)
+	}
+	s.publish(c, to, reason)
+	return nil
+}
+
+// Start runs the delivery worker in the background
+func (s *CampaignService) Start() {
+	go func() {
+		ticker := time.NewTicker(campaignPollInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			s.processActive()
+		}
+	}()
+}
+
+// processActive delivers every due campaign, one at a time
+func (s *CampaignService) processActive() {
+	campaigns, err := s.repo.Active()
+	if err != nil {
+		fmt.Printf("Warning: failed to load campaigns: %v\n", err)
+		return
+	}
+	for i := range campaigns {
+		if err := s.process(&campaigns[i]); err != nil {
+			fmt.Printf("Warning: campaign %d: %v\n", campaigns[i].ID, err)
+		}
+	}
+}
+
+// process builds the audience of a newly due campaign and sends its pending recipients
+func (s *CampaignService) process(c *repository.Campaign) error {
+	if c.Status == repository.CampaignScheduled {
+		added, err := s.repo.BuildAudience(c)
+		if err != nil {
+			return fmt.Errorf("build audience: %w", err)
+		}
+		ok, err := s.repo.SetStatus(c.ID, []string{repository.CampaignScheduled}, repository.CampaignRunning, "")
+		if err != nil || !ok {
+			return err
+		}
+		fmt.Printf("[Campaign] %d '%s' started with %d recipients\n", c.ID, c.Name, added)
+		s.publish(c, repository.CampaignRunning, "")
+	}
+
+	for {
+		batch, err := s.repo.PendingRecipients(c.ID, campaignBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			if ok, err := s.repo.SetStatus(c.ID, []string{repository.CampaignRunning}, repository.CampaignCompleted, ""); err == nil && ok {
+				fmt.Printf("[Campaign] %d '%s' completed\n", c.ID, c.Name)
+				s.publish(c, repository.CampaignCompleted, "")
+			}
+			return nil
+		}
+
+		for _, rc := range batch {
+			// Staff may pause or cancel mid-run
+			status, err := s.repo.Status(c.ID)
+			if err != nil {
+				return err
+			}
+			if status != repository.CampaignRunning {
+				return nil
+			}
+			if stop, err := s.deliver(c, rc); stop || err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// deliver sends the campaign to one recipient. Returns stop=true when the
+// campaign had to be paused (quota exhausted).
+func (s *CampaignService) deliver(c *repository.Campaign, rc repository.CampaignRecipient) (bool, error) {
+	contact, err := s.contacts.Find(c.SchemaName, rc.Platform, rc.ChatID)
+	if err != nil {
+		return false, err
+	}
+	if contact != nil && contact.OptedOut {
+		return false, s.repo.MarkRecipient(rc.ID, repository.RecipientSkipped, "opted out")
+	}
+
+	// Tenant bots are metered; the platform bot (UserID 0) is not
+	if rc.UserID != 0 {
+		if ok, reason := s.quotaAllows(rc.UserID); !ok {
+			if _, err := s.repo.SetStatus(c.ID, []string{repository.CampaignRunning}, repository.CampaignPaused, reason); err != nil {
+				return true, err
+			}
+			fmt.Printf("[Campaign] %d paused: %s\n", c.ID, reason)
+			s.publish(c, repository.CampaignPaused, reason)
+			return true, nil
+		}
+		s.waitForRateLimit(rc.UserID)
+	}
+
+	msg := entities.Message{
+		From:       rc.ChatID,
+		Platform:   rc.Platform,
+		SchemaName: c.SchemaName,
+		UserID:     rc.UserID,
+		MenuAction: campaignMenuAction,
+	}
+	if err := s.router.Send(msg, renderCampaignTemplate(c.Template, contact)); err != nil {
+		return false, s.repo.MarkRecipient(rc.ID, repository.RecipientFailed, err.Error())
+	}
+	return false, s.repo.MarkRecipient(rc.ID, repository.RecipientSent, "")
+}
+
+// renderCampaignTemplate fills {name} with the contact's display name
+func renderCampaignTemplate(template string, contact *repository.Contact) string {
+	name := "Kak"
+	if contact != nil && contact.DisplayName != "" {
+		name = contact.DisplayName
+	}
+	return strings.ReplaceAll(template, "{name}", name)
+}
+
+// quotaAllows checks the bot owner's daily and monthly limits
+func (s *CampaignService) quotaAllows(userID int) (bool, string) {
+	if s.userRepo == nil || s.usageRepo == nil {
+		return true, ""
+	}
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil || user == nil {
+		return false, fmt.Sprintf("owner %d not found", userID)
+	}
+	if !user.IsActive {
+		return false, "account inactive"
+	}
+	status, err := s.usageRepo.GetQuotaStatus(userID, user.DailyLimit, user.MonthlyLimit)
+	if err != nil {
+		return false, err.Error()
+	}
+	return status.CanSend()
+}
+
+// waitForRateLimit blocks until the bot owner's rate limiter allows another send
+func (s *CampaignService) waitForRateLimit(userID int) {
+	if s.rateLimiter == nil {
+		return
+	}
+	for !s.rateLimiter.Allow(userID) {
+		wait := s.rateLimiter.WaitTime(userID)
+		if wait <= 0 {
+			wait = 100 * time.Millisecond
+		}
+		time.Sleep(wait)
+	}
+}
+
+func (s *CampaignService) publish(c *repository.Campaign, status, reason string) {
+	if s.router == nil {
+		return
+	}
+	s.router.Events.Publish(infrastructure.Event{
+		Type:       infrastructure.EventCampaignUpdated,
+		SchemaName: c.SchemaName,
+		Data: map[string]any{
+			"campaign_id": c.ID,
+			"name":        c.Name,
+			"status":      status,
+			"reason":      reason,
+		},
+	})
+}
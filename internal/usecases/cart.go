@@ -0,0 +1,245 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"project_masAde/internal/entities"
+	"project_masAde/internal/infrastructure"
+	"project_masAde/internal/repository"
+	"strconv"
+	"strings"
+)
+
+// ErrCartItemNotFound is returned when removing a position the cart does not have
+var ErrCartItemNotFound = errors.New("cart item not found")
+
+// CartService keeps a cart of products per chat. Carts live in the database,
+// so WhatsApp and Telegram chats share the same code path and survive restarts.
+type CartService struct {
+	repo       *repository.CartRepository
+	calculator *DynamicCalculator
+}
+
+// NewCartService creates the cart service
+func NewCartService(repo *repository.CartRepository, calculator *DynamicCalculator) *CartService {
+	return &CartService{repo: repo, calculator: calculator}
+}
+
+// Add puts parsed items of a dataset in the chat's cart. Every product is
+// looked up first so the cart only holds items that can be priced; the
+// second result explains the first one that cannot.
+func (s *CartService) Add(key ConversationKey, tableName string, queries []DynamicQuery) ([]repository.CartItem, string) {
+	full := fmt.Sprintf("❌ Keranjang maksimal berisi %d produk.", maxQuoteItems)
+	if len(queries) > maxQuoteItems {
+		return nil, full
+	}
+
+	added := make([]repository.CartItem, 0, len(queries))
+	schemas := tableSchemas{}
+	for _, query := range queries {
+		item, _, failure := s.calculator.lookupItem(key.Schema, tableName, query, schemas)
+		if failure != "" {
+			return nil, failure
+		}
+		added = append(added, repository.CartItem{
+			Platform:    key.Platform,
+			ChatID:      key.ChatID,
+			TableName:   item.TableName,
+			Product:     item.Product,
+			Quantity:    query.Quantity,
+			Unit:        query.Unit,
+			WeightGrams: query.WeightGrams,
+		})
+	}
+	// The limit is checked with the inserts, so concurrent adds cannot pass it
+	err := s.repo.Add(key.Schema, key.Platform, key.ChatID, added, maxQuoteItems)
+	if errors.Is(err, repository.ErrCartFull) {
+		return nil, full
+	}
+	if err != nil {
+		return nil, fmt.Sprintf("❌ Error menyimpan keranjang: %s", err.Error())
+	}
+	return added, ""
+}
+
+// Items returns the chat's cart in the order items were added
+func (s *CartService) Items(key ConversationKey) ([]repository.CartItem, error) {
+	return s.repo.List(key.Schema, key.Platform, key.ChatID)
+}
+
+// Remove deletes the item at a 1-based position of the cart
+func (s *CartService) Remove(key ConversationKey, position int) (*repository.CartItem, error) {
+	items, err := s.Items(key)
+	if err != nil {
+		return nil, err
+	}
+	if position < 1 || position > len(items) {
+		return nil, ErrCartItemNotFound
+	}
+	item := items[position-1]
+	ok, err := s.repo.Remove(key.Schema, key.Platform, key.ChatID, item.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrCartItemNotFound
+	}
+	return &item, nil
+}
+
+// Clear empties the chat's cart
+func (s *CartService) Clear(key ConversationKey) error {
+	return s.repo.Clear(key.Schema, key.Platform, key.ChatID)
+}
+
+// Quote prices the whole cart as one order with the current prices and
+// pricing rules. Returns a nil quote for an empty cart.
+func (s *CartService) Quote(key ConversationKey) (*Quote, []repository.CartItem, string) {
+	items, err := s.Items(key)
+	if err != nil {
+		return nil, nil, fmt.Sprintf("❌ Error mengambil keranjang: %s", err.Error())
+	}
+	if len(items) == 0 {
+		return nil, items, ""
+	}
+	requests := make([]QuoteRequest, len(items))
+	for i, item := range items {
+		requests[i] = QuoteRequest{TableName: item.TableName, Query: cartQuery(item)}
+	}
+	quote, failure := s.calculator.QuoteRequests(key.Schema, requests)
+	return quote, items, failure
+}
+
+// cartQuery turns a stored cart item back into a calculator query
+func cartQuery(item repository.CartItem) DynamicQuery {
+	return DynamicQuery{
+		Quantity:    item.Quantity,
+		Unit:        item.Unit,
+		ProductName: item.Product,
+		WeightGrams: item.WeightGrams,
+	}
+}
+
+// cartItemText renders a cart item as it was entered ("30 Tumbler 5kg",
+// "2 lusin Gelas")
+func cartItemText(item repository.CartItem) string {
+	text := fmt.Sprintf("%s %s", formatQuantity(item.Quantity), item.Product)
+	if item.Unit != "" {
+		text = fmt.Sprintf("%s %s %s", formatQuantity(item.Quantity), item.Unit, item.Product)
+	}
+	switch {
+	case item.WeightGrams >= 1000:
+		text += fmt.Sprintf(" %skg", formatQuantity(item.WeightGrams/1000))
+	case item.WeightGrams > 0:
+		text += fmt.Sprintf(" %sg", formatQuantity(item.WeightGrams))
+	}
+	return text
+}
+
+// cartTable is the dataset new cart items are looked up in: the one of the
+// chat's current or last calculation, else the default dataset
+func (s *MessageService) cartTable(key ConversationKey) string {
+	if s.Conversations != nil {
+		state := s.Conversations.Get(key)
+		if (state.State == StateAwaitingCalcInput || state.State == StateCalcCompleted) && state.Data["table"] != "" {
+			return state.Data["table"]
+		}
+	}
+	return defaultCalcTable
+}
+
+// handleCartAdd adds "qty product [weight]" items to the cart. Without items
+// it adds the chat's last calculation.
+func (s *MessageService) handleCartAdd(msg entities.Message, input string) error {
+	if s.Cart == nil {
+		return s.sendReply(msg, "Fitur keranjang tidak tersedia.")
+	}
+	key := conversationKeyFor(msg)
+	tableName := s.cartTable(key)
+	if input == "" && s.Conversations != nil {
+		if state := s.Conversations.Get(key); state.State == StateCalcCompleted {
+			input = state.Data["last_input"]
+		}
+	}
+	if input == "" {
+		return s.sendReply(msg, "🛒 Ketik *TAMBAH [jumlah] [produk]* untuk menambah ke keranjang.\nContoh: `TAMBAH 30 tumbler, 50 gelas 5kg`")
+	}
+
+	queries, failure := s.Calculator.ParseItems(input)
+	if failure != "" {
+		return s.sendReply(msg, "❌ "+failure)
+	}
+	added, failure := s.Cart.Add(key, tableName, queries)
+	if failure != "" {
+		return s.sendReply(msg, failure)
+	}
+
+	names := make([]string, len(added))
+	for i, item := range added {
+		names[i] = cartItemText(item)
+	}
+	return s.sendCart(msg, fmt.Sprintf("🛒 Ditambahkan: *%s*\n\n", strings.Join(names, ", ")))
+}
+
+// handleCartRemove removes the item at the position given after HAPUS
+func (s *MessageService) handleCartRemove(msg entities.Message, arg string) error {
+	if s.Cart == nil {
+		return s.sendReply(msg, "Fitur keranjang tidak tersedia.")
+	}
+	position, err := strconv.Atoi(strings.TrimSpace(arg))
+	if err != nil {
+		return s.sendReply(msg, "🛒 Ketik *HAPUS [nomor]* untuk menghapus produk dari keranjang. Ketik *KERANJANG* untuk melihat nomornya.")
+	}
+	item, err := s.Cart.Remove(conversationKeyFor(msg), position)
+	if errors.Is(err, ErrCartItemNotFound) {
+		return s.sendReply(msg, fmt.Sprintf("❌ Produk nomor %d tidak ada di keranjang.", position))
+	}
+	if err != nil {
+		fmt.Printf("Warning: failed to remove cart item: %v\n", err)
+		return s.sendReply(msg, "❌ Gagal menghapus produk dari keranjang.")
+	}
+	return s.sendCart(msg, fmt.Sprintf("🗑️ Dihapus: *%s*\n\n", cartItemText(*item)))
+}
+
+// handleCartClear empties the chat's cart
+func (s *MessageService) handleCartClear(msg entities.Message) error {
+	if s.Cart == nil {
+		return s.sendReply(msg, "Fitur keranjang tidak tersedia.")
+	}
+	if err := s.Cart.Clear(conversationKeyFor(msg)); err != nil {
+		fmt.Printf("Warning: failed to clear cart: %v\n", err)
+		return s.sendReply(msg, "❌ Gagal mengosongkan keranjang.")
+	}
+	return s.sendReply(msg, "🗑️ Keranjang dikosongkan.")
+}
+
+// sendCart replies with the cart priced as one quote
+func (s *MessageService) sendCart(msg entities.Message, header string) error {
+	if s.Cart == nil {
+		return s.sendReply(msg, "Fitur keranjang tidak tersedia.")
+	}
+	quote, items, failure := s.Cart.Quote(conversationKeyFor(msg))
+	if len(items) == 0 && failure == "" {
+		return s.sendReply(msg, header+"🛒 Keranjang kosong.\nKetik *TAMBAH [jumlah] [produk]* untuk menambah, contoh: `TAMBAH 30 tumbler`")
+	}
+
+	var sb strings.Builder
+	sb.WriteString(header)
+	if quote != nil {
+		sb.WriteString(formatQuoteTitled("🛒 *Keranjang*", quote))
+	} else {
+		// Cannot be priced right now (product gone, below the minimum order, ...):
+		// still show what is in it
+		sb.WriteString("🛒 *Keranjang*\n\n")
+		for i, item := range items {
+			sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, cartItemText(item)))
+		}
+		sb.WriteString("\n" + failure)
+	}
+	sb.WriteString("\n\nKetik *KONFIRMASI ORDER* untuk memesan, *PDF* untuk penawaran resmi, *HAPUS [nomor]* untuk menghapus atau *KOSONGKAN KERANJANG* untuk mengosongkan.")
+
+	if msg.Platform == "whatsapp" {
+		return s.sendReply(msg, sb.String())
+	}
+	return s.sendReplyWithKeyboard(msg, sb.String(), infrastructure.CreateCartMenu())
+}
@@ -0,0 +1,196 @@
+package usecases
+
+import (
+	"fmt"
+	"project_masAde/internal/entities"
+	"project_masAde/internal/infrastructure"
+	"project_masAde/internal/interfaces"
+	"project_masAde/internal/repository"
+	"strconv"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ChannelRouter delivers outbound messages through the client that owns the chat:
+// the tenant's WhatsApp client, the tenant's Telegram bot, or the platform bot.
+// Every successful send is counted against the tenant's usage and logged.
+type ChannelRouter struct {
+	WAManager      *infrastructure.WhatsAppManager
+	TGManager      *infrastructure.TelegramBotManager
+	TelegramClient *infrastructure.TelegramClient // Platform bot (UserID 0)
+	Messenger      interfaces.Messenger           // Fallback for other platforms
+	UsageRepo      *repository.UsageRepository
+	Logger         *ConversationLogger      // Optional transcript logging
+	Events         *infrastructure.EventHub // Optional real-time dashboard events
+}
+
+// NewChannelRouter creates a router over the per-user client managers
+func NewChannelRouter(waManager *infrastructure.WhatsAppManager, tgManager *infrastructure.TelegramBotManager, usageRepo *repository.UsageRepository) *ChannelRouter {
+	return &ChannelRouter{
+		WAManager: waManager,
+		TGManager: tgManager,
+		UsageRepo: usageRepo,
+	}
+}
+
+// Send sends a plain text reply to the chat the message came from
+func (r *ChannelRouter) Send(msg entities.Message, text string) error {
+	err := r.deliver(msg, text, nil)
+	if err == nil {
+		r.countSent(msg)
+	}
+	return err
+}
+
+// SendWithKeyboard sends a reply with an inline keyboard.
+// Platforms without inline keyboards receive the text only.
+func (r *ChannelRouter) SendWithKeyboard(msg entities.Message, text string, keyboard tgbotapi.InlineKeyboardMarkup) error {
+	err := r.deliver(msg, text, &keyboard)
+	if err == nil {
+		r.countSent(msg)
+	}
+	return err
+}
+
+// SendUnmetered sends a system notice (e.g. quota exhausted) that is not counted as usage
+func (r *ChannelRouter) SendUnmetered(msg entities.Message, text string) error {
+	return r.deliver(msg, text, nil)
+}
+
+// SendDocument sends a file (e.g. a PDF quotation) with a caption.
+// Only WhatsApp and Telegram chats can receive documents.
+func (r *ChannelRouter) SendDocument(msg entities.Message, filename, mimetype string, data []byte, caption string) error {
+	var err error
+	switch msg.Platform {
+	case "whatsapp":
+		err = r.sendWhatsAppDocument(msg, filename, mimetype, data, caption)
+	case "telegram":
+		err = r.sendTelegramDocument(msg, filename, data, caption)
+	default:
+		return fmt.Errorf("documents are not supported on platform %q", msg.Platform)
+	}
+	if err != nil {
+		return err
+	}
+	r.countSent(msg)
+	text := fmt.Sprintf("[%s] %s", filename, caption)
+	r.Logger.RecordOutbound(msg, text)
+	r.Events.Publish(infrastructure.Event{
+		Type:       infrastructure.EventMessageSent,
+		SchemaName: msg.SchemaName,
+		UserID:     msg.UserID,
+		Data: map[string]any{
+			"platform":    msg.Platform,
+			"chat_id":     msg.From,
+			"content":     text,
+			"document":    filename,
+			"menu_action": msg.MenuAction,
+		},
+	})
+	return nil
+}
+
+func (r *ChannelRouter) deliver(msg entities.Message, text string, keyboard *tgbotapi.InlineKeyboardMarkup) error {
+	var err error
+	switch msg.Platform {
+	case "whatsapp":
+		err = r.sendWhatsApp(msg, text)
+	case "telegram":
+		err = r.sendTelegram(msg, text, keyboard)
+	default:
+		if r.Messenger == nil {
+			return fmt.Errorf("no messaging client available for platform %q", msg.Platform)
+		}
+		err = r.Messenger.SendMessage(msg.From, text)
+	}
+	if err == nil {
+		r.Logger.RecordOutbound(msg, text)
+		r.Events.Publish(infrastructure.Event{
+			Type:       infrastructure.EventMessageSent,
+			SchemaName: msg.SchemaName,
+			UserID:     msg.UserID,
+			Data: map[string]any{
+				"platform":    msg.Platform,
+				"chat_id":     msg.From,
+				"content":     text,
+				"menu_action": msg.MenuAction,
+			},
+		})
+	}
+	return err
+}
+
+func (r *ChannelRouter) sendWhatsApp(msg entities.Message, text string) error {
+	if r.WAManager == nil {
+		return fmt.Errorf("whatsapp not configured")
+	}
+	client := r.WAManager.GetClient(msg.UserID)
+	if client == nil {
+		return fmt.Errorf("whatsapp client not connected for user %d", msg.UserID)
+	}
+	return client.SendMessage(msg.From, text)
+}
+
+func (r *ChannelRouter) sendTelegram(msg entities.Message, text string, keyboard *tgbotapi.InlineKeyboardMarkup) error {
+	chatID, err := strconv.ParseInt(msg.From, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid telegram chat id %q", msg.From)
+	}
+
+	// Tenant bots are managed per user; UserID 0 is the platform bot
+	if msg.UserID != 0 {
+		if r.TGManager == nil {
+			return fmt.Errorf("telegram bots not configured")
+		}
+		if keyboard != nil {
+			return r.TGManager.SendMessageWithMenu(msg.UserID, chatID, text, *keyboard)
+		}
+		return r.TGManager.SendMessage(msg.UserID, chatID, text)
+	}
+
+	if r.TelegramClient == nil || r.TelegramClient.Bot == nil {
+		return fmt.Errorf("telegram bot not configured")
+	}
+	if keyboard != nil {
+		return r.TelegramClient.SendMessageWithMenu(msg.From, text, *keyboard)
+	}
+	return r.TelegramClient.SendMessage(msg.From, text)
+}
+
+func (r *ChannelRouter) sendWhatsAppDocument(msg entities.Message, filename, mimetype string, data []byte, caption string) error {
+	if r.WAManager == nil {
+		return fmt.Errorf("whatsapp not configured")
+	}
+	client := r.WAManager.GetClient(msg.UserID)
+	if client == nil {
+		return fmt.Errorf("whatsapp client not connected for user %d", msg.UserID)
+	}
+	return client.SendDocument(msg.From, filename, mimetype, data, caption)
+}
+
+func (r *ChannelRouter) sendTelegramDocument(msg entities.Message, filename string, data []byte, caption string) error {
+	chatID, err := strconv.ParseInt(msg.From, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid telegram chat id %q", msg.From)
+	}
+	if msg.UserID != 0 {
+		if r.TGManager == nil {
+			return fmt.Errorf("telegram bots not configured")
+		}
+		return r.TGManager.SendDocument(msg.UserID, chatID, filename, data, caption)
+	}
+	if r.TelegramClient == nil || r.TelegramClient.Bot == nil {
+		return fmt.Errorf("telegram bot not configured")
+	}
+	return r.TelegramClient.SendDocument(msg.From, filename, data, caption)
+}
+
+// countSent records an outbound message against the tenant's quota
+func (r *ChannelRouter) countSent(msg entities.Message) {
+	if r.UsageRepo == nil || msg.UserID == 0 {
+		return
+	}
+	if err := r.UsageRepo.IncrementSent(msg.UserID); err != nil {
+		fmt.Printf("Warning: failed to count sent message for user %d: %v\n", msg.UserID, err)
+	}
+}
@@ -0,0 +1,166 @@
+package usecases
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"project_masAde/internal/repository"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Errors returned by ContactService
+var (
+	ErrInvalidContact  = errors.New("invalid contact")
+	ErrContactNotFound = errors.New("contact not found")
+	ErrContactExists   = errors.New("contact already exists")
+)
+
+// maxContactTags caps how many tags one contact may carry
+const maxContactTags = 20
+
+// ContactService manages a tenant's registry of end customers
+type ContactService struct {
+	repo *repository.ContactRepository
+}
+
+// NewContactService creates a contact service
+func NewContactService(repo *repository.ContactRepository) *ContactService {
+	return &ContactService{repo: repo}
+}
+
+// List returns a page of contacts with the total matching count
+func (s *ContactService) List(schema string, f repository.ContactFilter) ([]repository.Contact, int, error) {
+	f.Limit = clampLogPageSize(f.Limit)
+	f.Offset = max(f.Offset, 0)
+	f.Tag = normalizeTag(f.Tag)
+	contacts, err := s.repo.List(schema, f)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := s.repo.Count(schema, f)
+	return contacts, total, err
+}
+
+// Get returns one contact
+func (s *ContactService) Get(schema string, id int) (*repository.Contact, error) {
+	c, err := s.repo.Get(schema, id)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, ErrContactNotFound
+	}
+	return c, nil
+}
+
+// Create adds a contact manually
+func (s *ContactService) Create(schema string, c *repository.Contact) error {
+	if c.Platform != "whatsapp" && c.Platform != "telegram" {
+		return fmt.Errorf("%w: platform must be whatsapp or telegram", ErrInvalidContact)
+	}
+	c.ChatID = strings.TrimSpace(c.ChatID)
+	if c.ChatID == "" {
+		return fmt.Errorf("%w: chat_id is required", ErrInvalidContact)
+	}
+	if err := s.normalize(c); err != nil {
+		return err
+	}
+	err := s.repo.Create(schema, c)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrContactExists
+	}
+	return err
+}
+
+// Update saves name, language, tags and opt-out of an existing contact
+func (s *ContactService) Update(schema string, c *repository.Contact) error {
+	if err := s.normalize(c); err != nil {
+		return err
+	}
+	ok, err := s.repo.Update(schema, c)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrContactNotFound
+	}
+	return nil
+}
+
+// Delete removes a contact
+func (s *ContactService) Delete(schema string, id int) error {
+	ok, err := s.repo.Delete(schema, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrContactNotFound
+	}
+	return nil
+}
+
+// ExportCSV writes every contact matching the filter as CSV
+func (s *ContactService) ExportCSV(schema string, f repository.ContactFilter, w io.Writer) error {
+	f.Limit, f.Offset = 0, 0
+	f.Tag = normalizeTag(f.Tag)
+	contacts, err := s.repo.List(schema, f)
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "platform", "chat_id", "display_name", "language", "tags", "opted_out", "first_seen_at", "last_seen_at"})
+	for _, c := range contacts {
+		cw.Write([]string{
+			strconv.Itoa(c.ID),
+			c.Platform,
+			c.ChatID,
+			c.DisplayName,
+			c.Language,
+			strings.Join(c.Tags, ";"),
+			strconv.FormatBool(c.OptedOut),
+			c.FirstSeenAt.Format(time.RFC3339),
+			c.LastSeenAt.Format(time.RFC3339),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// normalize trims fields and lower-cases/dedupes tags
+func (s *ContactService) normalize(c *repository.Contact) error {
+	c.DisplayName = strings.TrimSpace(c.DisplayName)
+	c.Language = strings.ToLower(strings.TrimSpace(c.Language))
+	if len(c.DisplayName) > 256 || len(c.Language) > 16 {
+		return fmt.Errorf("%w: display_name or language too long", ErrInvalidContact)
+	}
+
+	seen := map[string]bool{}
+	tags := []string{}
+	for _, t := range c.Tags {
+		t = normalizeTag(t)
+		if t == "" || seen[t] {
+			continue
+		}
+		if len(t) > 64 {
+			return fmt.Errorf("%w: tag '%s' is too long", ErrInvalidContact, t)
+		}
+		seen[t] = true
+		tags = append(tags, t)
+	}
+	if len(tags) > maxContactTags {
+		return fmt.Errorf("%w: at most %d tags", ErrInvalidContact, maxContactTags)
+	}
+	c.Tags = tags
+	return nil
+}
+
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
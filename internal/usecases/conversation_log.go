@@ -0,0 +1,94 @@
+package usecases
+
+import (
+	"fmt"
+	"project_masAde/internal/entities"
+	"project_masAde/internal/repository"
+	"time"
+)
+
+const (
+	defaultLogPageSize = 50
+	maxLogPageSize     = 200
+)
+
+// ConversationLogger records every inbound and outbound chat message and
+// serves the tenant-scoped transcript queries used by support staff.
+type ConversationLogger struct {
+	repo *repository.ConversationLogRepository
+}
+
+// NewConversationLogger creates a logger backed by conversation_logs
+func NewConversationLogger(repo *repository.ConversationLogRepository) *ConversationLogger {
+	return &ConversationLogger{repo: repo}
+}
+
+// RecordInbound logs a message received from a customer
+func (l *ConversationLogger) RecordInbound(msg entities.Message) {
+	l.record(msg, repository.DirectionIncoming, msg.Content, nil)
+}
+
+// RecordOutbound logs a reply sent to the chat msg came from.
+// Latency is measured from when msg entered the inbound pipeline.
+func (l *ConversationLogger) RecordOutbound(msg entities.Message, text string) {
+	var latency *int
+	if !msg.ReceivedAt.IsZero() {
+		ms := int(time.Since(msg.ReceivedAt).Milliseconds())
+		latency = &ms
+	}
+	l.record(msg, repository.DirectionOutgoing, text, latency)
+}
+
+func (l *ConversationLogger) record(msg entities.Message, direction, content string, latency *int) {
+	if l == nil || l.repo == nil {
+		return
+	}
+	schema := msg.SchemaName
+	if schema == "" {
+		schema = "public"
+	}
+	err := l.repo.Insert(&repository.ConversationLog{
+		UserID:     msg.UserID,
+		SchemaName: schema,
+		Platform:   msg.Platform,
+		ChatID:     msg.From,
+		Direction:  direction,
+		Content:    content,
+		MenuAction: msg.MenuAction,
+		LatencyMs:  latency,
+	})
+	if err != nil {
+		fmt.Printf("Warning: failed to log %s message for %s/%s: %v\n", direction, msg.Platform, msg.From, err)
+	}
+}
+
+// ListChats returns the tenant's chats, most recently active first
+func (l *ConversationLogger) ListChats(schema, platform string, limit, offset int) ([]repository.ChatSummary, error) {
+	return l.repo.ListChats(schema, platform, clampLogPageSize(limit), max(offset, 0))
+}
+
+// Transcript returns a page of a chat's messages; pass the smallest ID of the
+// previous page as beforeID to load older messages
+func (l *ConversationLogger) Transcript(schema, platform, chatID string, beforeID int64, limit int) ([]repository.ConversationLog, error) {
+	return l.repo.Transcript(schema, platform, chatID, beforeID, clampLogPageSize(limit))
+}
+
+// LastInbound returns the most recent message received from a chat (nil if none)
+func (l *ConversationLogger) LastInbound(schema, platform, chatID string) (*repository.ConversationLog, error) {
+	return l.repo.LastInbound(schema, platform, chatID)
+}
+
+// Search finds messages in the tenant's conversations matching query
+func (l *ConversationLogger) Search(schema, query string, limit, offset int) ([]repository.ConversationLog, error) {
+	return l.repo.Search(schema, query, clampLogPageSize(limit), max(offset, 0))
+}
+
+func clampLogPageSize(limit int) int {
+	if limit <= 0 {
+		return defaultLogPageSize
+	}
+	if limit > maxLogPageSize {
+		return maxLogPageSize
+	}
+	return limit
+}
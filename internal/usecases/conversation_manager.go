@@ -0,0 +1,277 @@
+package usecases
+
+import (
+	"fmt"
+	"hash/fnv"
+	"project_masAde/internal/entities"
+	"project_masAde/internal/repository"
+	"sync"
+	"time"
+)
+
+// Conversation states
+const (
+	StateIdle              = "idle"
+	StateAwaitingCalcInput = "awaiting_calc_input" // Asked for "qty product [weight]"
+	StateCalcCompleted     = "calc_completed"      // Result shown, "1" recalculates on the same dataset
+	StateBrowsingMenu      = "browsing_menu"       // Navigating the menu tree (data: menu, path)
+	StateHandoffPending    = "handoff_pending"     // Customer asked for a human; bot stays quiet (data: user_id)
+	StateHumanAgent        = "human_agent"         // Staff member has taken over (data: user_id, agent_id)
+	StateSearchResults     = "search_results"      // A search has more pages; "lagi" shows the next (data: query, offset)
+)
+
+// conversationTransitions lists the states reachable from each state.
+// Returning to idle is always allowed. Staff may take over a chat from any state.
+var conversationTransitions = map[string][]string{
+	StateIdle:              {StateAwaitingCalcInput, StateBrowsingMenu, StateSearchResults, StateHandoffPending, StateHumanAgent},
+	StateAwaitingCalcInput: {StateAwaitingCalcInput, StateCalcCompleted, StateBrowsingMenu, StateSearchResults, StateHandoffPending, StateHumanAgent},
+	StateCalcCompleted:     {StateAwaitingCalcInput, StateBrowsingMenu, StateSearchResults, StateHandoffPending, StateHumanAgent},
+	StateBrowsingMenu:      {StateBrowsingMenu, StateAwaitingCalcInput, StateSearchResults, StateHandoffPending, StateHumanAgent},
+	StateSearchResults:     {StateSearchResults, StateAwaitingCalcInput, StateBrowsingMenu, StateHandoffPending, StateHumanAgent},
+	StateHandoffPending:    {StateHandoffPending, StateHumanAgent},
+	StateHumanAgent:        {StateHumanAgent},
+}
+
+// conversationTimeouts is how long a chat may stay in a state before it falls back to idle
+var conversationTimeouts = map[string]time.Duration{
+	StateAwaitingCalcInput: 10 * time.Minute,
+	StateCalcCompleted:     30 * time.Minute,
+	StateBrowsingMenu:      30 * time.Minute,
+	StateSearchResults:     30 * time.Minute,
+	StateHandoffPending:    30 * time.Minute, // Nobody picked it up: back to the bot
+	StateHumanAgent:        15 * time.Minute, // Idle timeout for a taken-over chat
+}
+
+// ConversationKey identifies a chat across tenants and platforms
+type ConversationKey struct {
+	Schema   string
+	Platform string
+	ChatID   string
+}
+
+// conversationKeyFor builds the key for the chat a message came from
+func conversationKeyFor(msg entities.Message) ConversationKey {
+	schema := msg.SchemaName
+	if schema == "" {
+		schema = "public"
+	}
+	return ConversationKey{Schema: schema, Platform: msg.Platform, ChatID: msg.From}
+}
+
+func (k ConversationKey) String() string {
+	return fmt.Sprintf("%s:%s:%s", k.Schema, k.Platform, k.ChatID)
+}
+
+// conversationLockStripes is how many locks chats are spread over
+const conversationLockStripes = 64
+
+// ConversationManager tracks per-chat conversation state.
+// States are persisted in Postgres so multi-step flows survive restarts,
+// and cached in memory to avoid a query per message. Database I/O runs under
+// a per-chat lock only, so one slow query does not hold up other chats.
+type ConversationManager struct {
+	repo  *repository.ConversationRepository
+	cache map[string]*repository.ConversationState
+	mu    sync.Mutex // Guards cache only
+	locks [conversationLockStripes]sync.Mutex
+}
+
+// NewConversationManager creates a manager and starts the expiry sweeper
+func NewConversationManager(repo *repository.ConversationRepository) *ConversationManager {
+	m := &ConversationManager{
+		repo:  repo,
+		cache: make(map[string]*repository.ConversationState),
+	}
+	go m.cleanup()
+	return m
+}
+
+// lock serializes the operations on one chat; chats sharing a stripe wait
+// for each other, others do not
+func (m *ConversationManager) lock(key ConversationKey) *sync.Mutex {
+	h := fnv.New32a()
+	h.Write([]byte(key.String()))
+	l := &m.locks[h.Sum32()%conversationLockStripes]
+	l.Lock()
+	return l
+}
+
+// Get returns the current state of a chat. Expired or missing states read as idle.
+func (m *ConversationManager) Get(key ConversationKey) *repository.ConversationState {
+	defer m.lock(key).Unlock()
+	return m.load(key)
+}
+
+// load returns a copy-safe state for key; caller must hold the chat's lock
+func (m *ConversationManager) load(key ConversationKey) *repository.ConversationState {
+	now := time.Now()
+	m.mu.Lock()
+	state, cached := m.cache[key.String()]
+	m.mu.Unlock()
+	if !cached && m.repo != nil {
+		stored, err := m.repo.Get(key.Schema, key.Platform, key.ChatID)
+		if err != nil {
+			fmt.Printf("Warning: failed to load conversation %s: %v\n", key, err)
+		}
+		state = stored
+	}
+
+	if state == nil || state.Expired(now) {
+		if state != nil {
+			m.forget(key)
+		}
+		// Cache idle too so quiet chats don't hit the database on every message
+		state = &repository.ConversationState{
+			SchemaName: key.Schema,
+			Platform:   key.Platform,
+			ChatID:     key.ChatID,
+			State:      StateIdle,
+			Data:       map[string]string{},
+			UpdatedAt:  now,
+		}
+	}
+
+	m.mu.Lock()
+	m.cache[key.String()] = state
+	m.mu.Unlock()
+	return cloneConversationState(state)
+}
+
+// Transition moves a chat to a new state with the given data.
+// The timeout for the target state is applied automatically.
+func (m *ConversationManager) Transition(key ConversationKey, to string, data map[string]string) error {
+	defer m.lock(key).Unlock()
+
+	current := m.load(key)
+	if !canTransition(current.State, to) {
+		return fmt.Errorf("invalid conversation transition %s -> %s", current.State, to)
+	}
+
+	if to == StateIdle {
+		m.forget(key)
+		return nil
+	}
+
+	next := &repository.ConversationState{
+		SchemaName: key.Schema,
+		Platform:   key.Platform,
+		ChatID:     key.ChatID,
+		State:      to,
+		Data:       data,
+		UpdatedAt:  time.Now(),
+	}
+	if next.Data == nil {
+		next.Data = map[string]string{}
+	}
+	if ttl, ok := conversationTimeouts[to]; ok {
+		expires := time.Now().Add(ttl)
+		next.ExpiresAt = &expires
+	}
+
+	if m.repo != nil {
+		if err := m.repo.Save(next); err != nil {
+			return fmt.Errorf("failed to save conversation state: %w", err)
+		}
+	}
+	m.mu.Lock()
+	m.cache[key.String()] = next
+	m.mu.Unlock()
+	return nil
+}
+
+// List returns the live (unexpired) chats of a tenant in any of the given states
+func (m *ConversationManager) List(schema string, states ...string) ([]*repository.ConversationState, error) {
+	if m.repo != nil {
+		return m.repo.ListByState(schema, states)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	var result []*repository.ConversationState
+	for _, state := range m.cache {
+		if state.SchemaName != schema || state.Expired(now) {
+			continue
+		}
+		for _, s := range states {
+			if state.State == s {
+				result = append(result, cloneConversationState(state))
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+// Reset returns a chat to idle
+func (m *ConversationManager) Reset(key ConversationKey) {
+	defer m.lock(key).Unlock()
+	m.forget(key)
+}
+
+// Consume returns a chat in the given state to idle and reports whether it
+// was in it. Of concurrent calls for one chat only the first gets true.
+func (m *ConversationManager) Consume(key ConversationKey, state string) bool {
+	defer m.lock(key).Unlock()
+	if m.load(key).State != state {
+		return false
+	}
+	m.forget(key)
+	return true
+}
+
+// forget drops a chat's state from cache and storage; caller must hold the chat's lock
+func (m *ConversationManager) forget(key ConversationKey) {
+	m.mu.Lock()
+	delete(m.cache, key.String())
+	m.mu.Unlock()
+	if m.repo != nil {
+		if err := m.repo.Delete(key.Schema, key.Platform, key.ChatID); err != nil {
+			fmt.Printf("Warning: failed to delete conversation %s: %v\n", key, err)
+		}
+	}
+}
+
+// cleanup removes expired states periodically
+func (m *ConversationManager) cleanup() {
+	ticker := time.NewTicker(5 * time.Minute)
+	for range ticker.C {
+		now := time.Now()
+		m.mu.Lock()
+		for k, state := range m.cache {
+			idleTooLong := state.State == StateIdle && now.Sub(state.UpdatedAt) > 30*time.Minute
+			if state.Expired(now) || idleTooLong {
+				delete(m.cache, k)
+			}
+		}
+		m.mu.Unlock()
+
+		if m.repo != nil {
+			if _, err := m.repo.DeleteExpired(); err != nil {
+				fmt.Printf("Warning: conversation cleanup failed: %v\n", err)
+			}
+		}
+	}
+}
+
+// canTransition checks the transition table
+func canTransition(from, to string) bool {
+	if to == StateIdle {
+		return true
+	}
+	for _, allowed := range conversationTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+func cloneConversationState(s *repository.ConversationState) *repository.ConversationState {
+	c := *s
+	c.Data = make(map[string]string, len(s.Data))
+	for k, v := range s.Data {
+		c.Data[k] = v
+	}
+	return &c
+}
@@ -0,0 +1,287 @@
+package usecases
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"project_masAde/internal/entities"
+	"project_masAde/internal/repository"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Errors returned by CurrencyService
+var (
+	ErrInvalidExchangeRate  = errors.New("invalid exchange rate")
+	ErrExchangeRateNotFound = errors.New("exchange rate not found")
+	ErrNoExchangeRate       = errors.New("no exchange rate between the currencies")
+)
+
+// fallbackCurrency prices dataset rows without a currency column when the
+// tenant has no display currency
+const fallbackCurrency = "USD"
+
+// maxRateImportRows caps an exchange rate CSV upload
+const maxRateImportRows = 1000
+
+var currencyCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// QuoteConversion is a quote's totals in another currency
+type QuoteConversion struct {
+	Currency string  `json:"currency"`
+	Rate     float64 `json:"rate"` // 1 quote currency = Rate Currency
+	Subtotal float64 `json:"subtotal"`
+	Total    float64 `json:"total"`
+}
+
+// CurrencyService manages a tenant's exchange rates and display currency
+type CurrencyService struct {
+	repo       *repository.ExchangeRateRepository
+	configRepo *repository.ConfigRepository
+}
+
+// NewCurrencyService creates the currency service
+func NewCurrencyService(repo *repository.ExchangeRateRepository, configRepo *repository.ConfigRepository) *CurrencyService {
+	return &CurrencyService{repo: repo, configRepo: configRepo}
+}
+
+// normalizeCurrency upper-cases a currency code, "" if it is not one
+func normalizeCurrency(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if !currencyCodePattern.MatchString(code) {
+		return ""
+	}
+	return code
+}
+
+// DisplayCurrency is the currency the tenant's quotes are converted to
+// (bot_config display_currency), "" when unset
+func (s *CurrencyService) DisplayCurrency(schema string) string {
+	if s == nil || s.configRepo == nil {
+		return ""
+	}
+	value, err := s.configRepo.GetConfig(schema, "display_currency")
+	if err != nil {
+		fmt.Printf("Warning: failed to read display_currency: %v\n", err)
+		return ""
+	}
+	return normalizeCurrency(value)
+}
+
+// SetDisplayCurrency changes the tenant's display currency; "" turns conversion off
+func (s *CurrencyService) SetDisplayCurrency(schema, currency string) error {
+	code := normalizeCurrency(currency)
+	if code == "" && strings.TrimSpace(currency) != "" {
+		return fmt.Errorf("%w: currency must be a 3-letter code", ErrInvalidExchangeRate)
+	}
+	return s.configRepo.SetConfig(schema, "display_currency", code)
+}
+
+// List returns the tenant's rates
+func (s *CurrencyService) List(schema string) ([]repository.ExchangeRate, error) {
+	return s.repo.List(schema)
+}
+
+// Set stores the rate of a currency pair (1 base = rate quote)
+func (s *CurrencyService) Set(schema string, rate *repository.ExchangeRate) error {
+	rate.Base, rate.Quote = normalizeCurrency(rate.Base), normalizeCurrency(rate.Quote)
+	switch {
+	case rate.Base == "" || rate.Quote == "":
+		return fmt.Errorf("%w: currencies must be 3-letter codes", ErrInvalidExchangeRate)
+	case rate.Base == rate.Quote:
+		return fmt.Errorf("%w: base and quote currency are the same", ErrInvalidExchangeRate)
+	case !(rate.Rate > 0) || math.IsInf(rate.Rate, 0):
+		return fmt.Errorf("%w: rate must be positive", ErrInvalidExchangeRate)
+	}
+	return s.repo.Upsert(schema, rate)
+}
+
+// Delete removes a rate
+func (s *CurrencyService) Delete(schema string, id int) error {
+	ok, err := s.repo.Delete(schema, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrExchangeRateNotFound
+	}
+	return nil
+}
+
+// ImportCSV sets rates from "base,quote,rate" lines; a header line is
+// skipped. See parseRate for how rates are read. Nothing is stored if any
+// line is invalid.
+func (s *CurrencyService) ImportCSV(schema string, r io.Reader) (int, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	records, err := cr.ReadAll()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidExchangeRate, err)
+	}
+	if len(records) > 0 && len(records[0]) >= 3 {
+		if _, isNumber := parseRate(records[0][2]); !isNumber {
+			records = records[1:] // Header
+		}
+	}
+	if len(records) > maxRateImportRows {
+		return 0, fmt.Errorf("%w: at most %d rates per file", ErrInvalidExchangeRate, maxRateImportRows)
+	}
+
+	rates := make([]repository.ExchangeRate, 0, len(records))
+	for i, record := range records {
+		if len(record) < 3 {
+			return 0, fmt.Errorf("%w: line %d needs base,quote,rate", ErrInvalidExchangeRate, i+1)
+		}
+		value, ok := parseRate(record[2])
+		if !ok {
+			return 0, fmt.Errorf("%w: line %d: rate %q is not a number", ErrInvalidExchangeRate, i+1, record[2])
+		}
+		rate := repository.ExchangeRate{Base: normalizeCurrency(record[0]), Quote: normalizeCurrency(record[1]), Rate: value}
+		if rate.Base == "" || rate.Quote == "" || rate.Base == rate.Quote || !(value > 0) {
+			return 0, fmt.Errorf("%w: line %d", ErrInvalidExchangeRate, i+1)
+		}
+		rates = append(rates, rate)
+	}
+	for i := range rates {
+		if err := s.repo.Upsert(schema, &rates[i]); err != nil {
+			return i, err
+		}
+	}
+	return len(rates), nil
+}
+
+// parseRate reads an exchange rate. Unlike quantities, the last "." or ","
+// is always the decimal point: rates like "1.085" are common, thousands are
+// not, so "15.500" is 15.5 (write 15500). The other separator may group
+// thousands before it ("15,500.25"); a repeated decimal separator is rejected.
+func parseRate(s string) (float64, bool) {
+	s = strings.TrimSpace(s)
+	i := strings.LastIndexAny(s, ".,")
+	if i >= 0 {
+		intPart, frac := s[:i], s[i+1:]
+		thousands := ","
+		if s[i] == ',' {
+			thousands = "."
+		}
+		if strings.Contains(intPart, string(s[i])) || frac == "" {
+			return 0, false
+		}
+		s = strings.ReplaceAll(intPart, thousands, "") + "." + frac
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsInf(v, 0) || math.IsNaN(v) {
+		return 0, false
+	}
+	return v, true
+}
+
+// Rate returns how many units of to one unit of from is worth. A pair is
+// used in either direction.
+func (s *CurrencyService) Rate(schema, from, to string) (float64, error) {
+	from, to = normalizeCurrency(from), normalizeCurrency(to)
+	if from != "" && from == to {
+		return 1, nil
+	}
+	if s == nil || s.repo == nil || from == "" || to == "" {
+		return 0, ErrNoExchangeRate
+	}
+	rate, err := s.repo.Find(schema, from, to)
+	if err != nil {
+		return 0, err
+	}
+	if rate == nil {
+		return 0, ErrNoExchangeRate
+	}
+	if rate.Base == from {
+		return rate.Rate, nil
+	}
+	return 1 / rate.Rate, nil
+}
+
+// Convert adds the quote's totals in a currency ("" = the display currency).
+// A quote already in that currency gets no conversion.
+func (s *CurrencyService) Convert(schema string, quote *Quote, currency string) error {
+	if currency == "" {
+		currency = s.DisplayCurrency(schema)
+	}
+	currency = normalizeCurrency(currency)
+	quote.Converted = nil
+	if currency == "" || strings.EqualFold(currency, quote.Currency) {
+		return nil
+	}
+	rate, err := s.Rate(schema, quote.Currency, currency)
+	if err != nil {
+		return err
+	}
+	quote.Converted = &QuoteConversion{
+		Currency: currency,
+		Rate:     rate,
+		Subtotal: roundMoney(quote.Subtotal * rate),
+		Total:    roundMoney(quote.Total * rate),
+	}
+	return nil
+}
+
+// currencyRequestPattern matches "in IDR" / "dalam USD" at the end of a message
+var currencyRequestPattern = regexp.MustCompile(`(?i)(?:^|\s)(?:in|dalam|ke|harga dalam|convert to|konversi ke)\s+([a-z]{3})\s*$`)
+
+// splitCurrencyRequest separates a trailing "in IDR" from calculation input.
+// Only currencies with a known format count, so product names ending in
+// "ke abc" are left alone.
+func splitCurrencyRequest(input string) (string, string) {
+	loc := currencyRequestPattern.FindStringSubmatchIndex(input)
+	if loc == nil {
+		return input, ""
+	}
+	code := strings.ToUpper(input[loc[2]:loc[3]])
+	if _, known := currencyFormats[code]; !known {
+		return input, ""
+	}
+	return strings.TrimSpace(input[:loc[0]]), code
+}
+
+// conversionFailure explains why a quote could not be shown in a currency
+func conversionFailure(quote *Quote, currency string, err error) string {
+	if errors.Is(err, ErrNoExchangeRate) {
+		return fmt.Sprintf("❌ Kurs %s ke %s belum tersedia.", quote.Currency, currency)
+	}
+	fmt.Printf("Warning: currency conversion failed: %v\n", err)
+	return "❌ Gagal mengonversi mata uang. Silakan coba lagi."
+}
+
+// handleCurrencyCommand shows the chat's cart, or else its last calculation,
+// in another currency ("in IDR", "dalam USD"). The choice sticks to the
+// calculation, so a following PDF shows it too.
+func (s *MessageService) handleCurrencyCommand(msg entities.Message, content string) (bool, error) {
+	rest, currency := splitCurrencyRequest(content)
+	if currency == "" || rest != "" {
+		return false, nil
+	}
+	key := conversationKeyFor(msg)
+	quote, cart, failure := s.currentQuote(key)
+	if failure != "" {
+		return true, s.sendReply(msg, failure)
+	}
+	if quote == nil {
+		return true, s.sendReply(msg, "💱 Belum ada perhitungan untuk dikonversi.\nHitung harga dulu (ketik *MENU*), lalu ketik *IN "+currency+"*.")
+	}
+	if err := s.Calculator.Currency.Convert(key.Schema, quote, currency); err != nil {
+		return true, s.sendReply(msg, conversionFailure(quote, currency, err))
+	}
+
+	title := "✅ *Hasil Perhitungan*"
+	if len(cart) > 0 {
+		title = "🛒 *Keranjang*"
+	} else if s.Conversations != nil {
+		state := s.Conversations.Get(key)
+		state.Data["currency"] = currency
+		if err := s.Conversations.Transition(key, StateCalcCompleted, state.Data); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+	}
+	return true, s.sendReply(msg, formatQuoteTitled(title, quote))
+}
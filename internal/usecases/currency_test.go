@@ -0,0 +1,33 @@
+package usecases
+
+import "testing"
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want float64
+		ok   bool
+	}{
+		{"15500", 15500, true},
+		{"1.085", 1.085, true},
+		{"1,085", 1.085, true},
+		{"1.275", 1.275, true},
+		{"15.500", 15.5, true},
+		{"0,000065", 0.000065, true},
+		{"0.000065", 0.000065, true},
+		{"15,500.25", 15500.25, true},
+		{"15.500,25", 15500.25, true},
+		{" 1.5 ", 1.5, true},
+		{"1.250.000", 0, false},
+		{"1.", 0, false},
+		{"rate", 0, false},
+		{"", 0, false},
+		{"Inf", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseRate(tt.in)
+		if ok != tt.ok || (ok && got != tt.want) {
+			t.Errorf("parseRate(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -2,18 +2,22 @@ package usecases
 
 import (
 	"io"
+	"project_masAde/internal/infrastructure"
 	"project_masAde/internal/repository"
 )
 
 type DashboardUsecase struct {
 	configRepo   *repository.ConfigRepository
 	tableManager *repository.TableManager
+	imports      *importJobs
+	Events       *infrastructure.EventHub // Optional: import progress for the dashboard
 }
 
 func NewDashboardUsecase(configRepo *repository.ConfigRepository, tableManager *repository.TableManager) *DashboardUsecase {
 	return &DashboardUsecase{
 		configRepo:   configRepo,
 		tableManager: tableManager,
+		imports:      newImportJobs(),
 	}
 }
 
@@ -42,42 +46,132 @@ func (u *DashboardUsecase) GetMenu(schemaName, slug string) (*repository.Menu, e
 }
 
 func (u *DashboardUsecase) CreateMenu(schemaName string, m *repository.Menu) error {
+	if err := u.validateMenuChange(schemaName, m, ""); err != nil {
+		return err
+	}
 	return u.configRepo.CreateMenu(schemaName, m)
 }
 
 func (u *DashboardUsecase) UpdateMenu(schemaName string, m *repository.Menu) error {
+	if err := u.validateMenuChange(schemaName, m, ""); err != nil {
+		return err
+	}
 	return u.configRepo.UpdateMenu(schemaName, m)
 }
 
 func (u *DashboardUsecase) DeleteMenu(schemaName, slug string) error {
+	if err := u.validateMenuChange(schemaName, nil, slug); err != nil {
+		return err
+	}
 	return u.configRepo.DeleteMenu(schemaName, slug)
 }
 
+// validateMenuChange checks the menu graph as it would look after saving `saved`
+// and/or deleting `deleted`, rejecting dangling submenu slugs and cycles
+func (u *DashboardUsecase) validateMenuChange(schemaName string, saved *repository.Menu, deleted string) error {
+	existing, err := u.configRepo.GetAllMenus(schemaName)
+	if err != nil {
+		return err
+	}
+	menus := make([]repository.Menu, 0, len(existing)+1)
+	for _, m := range existing {
+		if m.Slug == deleted || (saved != nil && m.Slug == saved.Slug) {
+			continue
+		}
+		menus = append(menus, m)
+	}
+	if saved != nil {
+		menus = append(menus, *saved)
+	}
+	return ValidateMenuGraph(menus)
+}
+
 func (u *DashboardUsecase) GetAllMenus(schemaName string) ([]repository.Menu, error) {
 	return u.configRepo.GetAllMenus(schemaName)
 }
 
 // Dynamic Data Management (tenant-aware)
-func (u *DashboardUsecase) ImportTable(schemaName, displayName string, csvData io.Reader) error {
-	return u.tableManager.ImportCSV(schemaName, displayName, csvData)
+// ReimportTable merges a file into an existing dataset by key column
+func (u *DashboardUsecase) ReimportTable(schemaName, tableName, format, sheet string, data io.Reader, opts repository.ReimportOptions) (*repository.ReimportResult, error) {
+	return u.tableManager.ReimportFile(schemaName, tableName, format, sheet, data, opts)
+}
+
+// ExportTable streams a dataset as CSV, XLSX or JSON
+func (u *DashboardUsecase) ExportTable(schemaName, tableName, format string, w io.Writer) error {
+	return u.tableManager.ExportTable(schemaName, tableName, format, w)
 }
 
 func (u *DashboardUsecase) ListTables(schemaName string) ([]repository.TableMetadata, error) {
 	return u.tableManager.ListTables(schemaName)
 }
 
-func (u *DashboardUsecase) GetTableData(schemaName, tableName string) ([]map[string]interface{}, error) {
-	return u.tableManager.GetTableData(schemaName, tableName)
+func (u *DashboardUsecase) QueryTable(schemaName, tableName string, q repository.TableQuery) (*repository.TablePage, error) {
+	return u.tableManager.QueryTable(schemaName, tableName, q)
 }
 
 func (u *DashboardUsecase) DeleteTable(schemaName, tableName string) error {
 	return u.tableManager.DeleteTable(schemaName, tableName)
 }
 
-func (u *DashboardUsecase) UpdateRow(schemaName, tableName string, rowID int, data map[string]interface{}) error {
-	return u.tableManager.UpdateRow(schemaName, tableName, rowID, data)
+func (u *DashboardUsecase) UpdateRow(schemaName, tableName string, rowID int, data map[string]interface{}, authorID int) error {
+	return u.tableManager.UpdateRow(schemaName, tableName, rowID, data, authorID)
+}
+
+func (u *DashboardUsecase) DeleteRow(schemaName, tableName string, rowID, authorID int) error {
+	return u.tableManager.DeleteRow(schemaName, tableName, rowID, authorID)
+}
+
+// Dataset schema management
+func (u *DashboardUsecase) GetColumns(schemaName, tableName string) ([]repository.ColumnSchema, error) {
+	return u.tableManager.GetColumns(schemaName, tableName)
+}
+
+func (u *DashboardUsecase) AddColumn(schemaName, tableName, column, colType string, authorID int) (*repository.ColumnSchema, error) {
+	return u.tableManager.AddColumn(schemaName, tableName, column, colType, authorID)
+}
+
+// UpdateColumn renames and/or retypes a column. The retype runs first so a
+// failed conversion leaves the column untouched.
+func (u *DashboardUsecase) UpdateColumn(schemaName, tableName, column, newName, newType string, authorID int) error {
+	if newType != "" {
+		if err := u.tableManager.RetypeColumn(schemaName, tableName, column, newType, authorID); err != nil {
+			return err
+		}
+	}
+	if newName != "" {
+		return u.tableManager.RenameColumn(schemaName, tableName, column, newName, authorID)
+	}
+	return nil
+}
+
+func (u *DashboardUsecase) DropColumn(schemaName, tableName, column string, authorID int) error {
+	return u.tableManager.DropColumn(schemaName, tableName, column, authorID)
+}
+
+// Dataset column roles
+func (u *DashboardUsecase) GetRoles(schemaName, tableName string) (repository.ColumnRoles, repository.ColumnRoles, error) {
+	return u.tableManager.GetRoles(schemaName, tableName)
+}
+
+func (u *DashboardUsecase) SetRoles(schemaName, tableName string, roles repository.ColumnRoles) error {
+	return u.tableManager.SetRoles(schemaName, tableName, roles)
+}
+
+// Dataset revision history
+func (u *DashboardUsecase) ListRevisions(schemaName, tableName string, limit, offset int) ([]repository.DatasetRevision, int, error) {
+	return u.tableManager.ListRevisions(schemaName, tableName, limit, offset)
+}
+
+func (u *DashboardUsecase) DiffRevisions(schemaName, tableName string, from, to int) (*repository.RevisionDiff, error) {
+	return u.tableManager.DiffRevisions(schemaName, tableName, from, to)
+}
+
+// RollbackTable restores a dataset's rows to an earlier revision
+func (u *DashboardUsecase) RollbackTable(schemaName, tableName string, revisionID, authorID int) (*repository.DatasetRevision, error) {
+	return u.tableManager.Rollback(schemaName, tableName, revisionID, authorID)
 }
 
-func (u *DashboardUsecase) DeleteRow(schemaName, tableName string, rowID int) error {
-	return u.tableManager.DeleteRow(schemaName, tableName, rowID)
+// SearchDatasets runs the bot's ranked dataset search for the dashboard
+func (u *DashboardUsecase) SearchDatasets(schemaName, query string, limit, offset int) (*repository.SearchResult, error) {
+	return u.tableManager.Search(schemaName, query, limit, offset)
 }
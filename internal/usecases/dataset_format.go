@@ -0,0 +1,173 @@
+package usecases
+
+import (
+	"fmt"
+	"math"
+	"project_masAde/internal/repository"
+	"strconv"
+	"strings"
+)
+
+// formatDatasetRow renders a dataset row for chat. With a name role the row
+// reads like a product ("*Beras Pandan* — Rp 15.000 / kg (min. 10)");
+// without one every column is listed.
+func formatDatasetRow(row map[string]interface{}, cols []repository.ColumnSchema, roles repository.ColumnRoles) string {
+	if roles.Name == "" || row[roles.Name] == nil {
+		var sb strings.Builder
+		for _, col := range cols {
+			sb.WriteString(fmt.Sprintf("%s=%v ", col.Name, row[col.Name]))
+		}
+		return strings.TrimSpace(sb.String())
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("*%v*", row[roles.Name]))
+	if price := rowValue(row, roles.UnitPrice); price != "" {
+		currency := rowValue(row, roles.Currency)
+		if amount, ok := numericValue(row[roles.UnitPrice]); ok && currency != "" {
+			sb.WriteString(" — " + formatAmount(amount, currency))
+		} else {
+			sb.WriteString(" — " + strings.TrimSpace(price+" "+currency))
+		}
+		if unit := rowValue(row, roles.Unit); unit != "" {
+			sb.WriteString(" / " + unit)
+		}
+	}
+	if weight := rowValue(row, roles.Weight); weight != "" {
+		sb.WriteString(fmt.Sprintf(", berat %s", weight))
+	}
+	if minOrder := rowValue(row, roles.MinOrder); minOrder != "" {
+		sb.WriteString(fmt.Sprintf(" (min. %s)", minOrder))
+	}
+	if image := rowValue(row, roles.Image); image != "" {
+		sb.WriteString("\n   🖼️ " + image)
+	}
+	return sb.String()
+}
+
+// rowValue is a row's value in a role column, "" if the role is unset or empty
+func rowValue(row map[string]interface{}, column string) string {
+	if column == "" || row[column] == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", row[column])
+}
+
+// formatQuantity renders a quantity without trailing zeros (1.5, 30)
+func formatQuantity(q float64) string {
+	return strconv.FormatFloat(q, 'f', -1, 64)
+}
+
+// currencyFormat is how amounts of a currency are written locally
+type currencyFormat struct {
+	symbol    string
+	decimals  int // Digits after the decimal separator; 0 shows them only for fractional amounts
+	thousands string
+	decimal   string
+	spaced    bool // Space between symbol and amount
+}
+
+// currencyFormats are the locale conventions of the currencies quotes are
+// shown in: Rp 1.250.000, $1,250.00
+var currencyFormats = map[string]currencyFormat{
+	"IDR": {symbol: "Rp", decimals: 0, thousands: ".", decimal: ",", spaced: true},
+	"USD": {symbol: "$", decimals: 2, thousands: ",", decimal: "."},
+	"EUR": {symbol: "€", decimals: 2, thousands: ".", decimal: ","},
+	"GBP": {symbol: "£", decimals: 2, thousands: ",", decimal: "."},
+	"SGD": {symbol: "S$", decimals: 2, thousands: ",", decimal: "."},
+	"AUD": {symbol: "A$", decimals: 2, thousands: ",", decimal: "."},
+	"HKD": {symbol: "HK$", decimals: 2, thousands: ",", decimal: "."},
+	"MYR": {symbol: "RM", decimals: 2, thousands: ",", decimal: ".", spaced: true},
+	"JPY": {symbol: "¥", decimals: 0, thousands: ",", decimal: "."},
+	"CNY": {symbol: "CN¥", decimals: 2, thousands: ",", decimal: "."},
+	"SAR": {symbol: "SAR", decimals: 2, thousands: ",", decimal: ".", spaced: true},
+}
+
+// formatAmount renders a money amount the way its currency is written
+// locally (Rp 1.250.000, $1,250.00). Unknown currencies get the code after
+// the amount (1,250.00 THB).
+func formatAmount(v float64, currency string) string {
+	f, known := currencyFormats[strings.ToUpper(currency)]
+	if !known {
+		f = currencyFormat{decimals: 2, thousands: ",", decimal: "."}
+	}
+	decimals := f.decimals
+	if decimals == 0 && math.Abs(v-math.Round(v)) >= 0.005 {
+		decimals = 2
+	}
+	digits := strconv.FormatFloat(math.Abs(v), 'f', decimals, 64)
+	whole, frac, _ := strings.Cut(digits, ".")
+	var sb strings.Builder
+	if v < 0 && digits != strconv.FormatFloat(0, 'f', decimals, 64) {
+		sb.WriteString("-")
+	}
+	if known {
+		sb.WriteString(f.symbol)
+		if f.spaced {
+			sb.WriteString(" ")
+		}
+	}
+	for i, d := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			sb.WriteString(f.thousands)
+		}
+		sb.WriteRune(d)
+	}
+	if frac != "" {
+		sb.WriteString(f.decimal + frac)
+	}
+	if !known && currency != "" {
+		sb.WriteString(" " + currency)
+	}
+	return sb.String()
+}
+
+// formatRate renders an exchange rate the readable way round
+// ("1 USD = Rp 15.500" rather than "1 IDR = $0.00")
+func formatRate(from, to string, rate float64) string {
+	if rate < 1 {
+		from, to, rate = to, from, 1/rate
+	}
+	return fmt.Sprintf("1 %s = %s", from, formatAmount(rate, to))
+}
+
+// FormatQuote renders an itemized quote for chat
+func FormatQuote(q *Quote) string {
+	return formatQuoteTitled("✅ *Hasil Perhitungan*", q)
+}
+
+// formatQuoteTitled renders an itemized quote below a title
+func formatQuoteTitled(title string, q *Quote) string {
+	var sb strings.Builder
+	sb.WriteString(title + "\n\n")
+	if len(q.Items) == 1 {
+		item := q.Items[0]
+		sb.WriteString(fmt.Sprintf("📦 Produk: %s\n", item.Product))
+		sb.WriteString(fmt.Sprintf("📊 Jumlah: %s %s\n", formatQuantity(item.Quantity), item.Unit))
+		sb.WriteString(fmt.Sprintf("💰 Harga: %s / %s\n", formatAmount(item.ListPrice, q.Currency), item.Unit))
+		if item.Tier != "" {
+			sb.WriteString(fmt.Sprintf("🏷️ %s: %s / %s\n", item.Tier, formatAmount(item.UnitPrice, q.Currency), item.Unit))
+		}
+		sb.WriteString(fmt.Sprintf("🧮 Subtotal: %s × %s = %s\n", formatQuantity(item.Quantity), formatAmount(item.UnitPrice, q.Currency), formatAmount(item.Amount, q.Currency)))
+	} else {
+		for i, item := range q.Items {
+			sb.WriteString(fmt.Sprintf("%d. *%s*: %s %s × %s = %s", i+1, item.Product, formatQuantity(item.Quantity), item.Unit, formatAmount(item.UnitPrice, q.Currency), formatAmount(item.Amount, q.Currency)))
+			if item.Tier != "" {
+				sb.WriteString(" (" + item.Tier + ")")
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString(fmt.Sprintf("\n🧮 Subtotal: %s\n", formatAmount(q.Subtotal, q.Currency)))
+	}
+	for _, line := range q.Discounts {
+		sb.WriteString(fmt.Sprintf("➖ %s: %s\n", line.Label, formatAmount(line.Amount, q.Currency)))
+	}
+	for _, line := range q.Taxes {
+		sb.WriteString(fmt.Sprintf("➕ %s: %s\n", line.Label, formatAmount(line.Amount, q.Currency)))
+	}
+	sb.WriteString(fmt.Sprintf("\n🏷️ *Total: %s*", formatAmount(q.Total, q.Currency)))
+	if c := q.Converted; c != nil {
+		sb.WriteString(fmt.Sprintf("\n💱 *≈ %s* (kurs %s)", formatAmount(c.Total, c.Currency), formatRate(q.Currency, c.Currency, c.Rate)))
+	}
+	return sb.String()
+}
@@ -0,0 +1,285 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"project_masAde/internal/entities"
+	"project_masAde/internal/infrastructure"
+	"project_masAde/internal/repository"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PDFMimeType is the content type of generated documents
+const PDFMimeType = "application/pdf"
+
+// defaultQuoteValidityDays is how long a quotation is valid unless the
+// tenant sets quote_validity_days
+const defaultQuoteValidityDays = 7
+
+// Branding is the tenant's letterhead on generated documents. It comes from
+// the bot_config keys company_name, company_address, company_phone,
+// company_email and document_footer.
+type Branding struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	Phone   string `json:"phone"`
+	Email   string `json:"email"`
+	Footer  string `json:"footer"`
+}
+
+// Document is a generated file ready to send or download
+type Document struct {
+	Number   string
+	Filename string
+	Data     []byte
+}
+
+// DocumentService renders quotations and invoices as PDF
+type DocumentService struct {
+	configRepo *repository.ConfigRepository
+}
+
+// NewDocumentService creates the document service
+func NewDocumentService(configRepo *repository.ConfigRepository) *DocumentService {
+	return &DocumentService{configRepo: configRepo}
+}
+
+// config reads a tenant setting, "" when unset or unavailable
+func (s *DocumentService) config(schema, key string) string {
+	if s.configRepo == nil {
+		return ""
+	}
+	value, err := s.configRepo.GetConfig(schema, key)
+	if err != nil {
+		fmt.Printf("Warning: failed to read %s: %v\n", key, err)
+		return ""
+	}
+	return strings.TrimSpace(value)
+}
+
+// Branding returns the tenant's letterhead
+func (s *DocumentService) Branding(schema string) Branding {
+	b := Branding{
+		Name:    s.config(schema, "company_name"),
+		Address: s.config(schema, "company_address"),
+		Phone:   s.config(schema, "company_phone"),
+		Email:   s.config(schema, "company_email"),
+		Footer:  s.config(schema, "document_footer"),
+	}
+	if b.Name == "" {
+		b.Name = "Penawaran Harga"
+	}
+	return b
+}
+
+// validityDays returns how long the tenant's quotations are valid
+func (s *DocumentService) validityDays(schema string) int {
+	if days, err := strconv.Atoi(s.config(schema, "quote_validity_days")); err == nil && days > 0 {
+		return days
+	}
+	return defaultQuoteValidityDays
+}
+
+// QuotePDF renders a quotation. Quotes are not stored: the number is
+// derived from the time it was issued.
+func (s *DocumentService) QuotePDF(schema string, quote *Quote, customer string, issued time.Time) *Document {
+	number := "QUO-" + issued.Format("060102-150405")
+	validUntil := issued.AddDate(0, 0, s.validityDays(schema))
+	data := renderQuoteDocument(s.Branding(schema), quoteDocument{
+		Title:    "PENAWARAN HARGA",
+		Number:   number,
+		Date:     issued,
+		Details:  [][2]string{{"Berlaku s/d", validUntil.Format("02/01/2006")}},
+		Customer: customer,
+		Quote:    quote,
+	})
+	return &Document{Number: number, Filename: number + ".pdf", Data: data}
+}
+
+// InvoicePDF renders an order as an invoice
+func (s *DocumentService) InvoicePDF(schema string, order *repository.Order) *Document {
+	customer := order.ContactName
+	if customer == "" {
+		customer = order.ChatID
+	}
+	data := renderQuoteDocument(s.Branding(schema), quoteDocument{
+		Title:    "INVOICE",
+		Number:   order.Number,
+		Date:     order.CreatedAt,
+		Details:  [][2]string{{"Status", orderStatusLabels[order.Status]}},
+		Customer: customer,
+		Quote:    orderQuote(order),
+	})
+	return &Document{Number: order.Number, Filename: order.Number + ".pdf", Data: data}
+}
+
+// quoteDocument is what a quotation or invoice shows besides the letterhead
+type quoteDocument struct {
+	Title    string
+	Number   string
+	Date     time.Time
+	Details  [][2]string // Extra label/value lines below the date
+	Customer string
+	Quote    *Quote
+}
+
+// Layout of generated documents, in points
+const (
+	docMargin    = 50.0
+	docRight     = infrastructure.PDFPageWidth - docMargin
+	docRowHeight = 18.0
+	docBottom    = infrastructure.PDFPageHeight - 90
+	docQtyRight  = 330.0
+	docPriceEnd  = 435.0
+)
+
+// renderQuoteDocument lays out a quotation or invoice on A4 pages
+func renderQuoteDocument(b Branding, doc quoteDocument) []byte {
+	pdf := infrastructure.NewPDF()
+	q := doc.Quote
+
+	// Letterhead (left) and document title (right)
+	pdf.Text(docMargin, 70, 18, true, infrastructure.FitText(b.Name, 280, 18, true))
+	y := 88.0
+	for _, line := range []string{b.Address, b.Phone, b.Email} {
+		if line != "" {
+			pdf.Text(docMargin, y, 9, false, infrastructure.FitText(line, 280, 9, false))
+			y += 12
+		}
+	}
+	pdf.TextRight(docRight, 70, 16, true, doc.Title)
+	details := append([][2]string{{"No.", doc.Number}, {"Tanggal", doc.Date.Format("02/01/2006")}}, doc.Details...)
+	dy := 88.0
+	for _, d := range details {
+		pdf.TextRight(docRight, dy, 9, false, d[0]+": "+d[1])
+		dy += 12
+	}
+	y = max(y, dy) + 10
+	pdf.Line(docMargin, y, docRight, y, 1, 0)
+
+	y += 24
+	pdf.Text(docMargin, y, 9, true, "Kepada:")
+	pdf.Text(docMargin+45, y, 9, false, infrastructure.FitText(doc.Customer, 400, 9, false))
+
+	// Line items
+	y += 24
+	tableHeader := func() {
+		pdf.FillRect(docMargin, y-13, docRight-docMargin, docRowHeight, 0.9)
+		pdf.Text(docMargin+4, y, 9, true, "No")
+		pdf.Text(docMargin+30, y, 9, true, "Produk")
+		pdf.TextRight(docQtyRight, y, 9, true, "Jumlah")
+		pdf.TextRight(docPriceEnd, y, 9, true, "Harga satuan")
+		pdf.TextRight(docRight-4, y, 9, true, "Total")
+		y += docRowHeight
+	}
+	tableHeader()
+	for i, item := range q.Items {
+		if y > docBottom {
+			pdf.AddPage()
+			y = 70
+			tableHeader()
+		}
+		product := item.Product
+		if item.Tier != "" {
+			product += " (" + item.Tier + ")"
+		}
+		pdf.Text(docMargin+4, y, 9, false, strconv.Itoa(i+1))
+		pdf.Text(docMargin+30, y, 9, false, infrastructure.FitText(product, docQtyRight-docMargin-100, 9, false))
+		pdf.TextRight(docQtyRight, y, 9, false, formatQuantity(item.Quantity)+" "+item.Unit)
+		pdf.TextRight(docPriceEnd, y, 9, false, formatAmount(item.UnitPrice, q.Currency))
+		pdf.TextRight(docRight-4, y, 9, false, formatAmount(item.Amount, q.Currency))
+		pdf.Line(docMargin, y+5, docRight, y+5, 0.5, 0.8)
+		y += docRowHeight
+	}
+
+	// Totals
+	totals := [][2]string{{"Subtotal", formatAmount(q.Subtotal, q.Currency)}}
+	for _, line := range q.Discounts {
+		totals = append(totals, [2]string{line.Label, formatAmount(line.Amount, q.Currency)})
+	}
+	for _, line := range q.Taxes {
+		totals = append(totals, [2]string{line.Label, formatAmount(line.Amount, q.Currency)})
+	}
+	if y+float64(len(totals)+3)*docRowHeight > docBottom {
+		pdf.AddPage()
+		y = 70
+	}
+	y += 6
+	for _, t := range totals {
+		pdf.TextRight(docPriceEnd, y, 9, false, infrastructure.FitText(t[0], 200, 9, false))
+		pdf.TextRight(docRight-4, y, 9, false, t[1])
+		y += docRowHeight - 4
+	}
+	y += 4
+	pdf.Line(docPriceEnd-150, y-10, docRight, y-10, 1, 0)
+	pdf.TextRight(docPriceEnd, y+4, 11, true, "TOTAL")
+	pdf.TextRight(docRight-4, y+4, 11, true, formatAmount(q.Total, q.Currency))
+	if c := q.Converted; c != nil {
+		y += docRowHeight
+		pdf.TextRight(docPriceEnd, y+4, 9, false, "Total dalam "+c.Currency)
+		pdf.TextRight(docRight-4, y+4, 9, false, formatAmount(c.Total, c.Currency))
+	}
+
+	// Footer on the last page
+	footer := "Harga dalam " + q.Currency + "."
+	if c := q.Converted; c != nil {
+		footer += " Kurs " + formatRate(q.Currency, c.Currency, c.Rate) + "."
+	}
+	if b.Footer != "" {
+		footer = b.Footer + " " + footer
+	}
+	pdf.Line(docMargin, infrastructure.PDFPageHeight-60, docRight, infrastructure.PDFPageHeight-60, 0.5, 0.6)
+	pdf.Text(docMargin, infrastructure.PDFPageHeight-45, 8, false, infrastructure.FitText(footer, docRight-docMargin, 8, false))
+	return pdf.Bytes()
+}
+
+// contactName is the chat's display name, or its chat ID when unknown
+func (s *MessageService) contactName(msg entities.Message) string {
+	if s.Contacts != nil {
+		if contact, err := s.Contacts.Find(msg.SchemaName, msg.Platform, msg.From); err == nil && contact != nil && contact.DisplayName != "" {
+			return contact.DisplayName
+		}
+	}
+	return msg.From
+}
+
+// sendDocument sends a generated document to the chat, with a text reply
+// where documents cannot be delivered
+func (s *MessageService) sendDocument(msg entities.Message, doc *Document, caption string) error {
+	err := s.Router.SendDocument(msg, doc.Filename, PDFMimeType, doc.Data, caption)
+	if err != nil {
+		fmt.Printf("Warning: failed to send %s to %s: %v\n", doc.Filename, msg.From, err)
+		return s.sendReply(msg, "❌ Dokumen tidak dapat dikirim di chat ini. Silakan hubungi kami untuk mendapatkannya.")
+	}
+	return nil
+}
+
+// handleQuotePDF sends the chat's cart, or else its last calculation, as a
+// PDF quotation
+func (s *MessageService) handleQuotePDF(msg entities.Message) error {
+	quote, _, failure := s.currentQuote(conversationKeyFor(msg))
+	if failure != "" {
+		return s.sendReply(msg, failure)
+	}
+	if quote == nil {
+		return s.sendReply(msg, "📄 Belum ada penawaran.\nHitung harga dulu (ketik *MENU*) atau isi keranjang dengan *TAMBAH [jumlah] [produk]*.")
+	}
+	doc := s.Documents.QuotePDF(msg.SchemaName, quote, s.contactName(msg), time.Now())
+	return s.sendDocument(msg, doc, fmt.Sprintf("📄 Penawaran harga *%s*\nKetik *KONFIRMASI ORDER* untuk memesan.", doc.Number))
+}
+
+// sendInvoice sends one of the chat's orders as a PDF invoice
+func (s *MessageService) sendInvoice(msg entities.Message, number string) error {
+	order, err := s.Orders.Lookup(conversationKeyFor(msg), number)
+	if errors.Is(err, ErrOrderNotFound) {
+		return s.sendReply(msg, fmt.Sprintf("❌ Pesanan *%s* tidak ditemukan.\nKetik *PESANAN SAYA* untuk melihat pesanan Anda.", number))
+	}
+	if err != nil {
+		fmt.Printf("Warning: failed to look up order %s: %v\n", number, err)
+		return s.sendReply(msg, "❌ Gagal mengambil data pesanan. Silakan coba lagi.")
+	}
+	doc := s.Documents.InvoicePDF(msg.SchemaName, order)
+	return s.sendDocument(msg, doc, fmt.Sprintf("🧾 Invoice pesanan *%s*", order.Number))
+}
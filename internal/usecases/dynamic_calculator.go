@@ -1,16 +1,20 @@
 package usecases
 
 import (
+	"errors"
 	"fmt"
 	"project_masAde/internal/repository"
-	"regexp"
-	"strconv"
+	"slices"
 	"strings"
+	"unicode"
 )
 
 // DynamicCalculator performs calculations using data from user-imported datasets
 type DynamicCalculator struct {
-	tableManager *repository.TableManager
+	tableManager  *repository.TableManager
+	Conversations *ConversationManager // Remembers which dataset each chat is calculating against
+	Pricing       *PricingRuleService  // Tiers, discounts, MOQ and tax; list prices only if nil
+	Currency      *CurrencyService     // Exchange rates and display currency; no conversion if nil
 }
 
 func NewDynamicCalculator(tm *repository.TableManager) *DynamicCalculator {
@@ -19,179 +23,393 @@ func NewDynamicCalculator(tm *repository.TableManager) *DynamicCalculator {
 
 // DynamicQuery represents a parsed calculation query
 type DynamicQuery struct {
-	Quantity    int
+	Quantity    float64 // In Unit, or in the dataset's pricing unit if Unit is ""
+	Unit        string  // Canonical unit typed after the quantity ("kg", "lusin"), "" if none
 	ProductName string
-	WeightGrams int
+	WeightGrams float64 // Trailing weight ("30 tumbler 30kg")
 	Error       string
 }
 
-// ParseInput parses user input like "30 tumbler 30kg"
+// ParseInput parses one item: a quantity with an optional unit, the product
+// and an optional trailing weight. Examples: "30 tumbler", "1,5 kg beras",
+// "2 lusin gelas", "1.000 pcs botol", "30 tumbler 30kg". Input that does not
+// start with a quantity is not a calculation.
 func (dc *DynamicCalculator) ParseInput(input string) DynamicQuery {
-	result := DynamicQuery{}
-	input = strings.ToLower(strings.TrimSpace(input))
-
-	// Pattern: quantity product weight
-	// Examples: "30 tumbler 30kg", "50 steel rods 1000g", "10 coffee"
-	quantityRegex := regexp.MustCompile(`^(\d+)\s+(.+?)\s*(\d+)?\s*(kg|g)?$`)
-	matches := quantityRegex.FindStringSubmatch(input)
-
-	if len(matches) < 3 {
-		// Try simpler pattern: quantity product
-		simpleRegex := regexp.MustCompile(`^(\d+)\s+(.+)$`)
-		simpleMatches := simpleRegex.FindStringSubmatch(input)
-		if len(simpleMatches) >= 3 {
-			qty, _ := strconv.Atoi(simpleMatches[1])
-			result.Quantity = qty
-			result.ProductName = strings.TrimSpace(simpleMatches[2])
-			return result
-		}
-		result.Error = "Format tidak dikenali. Gunakan: `30 tumbler` atau `30 tumbler 30kg`"
-		return result
+	input = strings.ToLower(strings.Join(strings.Fields(input), " "))
+	matches := quantityPattern.FindStringSubmatch(input)
+	if matches == nil {
+		return DynamicQuery{Error: "Format tidak dikenali. Gunakan: `30 tumbler`, `1,5 kg beras` atau `2 lusin gelas`"}
+	}
+	qty, ok := parseQuantityNumber(matches[1])
+	if !ok || qty <= 0 || qty > maxQuantity {
+		return DynamicQuery{Error: fmt.Sprintf("Jumlah `%s` tidak valid", matches[1])}
 	}
 
-	// Parse quantity
-	qty, err := strconv.Atoi(matches[1])
-	if err != nil {
-		result.Error = "Quantity tidak valid"
-		return result
+	result := DynamicQuery{Quantity: qty}
+	product := matches[2]
+	// The first word is a unit only if it is a known one: "10 a4 paper" has none
+	if word, rest, _ := strings.Cut(product, " "); word != "" {
+		if unit, isUnit := quantityUnits[word]; isUnit {
+			result.Unit = unit.name
+			product = rest
+		}
 	}
-	result.Quantity = qty
-	result.ProductName = strings.TrimSpace(matches[2])
 
 	// Parse weight if provided
-	if len(matches) > 3 && matches[3] != "" {
-		weight, _ := strconv.Atoi(matches[3])
-		unit := strings.ToLower(matches[4])
-		if unit == "kg" {
-			result.WeightGrams = weight * 1000
-		} else {
-			result.WeightGrams = weight
+	if w := trailingWeightPattern.FindStringSubmatch(product); w != nil {
+		unit, isUnit := quantityUnits[w[3]]
+		weight, ok := parseQuantityNumber(w[2])
+		if isUnit && unit.dimension == dimMass && ok && weight > 0 {
+			result.WeightGrams, _ = convertQuantity(weight, unit.name, unitGram.name)
+			product = w[1]
 		}
 	}
 
+	if !strings.ContainsFunc(product, unicode.IsLetter) {
+		result.Error = "Sebutkan nama produknya, contoh: `1,5 kg beras`"
+		return result
+	}
+	result.ProductName = product
 	return result
 }
 
-// Calculate performs calculation by looking up product in dataset
-func (dc *DynamicCalculator) Calculate(schemaName, tableName string, query DynamicQuery) string {
-	if query.Error != "" {
-		return "❌ " + query.Error
+// maxQuoteItems caps the product lines of one message or cart
+const maxQuoteItems = 50
+
+// ParseItems parses one or more "qty product [weight]" lines, separated by
+// newlines, semicolons or commas ("30 tumbler, 50 gelas 5kg, 10 botol").
+// The second result explains the first line that could not be parsed.
+func (dc *DynamicCalculator) ParseItems(input string) ([]DynamicQuery, string) {
+	lines := splitItemLines(input)
+	if len(lines) == 0 {
+		return nil, dc.ParseInput(input).Error
+	}
+	if len(lines) > maxQuoteItems {
+		return nil, fmt.Sprintf("Maksimal %d produk per pesan", maxQuoteItems)
+	}
+	queries := make([]DynamicQuery, 0, len(lines))
+	for _, line := range lines {
+		query := dc.ParseInput(line)
+		if query.Error != "" {
+			if len(lines) > 1 {
+				return nil, fmt.Sprintf("%s (baris: `%s`)", query.Error, line)
+			}
+			return nil, query.Error
+		}
+		queries = append(queries, query)
 	}
+	return queries, ""
+}
 
-	// Fetch data from dataset
-	data, err := dc.tableManager.GetTableData(schemaName, tableName)
-	if err != nil {
-		return fmt.Sprintf("❌ Error mengambil data: %s", err.Error())
+// splitItemLines splits a multi-item message. A comma between two digits is
+// a decimal or thousands separator ("1,5 kg"), not a new item.
+func splitItemLines(input string) []string {
+	var lines []string
+	var line strings.Builder
+	runes := []rune(input)
+	flush := func() {
+		if l := strings.TrimSpace(line.String()); l != "" {
+			lines = append(lines, l)
+		}
+		line.Reset()
 	}
+	for i, r := range runes {
+		switch {
+		case r == '\n' || r == ';':
+			flush()
+		case r == ',' && !(i > 0 && i+1 < len(runes) && unicode.IsDigit(runes[i-1]) && unicode.IsDigit(runes[i+1])):
+			flush()
+		default:
+			line.WriteRune(r)
+		}
+	}
+	flush()
+	return lines
+}
 
-	if len(data) == 0 {
-		return "❌ Dataset kosong"
+// Calculate looks the products up in the dataset and replies with an itemized
+// quote priced by the tenant's pricing rules
+func (dc *DynamicCalculator) Calculate(schemaName, tableName string, queries ...DynamicQuery) string {
+	return dc.CalculateIn(schemaName, tableName, "", queries...)
+}
+
+// CalculateIn is Calculate with the total also shown in a currency
+// ("" = the tenant's display currency)
+func (dc *DynamicCalculator) CalculateIn(schemaName, tableName, currency string, queries ...DynamicQuery) string {
+	quote, failure := dc.QuoteIn(schemaName, tableName, currency, queries...)
+	if failure != "" {
+		return failure
 	}
+	return FormatQuote(quote)
+}
 
-	// Find product by name (fuzzy match)
-	var matchedRow map[string]interface{}
-	searchName := strings.ToLower(query.ProductName)
+// QuoteIn is Quote converted to a currency ("" = the display currency)
+func (dc *DynamicCalculator) QuoteIn(schemaName, tableName, currency string, queries ...DynamicQuery) (*Quote, string) {
+	quote, failure := dc.Quote(schemaName, tableName, queries...)
+	if failure != "" || currency == "" {
+		return quote, failure
+	}
+	if err := dc.Currency.Convert(schemaName, quote, currency); err != nil {
+		return nil, conversionFailure(quote, currency, err)
+	}
+	return quote, ""
+}
 
-	for _, row := range data {
-		// Check common column names for product name
-		for _, colName := range []string{"name", "nama", "product", "produk", "item"} {
-			if val, ok := row[colName]; ok {
-				if strings.Contains(strings.ToLower(fmt.Sprintf("%v", val)), searchName) {
-					matchedRow = row
-					break
+// QuoteRequest asks for a price of one product of a dataset
+type QuoteRequest struct {
+	TableName string
+	Query     DynamicQuery
+}
+
+// Quote prices parsed queries against one dataset. On failure the second
+// result is the reply explaining why (product not found, below the minimum
+// order, ...).
+func (dc *DynamicCalculator) Quote(schemaName, tableName string, queries ...DynamicQuery) (*Quote, string) {
+	requests := make([]QuoteRequest, len(queries))
+	for i, q := range queries {
+		requests[i] = QuoteRequest{TableName: tableName, Query: q}
+	}
+	return dc.QuoteRequests(schemaName, requests)
+}
+
+// QuoteRequests prices products of any of the tenant's datasets in one
+// quote. Products in other currencies are converted to the first product's
+// currency; the totals are also given in the tenant's display currency.
+func (dc *DynamicCalculator) QuoteRequests(schemaName string, requests []QuoteRequest) (*Quote, string) {
+	if len(requests) == 0 {
+		return nil, "❌ Tidak ada produk untuk dihitung."
+	}
+	displayCurrency := dc.Currency.DisplayCurrency(schemaName)
+	items := make([]QuoteItem, 0, len(requests))
+	currency := ""
+	schemas := tableSchemas{}
+	for _, req := range requests {
+		if req.Query.Error != "" {
+			return nil, "❌ " + req.Query.Error
+		}
+		item, itemCurrency, failure := dc.lookupItem(schemaName, req.TableName, req.Query, schemas)
+		if failure != "" {
+			return nil, failure
+		}
+		if itemCurrency == "" {
+			itemCurrency = displayCurrency
+		}
+		if itemCurrency == "" {
+			itemCurrency = fallbackCurrency
+		}
+		if currency == "" {
+			currency = itemCurrency
+		} else if !strings.EqualFold(currency, itemCurrency) {
+			rate, err := dc.Currency.Rate(schemaName, itemCurrency, currency)
+			if err != nil {
+				if !errors.Is(err, ErrNoExchangeRate) {
+					fmt.Printf("Warning: failed to read exchange rate: %v\n", err)
 				}
+				return nil, fmt.Sprintf("❌ *%s* memakai mata uang %s, produk lain %s. Hitung secara terpisah.", item.Product, itemCurrency, currency)
 			}
+			item.ListPrice *= rate
+			item.Rate = rate
 		}
-		if matchedRow != nil {
-			break
-		}
+		items = append(items, item)
 	}
 
-	if matchedRow == nil {
-		return fmt.Sprintf("❌ Produk '%s' tidak ditemukan di dataset", query.ProductName)
-	}
-
-	// Find price column
-	var price float64
-	var priceFound bool
-	var currency string = "USD"
-
-	for _, colName := range []string{"price", "harga", "unit_price", "cost"} {
-		if val, ok := matchedRow[colName]; ok {
-			priceStr := fmt.Sprintf("%v", val)
-			// Remove currency symbols
-			priceStr = strings.ReplaceAll(priceStr, "$", "")
-			priceStr = strings.ReplaceAll(priceStr, "Rp", "")
-			priceStr = strings.ReplaceAll(priceStr, ",", "")
-			priceStr = strings.TrimSpace(priceStr)
-			
-			if p, err := strconv.ParseFloat(priceStr, 64); err == nil {
-				price = p
-				priceFound = true
-				break
+	rules, err := dc.Pricing.Rules(schemaName)
+	if err != nil {
+		fmt.Printf("Warning: failed to load pricing rules: %v\n", err)
+	}
+	quote, err := BuildQuote(rules, items, currency)
+	if errors.Is(err, ErrBelowMinOrder) {
+		for _, item := range quote.Items {
+			if item.Quantity < item.MinOrder {
+				return nil, fmt.Sprintf("❌ Minimal order *%s* adalah %s %s.", item.Product, formatQuantity(item.MinOrder), item.Unit)
 			}
 		}
 	}
+	if err := dc.Currency.Convert(schemaName, quote, ""); err != nil && !errors.Is(err, ErrNoExchangeRate) {
+		fmt.Printf("Warning: failed to convert quote: %v\n", err)
+	}
+	return quote, ""
+}
 
+// tableSchemas caches dataset schemas by table name while one quote or cart
+// update looks up its items, so each dataset is read once
+type tableSchemas map[string]*repository.TableSchema
+
+func (dc *DynamicCalculator) tableSchema(schemaName, tableName string, schemas tableSchemas) (*repository.TableSchema, error) {
+	if schema, ok := schemas[tableName]; ok {
+		return schema, nil
+	}
+	schema, err := dc.tableManager.Schema(schemaName, tableName)
+	if err != nil {
+		return nil, err
+	}
+	schemas[tableName] = schema
+	return schema, nil
+}
+
+// lookupItem finds the product of a query in the dataset and prepares its
+// quote item: list price, pricing basis, billable quantity and minimum order.
+// The currency is "" when the dataset has no currency column.
+func (dc *DynamicCalculator) lookupItem(schemaName, tableName string, query DynamicQuery, schemas tableSchemas) (QuoteItem, string, string) {
+	schema, err := dc.tableSchema(schemaName, tableName, schemas)
+	if err != nil {
+		return QuoteItem{}, "", fmt.Sprintf("❌ Error mengambil data: %s", err.Error())
+	}
+	// Columns come from the dataset's role mapping (suggested on import)
+	roles := schema.Roles
+	if roles.UnitPrice == "" {
+		return QuoteItem{}, "", "❌ Kolom harga belum ditentukan. Atur peran kolom *harga satuan* untuk dataset ini di dashboard."
+	}
+
+	// Find the product by name in the name and search key columns: exact
+	// matches first, then prefixes, then the most similar
+	matchedRow, err := dc.tableManager.FindProduct(schemaName, schema, query.ProductName, productSearchColumns(roles))
+	if err != nil {
+		return QuoteItem{}, "", fmt.Sprintf("❌ Error mengambil data: %s", err.Error())
+	}
+	if matchedRow == nil {
+		return QuoteItem{}, "", fmt.Sprintf("❌ Produk '%s' tidak ditemukan di dataset", query.ProductName)
+	}
+
+	price, priceFound := numericValue(matchedRow[roles.UnitPrice])
 	if !priceFound {
-		return "❌ Kolom harga tidak ditemukan di dataset. Pastikan ada kolom `price` atau `harga`."
+		return QuoteItem{}, "", fmt.Sprintf("❌ Harga produk '%s' kosong atau bukan angka.", query.ProductName)
+	}
+
+	currency := ""
+	if val := matchedRow[roles.Currency]; roles.Currency != "" && val != nil {
+		currency = fmt.Sprintf("%v", val)
+	}
+
+	item := QuoteItem{
+		Product:   query.ProductName,
+		TableName: schema.TableName,
+		ListPrice: price,
+		Quantity:  query.Quantity,
+		Unit:      pricingBasis(rowValue(matchedRow, roles.Unit)),
+	}
+	if val := matchedRow[roles.Name]; roles.Name != "" && val != nil {
+		item.Product = fmt.Sprintf("%v", val)
+	}
+	if minOrder, ok := numericValue(matchedRow[roles.MinOrder]); ok && roles.MinOrder != "" {
+		item.MinOrder = minOrder
 	}
 
-	// Check for currency column
-	for _, colName := range []string{"currency", "mata_uang"} {
-		if val, ok := matchedRow[colName]; ok {
-			currency = fmt.Sprintf("%v", val)
-			break
+	// A typed unit converts to the price's unit ("2 lusin" of a per-pcs
+	// product is 24 pcs). Datasets without a unit column are priced in the
+	// base unit of whatever the customer typed.
+	if query.Unit != "" {
+		unit := quantityUnits[query.Unit]
+		if roles.Unit == "" {
+			item.Unit = baseUnits[unit.dimension].name
 		}
+		quantity, ok := convertQuantity(query.Quantity, query.Unit, item.Unit)
+		if !ok {
+			return QuoteItem{}, "", fmt.Sprintf("❌ Harga *%s* per %s, jumlah dalam %s tidak bisa dihitung.", item.Product, item.Unit, query.Unit)
+		}
+		item.Quantity = quantity
 	}
 
-	// Get product display name
-	productName := query.ProductName
-	for _, colName := range []string{"name", "nama", "product", "produk"} {
-		if val, ok := matchedRow[colName]; ok {
-			productName = fmt.Sprintf("%v", val)
-			break
+	// A weight bills by weight: always for prices per mass unit, and by the
+	// kilo for datasets without a unit column (how "30 tumbler 30kg" has
+	// always worked)
+	if query.WeightGrams > 0 && (quantityUnits[item.Unit].dimension == dimMass || roles.Unit == "") {
+		if roles.Unit == "" {
+			item.Unit = PerKg
 		}
+		item.Quantity, _ = convertQuantity(query.WeightGrams, unitGram.name, item.Unit)
+	}
+	return item, currency, ""
+}
+
+// productSearchColumns are the columns a product name is looked up in: the
+// name column and the search keys. Empty (every column) if neither is mapped.
+func productSearchColumns(roles repository.ColumnRoles) []string {
+	cols := roles.SearchKeys
+	if roles.Name != "" && !slices.Contains(cols, roles.Name) {
+		cols = append([]string{roles.Name}, cols...)
 	}
+	return cols
+}
 
-	// Calculate total
-	var total float64
-	var calculation string
+// numericValue reads a number from a dataset cell. Typed (integer/decimal)
+// columns arrive as numbers; text columns of older imports are parsed.
+func numericValue(val interface{}) (float64, bool) {
+	switch v := val.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case string:
+		return repository.ParseNumber(v)
+	}
+	return 0, false
+}
 
-	if query.WeightGrams > 0 {
-		// Weight-based calculation
-		weightKg := float64(query.WeightGrams) / 1000
-		total = price * weightKg
-		calculation = fmt.Sprintf("%.2f × %.2f kg", price, weightKg)
-	} else {
-		// Quantity-based calculation
-		total = price * float64(query.Quantity)
-		calculation = fmt.Sprintf("%.2f × %d units", price, query.Quantity)
+// CalculateFromInput is a convenience method that parses and calculates in one call
+// ("10 tumbler in IDR" also shows the total in IDR)
+func (dc *DynamicCalculator) CalculateFromInput(schemaName, tableName, userInput string) string {
+	userInput, currency := splitCurrencyRequest(userInput)
+	queries, failure := dc.ParseItems(userInput)
+	if failure != "" {
+		return "❌ " + failure
 	}
+	return dc.CalculateIn(schemaName, tableName, currency, queries...)
+}
 
-	// Build result
-	result := fmt.Sprintf(`✅ *Hasil Perhitungan*
+// BeginCalculation puts a chat into the awaiting-input state for a dataset
+func (dc *DynamicCalculator) BeginCalculation(key ConversationKey, tableName string) error {
+	if dc.Conversations == nil {
+		return fmt.Errorf("conversation state not configured")
+	}
+	return dc.Conversations.Transition(key, StateAwaitingCalcInput, map[string]string{"table": tableName})
+}
 
-📦 Produk: %s
-📊 Quantity: %d
-💰 Harga: %.2f %s
-🧮 Perhitungan: %s
+// ContinueCalculation calculates against the dataset remembered for the chat.
+// Returns false if the chat is not waiting for calculation input.
+// Unparseable input keeps the chat waiting so the user can retry.
+func (dc *DynamicCalculator) ContinueCalculation(key ConversationKey, input string) (string, bool) {
+	if dc.Conversations == nil {
+		return "", false
+	}
+	state := dc.Conversations.Get(key)
+	if state.State != StateAwaitingCalcInput {
+		return "", false
+	}
+	tableName := state.Data["table"]
 
-🏷️ *Total: %.2f %s*`,
-		productName,
-		query.Quantity,
-		price,
-		currency,
-		calculation,
-		total,
-		currency,
-	)
+	input, currency := splitCurrencyRequest(input)
+	queries, failure := dc.ParseItems(input)
+	if failure != "" {
+		// Stay in the same state (refreshes the timeout)
+		dc.Conversations.Transition(key, StateAwaitingCalcInput, state.Data)
+		return "❌ " + failure, true
+	}
 
-	return result
+	result := dc.CalculateIn(key.Schema, tableName, currency, queries...)
+	data := map[string]string{
+		"table":      tableName,
+		"last_input": input,
+	}
+	if currency != "" {
+		data["currency"] = currency // The PDF of this calculation shows it too
+	}
+	if err := dc.Conversations.Transition(key, StateCalcCompleted, data); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	return result, true
 }
 
-// CalculateFromInput is a convenience method that parses and calculates in one call
-func (dc *DynamicCalculator) CalculateFromInput(schemaName, tableName, userInput string) string {
-	query := dc.ParseInput(userInput)
-	return dc.Calculate(schemaName, tableName, query)
+// LastCalculationTable returns the dataset of the chat's most recent calculation
+func (dc *DynamicCalculator) LastCalculationTable(key ConversationKey) (string, bool) {
+	if dc.Conversations == nil {
+		return "", false
+	}
+	state := dc.Conversations.Get(key)
+	if state.State != StateCalcCompleted || state.Data["table"] == "" {
+		return "", false
+	}
+	return state.Data["table"], true
 }
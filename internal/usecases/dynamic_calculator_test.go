@@ -0,0 +1,79 @@
+package usecases
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseInput(t *testing.T) {
+	tests := []struct {
+		in      string
+		qty     float64
+		unit    string
+		product string
+		grams   float64
+		fails   bool
+	}{
+		{in: "30 tumbler", qty: 30, product: "tumbler"},
+		{in: "1,5 kg beras", qty: 1.5, unit: "kg", product: "beras"},
+		{in: "1,5kg beras", qty: 1.5, unit: "kg", product: "beras"},
+		{in: "2 Lusin  Gelas", qty: 2, unit: "lusin", product: "gelas"},
+		{in: "1.000 pcs botol", qty: 1000, unit: "pcs", product: "botol"},
+		{in: "3 mtr kain", qty: 3, unit: "m", product: "kain"},
+		{in: "30 tumbler 30kg", qty: 30, product: "tumbler", grams: 30000},
+		{in: "10 a4 paper", qty: 10, product: "a4 paper"},
+		{in: "10a4 paper", qty: 10, product: "a4 paper"},
+		{in: "5 kertas-a4", qty: 5, product: "kertas-a4"},
+		{in: "3 3m tape", qty: 3, product: "3m tape"},
+		{in: "4 kgs-x", qty: 4, product: "kgs-x"},
+		{in: "tumbler", fails: true},
+		{in: "5 kg", fails: true},
+		{in: "0 tumbler", fails: true},
+		{in: "1.00.0 tumbler", fails: true},
+	}
+	dc := &DynamicCalculator{}
+	for _, tt := range tests {
+		got := dc.ParseInput(tt.in)
+		if tt.fails {
+			if got.Error == "" {
+				t.Errorf("ParseInput(%q) = %+v; want an error", tt.in, got)
+			}
+			continue
+		}
+		if got.Error != "" || got.Quantity != tt.qty || got.Unit != tt.unit || got.ProductName != tt.product || got.WeightGrams != tt.grams {
+			t.Errorf("ParseInput(%q) = %+v; want %v %q %q %vg", tt.in, got, tt.qty, tt.unit, tt.product, tt.grams)
+		}
+	}
+}
+
+func TestParseItems(t *testing.T) {
+	dc := &DynamicCalculator{}
+	queries, failure := dc.ParseItems("30 tumbler, 1,5 kg beras; 2 lusin gelas")
+	if failure != "" || len(queries) != 3 {
+		t.Fatalf("ParseItems = %+v, %q; want 3 items", queries, failure)
+	}
+	if queries[1].Quantity != 1.5 || queries[1].ProductName != "beras" {
+		t.Errorf("second item = %+v; want 1.5 beras", queries[1])
+	}
+	if _, failure := dc.ParseItems("30 tumbler\ngelas"); failure == "" {
+		t.Error("ParseItems accepted a line without a quantity")
+	}
+}
+
+func TestSplitItemLines(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"30 tumbler", []string{"30 tumbler"}},
+		{"30 tumbler, 50 gelas 5kg, 10 botol", []string{"30 tumbler", "50 gelas 5kg", "10 botol"}},
+		{"1,5 kg beras;2 lusin gelas", []string{"1,5 kg beras", "2 lusin gelas"}},
+		{"1,000 pcs botol\n\n3 meja", []string{"1,000 pcs botol", "3 meja"}},
+		{" , ;\n", nil},
+	}
+	for _, tt := range tests {
+		if got := splitItemLines(tt.in); !slices.Equal(got, tt.want) {
+			t.Errorf("splitItemLines(%q) = %q; want %q", tt.in, got, tt.want)
+		}
+	}
+}
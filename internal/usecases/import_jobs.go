@@ -0,0 +1,172 @@
+package usecases
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"project_masAde/internal/infrastructure"
+	"project_masAde/internal/repository"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Import job statuses
+const (
+	ImportQueued    = "queued"
+	ImportRunning   = "running"
+	ImportCompleted = "completed"
+	ImportFailed    = "failed"
+)
+
+const (
+	maxConcurrentImports = 2              // Imports beyond this wait in the queue
+	importJobRetention   = 24 * time.Hour // Finished jobs are forgotten after this
+)
+
+// ErrImportJobNotFound is returned for unknown (or another tenant's) import jobs
+var ErrImportJobNotFound = errors.New("import job not found")
+
+// ImportJob is a dataset import running in the background
+type ImportJob struct {
+	ID          string                    `json:"id"`
+	SchemaName  string                    `json:"-"`
+	AuthorID    int                       `json:"author_id"`
+	DisplayName string                    `json:"display_name"`
+	Status      string                    `json:"status"`
+	RowsDone    int                       `json:"rows_done"`
+	RowsTotal   int                       `json:"rows_total"` // Known once the file has been scanned
+	Table       *repository.TableMetadata `json:"table,omitempty"`
+	Error       string                    `json:"error,omitempty"`
+	CreatedAt   time.Time                 `json:"created_at"`
+	FinishedAt  *time.Time                `json:"finished_at,omitempty"`
+}
+
+// importJobs tracks import jobs in memory; jobs do not survive a restart
+type importJobs struct {
+	mu    sync.Mutex
+	jobs  map[string]*ImportJob
+	slots chan struct{}
+}
+
+func newImportJobs() *importJobs {
+	return &importJobs{
+		jobs:  make(map[string]*ImportJob),
+		slots: make(chan struct{}, maxConcurrentImports),
+	}
+}
+
+// PreviewImport is a dry run of an import: headers, inferred types, row
+// length mismatches and sample rows, without creating anything
+func (u *DashboardUsecase) PreviewImport(src repository.ImportSource, columnTypes map[string]string) (*repository.ImportPreview, error) {
+	return u.tableManager.PreviewImport(src, columnTypes)
+}
+
+// StartImport queues a dataset import and returns immediately. The job owns
+// the source file and removes it when done.
+func (u *DashboardUsecase) StartImport(schemaName, displayName string, authorID int, src repository.ImportSource, columnTypes map[string]string) ImportJob {
+	id := make([]byte, 8)
+	rand.Read(id)
+	job := &ImportJob{
+		ID:          hex.EncodeToString(id),
+		SchemaName:  schemaName,
+		AuthorID:    authorID,
+		DisplayName: displayName,
+		Status:      ImportQueued,
+		CreatedAt:   time.Now(),
+	}
+
+	u.imports.mu.Lock()
+	u.imports.prune()
+	u.imports.jobs[job.ID] = job
+	snapshot := *job
+	u.imports.mu.Unlock()
+
+	go u.runImport(job, src, columnTypes)
+	return snapshot
+}
+
+func (u *DashboardUsecase) runImport(job *ImportJob, src repository.ImportSource, columnTypes map[string]string) {
+	defer os.Remove(src.Path)
+	u.imports.slots <- struct{}{}
+	defer func() { <-u.imports.slots }()
+
+	u.updateImport(job, func(j *ImportJob) { j.Status = ImportRunning })
+	table, err := u.tableManager.ImportStream(job.SchemaName, job.DisplayName, src, columnTypes, job.AuthorID, func(done, total int) {
+		u.updateImport(job, func(j *ImportJob) {
+			j.RowsDone, j.RowsTotal = done, total
+		})
+	})
+
+	u.updateImport(job, func(j *ImportJob) {
+		now := time.Now()
+		j.FinishedAt = &now
+		if err != nil {
+			j.Status = ImportFailed
+			j.Error = err.Error()
+			return
+		}
+		j.Status = ImportCompleted
+		j.Table = table
+	})
+	if err != nil {
+		fmt.Printf("Warning: import of '%s' failed: %v\n", job.DisplayName, err)
+	}
+}
+
+// updateImport changes a job under the lock and notifies the dashboard
+func (u *DashboardUsecase) updateImport(job *ImportJob, fn func(j *ImportJob)) {
+	u.imports.mu.Lock()
+	fn(job)
+	snapshot := *job
+	u.imports.mu.Unlock()
+
+	u.Events.Publish(infrastructure.Event{
+		Type:       infrastructure.EventDatasetImport,
+		SchemaName: snapshot.SchemaName,
+		Data: map[string]any{
+			"job_id":     snapshot.ID,
+			"status":     snapshot.Status,
+			"rows_done":  snapshot.RowsDone,
+			"rows_total": snapshot.RowsTotal,
+			"error":      snapshot.Error,
+		},
+	})
+}
+
+// ImportJob returns one of the tenant's import jobs
+func (u *DashboardUsecase) ImportJob(schemaName, id string) (*ImportJob, error) {
+	u.imports.mu.Lock()
+	defer u.imports.mu.Unlock()
+	job, ok := u.imports.jobs[id]
+	if !ok || job.SchemaName != schemaName {
+		return nil, ErrImportJobNotFound
+	}
+	snapshot := *job
+	return &snapshot, nil
+}
+
+// ImportJobs lists the tenant's recent import jobs, newest first
+func (u *DashboardUsecase) ImportJobs(schemaName string) []ImportJob {
+	u.imports.mu.Lock()
+	defer u.imports.mu.Unlock()
+	jobs := []ImportJob{}
+	for _, job := range u.imports.jobs {
+		if job.SchemaName == schemaName {
+			jobs = append(jobs, *job)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].CreatedAt.After(jobs[j].CreatedAt) })
+	return jobs
+}
+
+// prune forgets finished jobs past the retention period. Callers hold mu.
+func (j *importJobs) prune() {
+	for id, job := range j.jobs {
+		if job.FinishedAt != nil && time.Since(*job.FinishedAt) > importJobRetention {
+			delete(j.jobs, id)
+		}
+	}
+}
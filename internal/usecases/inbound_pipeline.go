@@ -0,0 +1,200 @@
+package usecases
+
+import (
+	"fmt"
+	"project_masAde/internal/entities"
+	"project_masAde/internal/infrastructure"
+	"project_masAde/internal/repository"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// quotaThresholds are the usage percentages reported to the dashboard once per period
+var quotaThresholds = []int{80, 100}
+
+// InboundPipeline is the single entry point for inbound messages from every channel.
+// It applies quota checks, rate limiting and usage counting before handing the
+// message to MessageService, so Telegram, WhatsApp and web behave the same way.
+type InboundPipeline struct {
+	service     *MessageService
+	userRepo    *repository.UserRepository
+	usageRepo   *repository.UsageRepository
+	rateLimiter *infrastructure.MessageRateLimiter
+	sessions    *infrastructure.SessionManager
+	logger      *ConversationLogger
+	contacts    *repository.ContactRepository
+
+	quotaAlerts map[string]quotaAlert // "userID:period" -> what was last reported
+	alertsMu    sync.Mutex
+}
+
+// quotaAlert is the highest threshold reported in a tenant's current period.
+// A new period replaces it, so there is one per tenant and period kind.
+type quotaAlert struct {
+	periodKey string // "2006-01-02" or "2006-01"
+	threshold int
+}
+
+// NewInboundPipeline creates the shared inbound pipeline
+func NewInboundPipeline(service *MessageService, userRepo *repository.UserRepository, usageRepo *repository.UsageRepository, rateLimiter *infrastructure.MessageRateLimiter, logger *ConversationLogger, contacts *repository.ContactRepository) *InboundPipeline {
+	return &InboundPipeline{
+		service:     service,
+		userRepo:    userRepo,
+		usageRepo:   usageRepo,
+		rateLimiter: rateLimiter,
+		sessions:    infrastructure.NewSessionManager(),
+		logger:      logger,
+		contacts:    contacts,
+		quotaAlerts: make(map[string]quotaAlert),
+	}
+}
+
+// Handle runs a message through logging, quota, rate limit and menu dispatch
+func (p *InboundPipeline) Handle(msg entities.Message) error {
+	if msg.SchemaName == "" {
+		msg.SchemaName = "public"
+	}
+	if msg.ReceivedAt.IsZero() {
+		msg.ReceivedAt = time.Now()
+	}
+	p.logger.RecordInbound(msg)
+	p.touchContact(msg)
+	p.publish(infrastructure.Event{
+		Type:       infrastructure.EventMessageReceived,
+		SchemaName: msg.SchemaName,
+		UserID:     msg.UserID,
+		Data: map[string]any{
+			"platform": msg.Platform,
+			"chat_id":  msg.From,
+			"content":  msg.Content,
+		},
+	})
+
+	// Tenant-owned channels are metered; the platform bot (UserID 0) is not
+	if msg.UserID != 0 {
+		if ok, err := p.checkQuota(msg); !ok {
+			return err
+		}
+	}
+
+	// Debounce button spam (one callback at a time per chat)
+	if msg.IsCallback {
+		if chatID, err := strconv.ParseInt(msg.From, 10, 64); err == nil {
+			session := p.sessions.GetOrCreateSession(chatID)
+			if !session.IsAllowedClick() {
+				return nil
+			}
+			session.StartProcessing()
+			defer session.FinishProcessing()
+		}
+	}
+
+	return p.service.ProcessMessage(msg)
+}
+
+// checkQuota counts the inbound message and verifies the tenant may still reply.
+// Returns false when processing should stop.
+func (p *InboundPipeline) checkQuota(msg entities.Message) (bool, error) {
+	if p.usageRepo != nil {
+		p.usageRepo.IncrementReceived(msg.UserID)
+	}
+
+	if p.userRepo == nil {
+		return true, nil
+	}
+	user, err := p.userRepo.GetByID(msg.UserID)
+	if err != nil || user == nil {
+		return false, fmt.Errorf("error getting user %d: %v", msg.UserID, err)
+	}
+	if !user.IsActive {
+		return false, nil
+	}
+
+	if p.usageRepo != nil {
+		status, err := p.usageRepo.GetQuotaStatus(msg.UserID, user.DailyLimit, user.MonthlyLimit)
+		if err != nil {
+			return false, fmt.Errorf("error getting quota for user %d: %v", msg.UserID, err)
+		}
+		p.reportQuotaThresholds(msg, status)
+
+		canSend, reason := status.CanSend()
+		if !canSend {
+			// Quota notice is not counted as a sent message
+			notice := "⚠️ " + reason + "\n\nYour message quota has been reached. Please contact support or wait for quota reset."
+			if p.service.Router == nil {
+				return false, nil
+			}
+			return false, p.service.Router.SendUnmetered(msg, notice)
+		}
+	}
+
+	if p.rateLimiter != nil && !p.rateLimiter.Allow(msg.UserID) {
+		if waitTime := p.rateLimiter.WaitTime(msg.UserID); waitTime > 0 {
+			time.Sleep(waitTime)
+		}
+	}
+
+	return true, nil
+}
+
+// touchContact records the sender in the tenant's contact registry
+func (p *InboundPipeline) touchContact(msg entities.Message) {
+	if p.contacts == nil || msg.Platform == "web" {
+		return
+	}
+	if err := p.contacts.Touch(msg.SchemaName, msg.Platform, msg.From, msg.UserID, msg.SenderName, msg.Language); err != nil {
+		fmt.Printf("Warning: failed to update contact %s/%s: %v\n", msg.Platform, msg.From, err)
+	}
+}
+
+// reportQuotaThresholds publishes an event the first time a tenant's daily or
+// monthly usage crosses each threshold in the current period
+func (p *InboundPipeline) reportQuotaThresholds(msg entities.Message, status *repository.UserQuotaStatus) {
+	now := time.Now()
+	p.checkThreshold(msg, "daily", now.Format("2006-01-02"), status.DailyLimit, status.DailyPercent)
+	p.checkThreshold(msg, "monthly", now.Format("2006-01"), status.MonthlyLimit, status.MonthlyPercent)
+}
+
+func (p *InboundPipeline) checkThreshold(msg entities.Message, period, periodKey string, limit, percent int) {
+	if limit <= 0 {
+		return
+	}
+	crossed := 0
+	for _, t := range quotaThresholds {
+		if percent >= t {
+			crossed = t
+		}
+	}
+	if crossed == 0 {
+		return
+	}
+
+	key := fmt.Sprintf("%d:%s", msg.UserID, period)
+	p.alertsMu.Lock()
+	if last := p.quotaAlerts[key]; last.periodKey == periodKey && last.threshold >= crossed {
+		p.alertsMu.Unlock()
+		return
+	}
+	p.quotaAlerts[key] = quotaAlert{periodKey: periodKey, threshold: crossed}
+	p.alertsMu.Unlock()
+
+	p.publish(infrastructure.Event{
+		Type:       infrastructure.EventQuotaThreshold,
+		SchemaName: msg.SchemaName,
+		UserID:     msg.UserID,
+		Data: map[string]any{
+			"period":    period,
+			"threshold": crossed,
+			"percent":   percent,
+			"limit":     limit,
+		},
+	})
+}
+
+// publish sends a dashboard event through the router's hub, if any
+func (p *InboundPipeline) publish(evt infrastructure.Event) {
+	if p.service.Router != nil {
+		p.service.Router.Events.Publish(evt)
+	}
+}
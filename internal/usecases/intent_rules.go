@@ -0,0 +1,420 @@
+package usecases
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"project_masAde/internal/entities"
+	"project_masAde/internal/repository"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Intent actions handled by the message service itself. Rules may also target
+// any menu item action (reply, view_table, calculate_from_table, submenu, handoff, ...).
+const (
+	IntentGreeting      = "greeting"       // Welcome message + main menu
+	IntentMenu          = "menu"           // Main menu (or every menu when none is configured)
+	IntentSearch        = "search"         // Dataset search; the query is the text after the trigger
+	IntentCalculate     = "calculate"      // Start a calculation (payload = dataset, default: last used)
+	IntentCalculateHint = "calculate_hint" // Explain how to calculate (payload = custom text)
+	IntentCartAdd       = "cart_add"       // Add the items after the trigger (or the last calculation) to the cart
+	IntentCartList      = "cart"           // Show the cart as one quote
+	IntentCartRemove    = "cart_remove"    // Remove the cart item numbered after the trigger
+	IntentCartClear     = "cart_clear"     // Empty the cart
+	IntentOrderConfirm  = "order_confirm"  // Order the cart, or else the last calculation
+	IntentQuotePDF      = "quote_pdf"      // Send the cart, or else the last calculation, as a PDF quotation
+)
+
+// Errors returned by IntentRuleService
+var (
+	ErrInvalidIntentRule  = errors.New("invalid intent rule")
+	ErrIntentRuleNotFound = errors.New("intent rule not found")
+)
+
+// intentActions lists every action a rule may target, and whether it needs a payload
+var intentActions = map[string]bool{
+	IntentGreeting:                 false,
+	IntentMenu:                     false,
+	IntentSearch:                   false,
+	IntentCalculate:                false,
+	IntentCalculateHint:            false,
+	IntentCartAdd:                  false,
+	IntentCartList:                 false,
+	IntentCartRemove:               false,
+	IntentCartClear:                false,
+	IntentOrderConfirm:             false,
+	IntentQuotePDF:                 false,
+	repository.MenuActionReply:     true,
+	repository.MenuActionViewTable: true,
+	repository.MenuActionCalculate: true,
+	repository.MenuActionSubmenu:   true,
+	repository.MenuActionHandoff:   false,
+	repository.MenuActionBack:      false,
+	repository.MenuActionHome:      false,
+}
+
+// DefaultIntentRules are used by tenants that have not defined rules of their own
+func DefaultIntentRules() []repository.IntentRule {
+	rules := []repository.IntentRule{
+		{Pattern: `\b(halo|hai|hello|hi|selamat (pagi|siang|sore|malam)|assalamualaikum|asslmkm|start)\b`, MatchType: repository.MatchRegex, Priority: 100, Action: IntentGreeting},
+		{Pattern: "calculate", MatchType: repository.MatchContains, Priority: 80, Action: IntentCalculate},
+		{Pattern: "cari", MatchType: repository.MatchPrefix, Priority: 50, Action: IntentSearch},
+		{Pattern: "search", MatchType: repository.MatchPrefix, Priority: 50, Action: IntentSearch},
+		{Pattern: "harga", MatchType: repository.MatchPrefix, Priority: 50, Action: IntentSearch},
+		// Only messages shaped like a calculation: "1,5 kg beras", "30 tumbler 30kg"
+		{Pattern: `^\d[\d.,]*\s*(ton|kg|kilo|ons|gr|gram|g|lb|lbs|lusin|dozen|kodi|pcs|box|dus|meter|m|cm)\s+[^\s\d]|^\d[\d.,]*\s+[^\s\d].*\s\d[\d.,]*\s*(kg|kilo|gr|gram|g)$`, MatchType: repository.MatchRegex, Priority: 10, Action: IntentCalculateHint},
+		{Pattern: "kosongkan keranjang", MatchType: repository.MatchExact, Priority: 70, Action: IntentCartClear},
+		{Pattern: "clear cart", MatchType: repository.MatchExact, Priority: 70, Action: IntentCartClear},
+	}
+	for _, cmd := range []string{"tambah", "add"} {
+		rules = append(rules, repository.IntentRule{Pattern: cmd, MatchType: repository.MatchPrefix, Priority: 60, Action: IntentCartAdd})
+	}
+	for _, cmd := range []string{"keranjang", "cart"} {
+		rules = append(rules, repository.IntentRule{Pattern: cmd, MatchType: repository.MatchExact, Priority: 60, Action: IntentCartList})
+	}
+	for _, cmd := range []string{"hapus", "remove"} {
+		rules = append(rules, repository.IntentRule{Pattern: cmd, MatchType: repository.MatchPrefix, Priority: 60, Action: IntentCartRemove})
+	}
+	for _, cmd := range []string{"konfirmasi order", "konfirmasi pesanan", "confirm order", "checkout"} {
+		rules = append(rules, repository.IntentRule{Pattern: cmd, MatchType: repository.MatchExact, Priority: 70, Action: IntentOrderConfirm})
+	}
+	for _, cmd := range []string{"pdf", "penawaran", "quotation"} {
+		rules = append(rules, repository.IntentRule{Pattern: cmd, MatchType: repository.MatchExact, Priority: 70, Action: IntentQuotePDF})
+	}
+	for _, cmd := range []string{"menu", "help", "?", "daftar", "pilihan", "opsi"} {
+		rules = append(rules, repository.IntentRule{Pattern: cmd, MatchType: repository.MatchPrefix, Priority: 90, Action: IntentMenu})
+	}
+	for i := range rules {
+		rules[i].Enabled = true
+	}
+	// Evaluation order, the same the repository lists stored rules in
+	slices.SortStableFunc(rules, func(a, b repository.IntentRule) int { return cmp.Compare(b.Priority, a.Priority) })
+	return rules
+}
+
+// IntentMatch is the rule an incoming message matched
+type IntentMatch struct {
+	Rule     repository.IntentRule `json:"rule"`
+	Argument string                `json:"argument"` // Text after the trigger (e.g. the search query)
+}
+
+// intentRulesTTL bounds how long a tenant's rules are cached, so changes made
+// through another instance show up too
+const intentRulesTTL = time.Minute
+
+// cachedIntentRules is a tenant's rules as Rules returns them
+type cachedIntentRules struct {
+	rules         []repository.IntentRule
+	usingDefaults bool
+	loadedAt      time.Time
+}
+
+// IntentRuleService stores tenant intent rules and matches messages against them.
+// Rules are cached per tenant to avoid a query per message.
+type IntentRuleService struct {
+	repo    *repository.IntentRuleRepository
+	regexes sync.Map // pattern -> *regexp.Regexp
+	cache   map[string]cachedIntentRules
+	mu      sync.Mutex
+}
+
+// NewIntentRuleService creates the intent rule service
+func NewIntentRuleService(repo *repository.IntentRuleRepository) *IntentRuleService {
+	return &IntentRuleService{repo: repo, cache: make(map[string]cachedIntentRules)}
+}
+
+// Rules returns the tenant's rules in evaluation order. Tenants without rules
+// get the defaults (usingDefaults = true).
+func (s *IntentRuleService) Rules(schema string) (rules []repository.IntentRule, usingDefaults bool, err error) {
+	if s.repo == nil {
+		return DefaultIntentRules(), true, nil
+	}
+	s.mu.Lock()
+	cached, ok := s.cache[schema]
+	s.mu.Unlock()
+	if ok && time.Since(cached.loadedAt) < intentRulesTTL {
+		return slices.Clone(cached.rules), cached.usingDefaults, nil
+	}
+
+	rules, err = s.repo.List(schema, false)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(rules) == 0 {
+		rules, usingDefaults = DefaultIntentRules(), true
+	}
+	s.mu.Lock()
+	s.cache[schema] = cachedIntentRules{rules: rules, usingDefaults: usingDefaults, loadedAt: time.Now()}
+	s.mu.Unlock()
+	return slices.Clone(rules), usingDefaults, nil
+}
+
+// forget drops a tenant's cached rules after they change
+func (s *IntentRuleService) forget(schema string) {
+	s.mu.Lock()
+	delete(s.cache, schema)
+	s.mu.Unlock()
+}
+
+// Match returns the first enabled rule that fits the message
+func (s *IntentRuleService) Match(schema, content, language string) (*IntentMatch, error) {
+	rules, _, err := s.Rules(schema)
+	if err != nil {
+		return nil, err
+	}
+	content = strings.ToLower(strings.TrimSpace(content))
+	language = strings.ToLower(language)
+	for _, rule := range rules {
+		if !rule.Enabled || !languageMatches(rule.Language, language) {
+			continue
+		}
+		if arg, ok := s.matchRule(rule, content); ok {
+			return &IntentMatch{Rule: rule, Argument: arg}, nil
+		}
+	}
+	return nil, nil
+}
+
+// matchRule tests one rule against lower-cased content and extracts the argument
+func (s *IntentRuleService) matchRule(rule repository.IntentRule, content string) (string, bool) {
+	pattern := strings.ToLower(rule.Pattern)
+	switch rule.MatchType {
+	case repository.MatchExact:
+		return "", content == pattern
+	case repository.MatchPrefix:
+		// Whole-word prefix: "cari" matches "cari beras" but not "carikan"
+		if content == pattern {
+			return "", true
+		}
+		if strings.HasPrefix(content, pattern+" ") {
+			return strings.TrimSpace(content[len(pattern):]), true
+		}
+	case repository.MatchContains:
+		if i := strings.Index(content, pattern); i >= 0 {
+			return strings.TrimSpace(content[:i] + " " + content[i+len(pattern):]), true
+		}
+	case repository.MatchRegex:
+		re, err := s.compile(rule.Pattern)
+		if err != nil {
+			return "", false
+		}
+		m := re.FindStringSubmatchIndex(content)
+		if m == nil {
+			return "", false
+		}
+		// A group named "q" is the argument; otherwise whatever surrounds the match
+		if i := re.SubexpIndex("q"); i > 0 && m[2*i] >= 0 {
+			return strings.TrimSpace(content[m[2*i]:m[2*i+1]]), true
+		}
+		return strings.TrimSpace(content[:m[0]] + " " + content[m[1]:]), true
+	}
+	return "", false
+}
+
+func (s *IntentRuleService) compile(pattern string) (*regexp.Regexp, error) {
+	if re, ok := s.regexes.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		return nil, err
+	}
+	s.regexes.Store(pattern, re)
+	return re, nil
+}
+
+// languageMatches reports whether a rule for ruleLang applies to a message in
+// msgLang. Rules without a language, and messages of unknown language, always match.
+func languageMatches(ruleLang, msgLang string) bool {
+	if ruleLang == "" || msgLang == "" {
+		return true
+	}
+	return strings.HasPrefix(msgLang, strings.ToLower(ruleLang))
+}
+
+// Get returns one of the tenant's stored rules
+func (s *IntentRuleService) Get(schema string, id int) (*repository.IntentRule, error) {
+	rule, err := s.repo.Get(schema, id)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		return nil, ErrIntentRuleNotFound
+	}
+	return rule, nil
+}
+
+// Create stores a new rule. A tenant still on the defaults gets editable
+// copies of them first, so the new rule extends rather than replaces them.
+func (s *IntentRuleService) Create(schema string, rule *repository.IntentRule) error {
+	if err := s.validate(rule); err != nil {
+		return err
+	}
+	existing, err := s.repo.List(schema, false)
+	if err != nil {
+		return err
+	}
+	if len(existing) == 0 {
+		if err := s.ResetDefaults(schema); err != nil {
+			return err
+		}
+	}
+	defer s.forget(schema)
+	return s.repo.Create(schema, rule)
+}
+
+// Update saves an existing rule
+func (s *IntentRuleService) Update(schema string, rule *repository.IntentRule) error {
+	if err := s.validate(rule); err != nil {
+		return err
+	}
+	defer s.forget(schema)
+	ok, err := s.repo.Update(schema, rule)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrIntentRuleNotFound
+	}
+	return nil
+}
+
+// Delete removes a rule
+func (s *IntentRuleService) Delete(schema string, id int) error {
+	defer s.forget(schema)
+	ok, err := s.repo.Delete(schema, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrIntentRuleNotFound
+	}
+	return nil
+}
+
+// ResetDefaults replaces the tenant's rules with editable copies of the defaults
+func (s *IntentRuleService) ResetDefaults(schema string) error {
+	defer s.forget(schema)
+	return s.repo.ReplaceAll(schema, DefaultIntentRules())
+}
+
+func (s *IntentRuleService) validate(rule *repository.IntentRule) error {
+	rule.Pattern = strings.TrimSpace(rule.Pattern)
+	rule.Language = strings.ToLower(strings.TrimSpace(rule.Language))
+	rule.Payload = strings.TrimSpace(rule.Payload)
+	if rule.Pattern == "" || len(rule.Pattern) > 512 {
+		return fmt.Errorf("%w: pattern must be 1-512 characters", ErrInvalidIntentRule)
+	}
+	if len(rule.Language) > 16 {
+		return fmt.Errorf("%w: language too long", ErrInvalidIntentRule)
+	}
+
+	switch rule.MatchType {
+	case repository.MatchExact, repository.MatchContains, repository.MatchPrefix:
+		rule.Pattern = strings.ToLower(rule.Pattern)
+	case repository.MatchRegex:
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return fmt.Errorf("%w: bad regex: %v", ErrInvalidIntentRule, err)
+		}
+	default:
+		return fmt.Errorf("%w: match_type must be exact, contains, prefix or regex", ErrInvalidIntentRule)
+	}
+
+	needsPayload, ok := intentActions[rule.Action]
+	if !ok {
+		return fmt.Errorf("%w: unknown action '%s'", ErrInvalidIntentRule, rule.Action)
+	}
+	if needsPayload && rule.Payload == "" {
+		return fmt.Errorf("%w: action '%s' needs a payload", ErrInvalidIntentRule, rule.Action)
+	}
+	return nil
+}
+
+// handleIntent runs the tenant's intent rules against a message
+func (s *MessageService) handleIntent(msg entities.Message) (bool, error) {
+	match := s.matchIntent(msg)
+	if match == nil {
+		return false, nil
+	}
+	return s.runIntent(msg, match)
+}
+
+// cartIntents are the commands that also work while a calculation waits for
+// input, so "TAMBAH" or "PDF" is not taken for a product
+var cartIntents = map[string]bool{
+	IntentCartAdd:      true,
+	IntentCartList:     true,
+	IntentCartRemove:   true,
+	IntentCartClear:    true,
+	IntentOrderConfirm: true,
+	IntentQuotePDF:     true,
+}
+
+// handleCartIntent runs the tenant's intent rules for cart and order commands only
+func (s *MessageService) handleCartIntent(msg entities.Message) (bool, error) {
+	match := s.matchIntent(msg)
+	if match == nil || !cartIntents[match.Rule.Action] {
+		return false, nil
+	}
+	return s.runIntent(msg, match)
+}
+
+// matchIntent returns the intent rule a message matches, nil if none
+func (s *MessageService) matchIntent(msg entities.Message) *IntentMatch {
+	match, err := s.Intents.Match(msg.SchemaName, msg.Content, msg.Language)
+	if err != nil {
+		fmt.Printf("Warning: intent rules: %v\n", err)
+		return nil
+	}
+	return match
+}
+
+// runIntent carries out a matched intent rule
+func (s *MessageService) runIntent(msg entities.Message, match *IntentMatch) (bool, error) {
+	rule := match.Rule
+	fmt.Printf("[BOT] Matched intent rule %d: %s '%s' -> %s\n", rule.ID, rule.MatchType, rule.Pattern, rule.Action)
+
+	msg.MenuAction = rule.Action
+	switch rule.Action {
+	case IntentGreeting:
+		return true, s.sendWelcome(msg, msg.SchemaName)
+	case IntentMenu:
+		if _, _, ok := s.loadMenu(msg.SchemaName, rootMenuSlug); ok {
+			return true, s.navigateHome(msg)
+		}
+		return true, s.sendReply(msg, s.getMenuList(msg.SchemaName))
+	case IntentSearch:
+		if match.Argument == "" {
+			return true, s.sendReply(msg, "🔍 Ketik *CARI [nama]* untuk mencari produk.")
+		}
+		return true, s.handleDatasetSearch(msg, msg.SchemaName, match.Argument, 0)
+	case IntentCalculate:
+		if rule.Payload != "" {
+			return true, s.startCalculation(msg, rule.Payload)
+		}
+		return true, s.restartCalculation(msg)
+	case IntentCalculateHint:
+		hint := rule.Payload
+		if hint == "" {
+			hint = "🧮 *Untuk menghitung harga:*\nSilakan pilih produk dari MENU, lalu masukkan jumlah yang diinginkan.\n\nKetik *MENU* untuk melihat pilihan."
+		}
+		return true, s.sendReply(msg, hint)
+	case IntentCartAdd:
+		return true, s.handleCartAdd(msg, match.Argument)
+	case IntentCartList:
+		return true, s.sendCart(msg, "")
+	case IntentCartRemove:
+		return true, s.handleCartRemove(msg, match.Argument)
+	case IntentCartClear:
+		return true, s.handleCartClear(msg)
+	case IntentOrderConfirm:
+		return true, s.handleOrderConfirm(msg)
+	case IntentQuotePDF:
+		return true, s.handleQuotePDF(msg)
+	}
+	return s.dispatchMenuAction(msg, repository.MenuItem{Label: rule.Pattern, Action: rule.Action, Payload: rule.Payload})
+}
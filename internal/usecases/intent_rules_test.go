@@ -0,0 +1,124 @@
+package usecases
+
+import (
+	"project_masAde/internal/repository"
+	"slices"
+	"testing"
+)
+
+func TestMatchRule(t *testing.T) {
+	s := NewIntentRuleService(nil)
+	tests := []struct {
+		pattern   string
+		matchType string
+		content   string // Lower-cased, as Match passes it
+		wantArg   string
+		wantOK    bool
+	}{
+		{"keranjang", repository.MatchExact, "keranjang", "", true},
+		{"Keranjang", repository.MatchExact, "keranjang", "", true},
+		{"keranjang", repository.MatchExact, "keranjang saya", "", false},
+		{"cari", repository.MatchPrefix, "cari beras merah", "beras merah", true},
+		{"cari", repository.MatchPrefix, "cari", "", true},
+		{"cari", repository.MatchPrefix, "carikan beras", "", false},
+		{"cari", repository.MatchPrefix, "mau cari beras", "", false},
+		{"calculate", repository.MatchContains, "please calculate", "please", true},
+		{"calculate", repository.MatchContains, "calculate 10 kg beras", "10 kg beras", true},
+		{"calculate", repository.MatchContains, "calculate", "", true},
+		{"calculate", repository.MatchContains, "hitung", "", false},
+		{`^harga (?P<q>.+)$`, repository.MatchRegex, "harga beras merah", "beras merah", true},
+		{`\bpromo\b`, repository.MatchRegex, "promo hari ini", "hari ini", true},
+		{`PROMO`, repository.MatchRegex, "promo", "", true},
+		{`\bpromo\b`, repository.MatchRegex, "promosi", "", false},
+		{`(`, repository.MatchRegex, "(", "", false},
+		{"cari", "unknown", "cari beras", "", false},
+	}
+	for _, tt := range tests {
+		rule := repository.IntentRule{Pattern: tt.pattern, MatchType: tt.matchType, Enabled: true}
+		arg, ok := s.matchRule(rule, tt.content)
+		if ok != tt.wantOK || arg != tt.wantArg {
+			t.Errorf("matchRule(%s %q, %q) = %q, %v; want %q, %v", tt.matchType, tt.pattern, tt.content, arg, ok, tt.wantArg, tt.wantOK)
+		}
+	}
+}
+
+func TestDefaultIntentRules(t *testing.T) {
+	s := NewIntentRuleService(nil)
+	rules := DefaultIntentRules()
+	if !slices.IsSortedFunc(rules, func(a, b repository.IntentRule) int { return b.Priority - a.Priority }) {
+		t.Errorf("DefaultIntentRules are not sorted by priority, highest first")
+	}
+	for _, rule := range rules {
+		if !rule.Enabled {
+			t.Errorf("default rule %q is disabled", rule.Pattern)
+		}
+		r := rule
+		if err := s.validate(&r); err != nil {
+			t.Errorf("default rule %q: %v", rule.Pattern, err)
+		}
+	}
+}
+
+func TestMatchDefaults(t *testing.T) {
+	s := NewIntentRuleService(nil)
+	tests := []struct {
+		content    string
+		wantAction string // "" = no match
+		wantArg    string
+	}{
+		{"Halo", IntentGreeting, ""},
+		{"selamat pagi", IntentGreeting, ""},
+		// Higher priority wins when several rules match
+		{"hai, cari beras", IntentGreeting, ", cari beras"},
+		{"menu", IntentMenu, ""},
+		{"help calculate", IntentMenu, "calculate"},
+		{"calculate 10 kg beras", IntentCalculate, "10 kg beras"},
+		{"checkout", IntentOrderConfirm, ""},
+		{"kosongkan keranjang", IntentCartClear, ""},
+		{"keranjang", IntentCartList, ""},
+		{"tambah 2 kg beras", IntentCartAdd, "2 kg beras"},
+		{"hapus 2", IntentCartRemove, "2"},
+		{"pdf", IntentQuotePDF, ""},
+		{"  Cari Beras Merah ", IntentSearch, "beras merah"},
+		{"harga gula", IntentSearch, "gula"},
+		{"1,5 kg beras", IntentCalculateHint, ""},
+		{"30 tumbler 30kg", IntentCalculateHint, ""},
+		{"terima kasih", "", ""},
+		{"carikan beras", "", ""},
+	}
+	for _, tt := range tests {
+		m, err := s.Match("public", tt.content, "")
+		if err != nil {
+			t.Fatalf("Match(%q): %v", tt.content, err)
+		}
+		var action, arg string
+		if m != nil {
+			action, arg = m.Rule.Action, m.Argument
+		}
+		if action == IntentCalculateHint {
+			arg = "" // The hint ignores its argument
+		}
+		if action != tt.wantAction || arg != tt.wantArg {
+			t.Errorf("Match(%q) = %q, %q; want %q, %q", tt.content, action, arg, tt.wantAction, tt.wantArg)
+		}
+	}
+}
+
+func TestLanguageMatches(t *testing.T) {
+	tests := []struct {
+		ruleLang, msgLang string
+		want              bool
+	}{
+		{"", "id", true},
+		{"id", "", true},
+		{"id", "id", true},
+		{"en", "en-us", true},
+		{"EN", "en", true},
+		{"en", "id", false},
+	}
+	for _, tt := range tests {
+		if got := languageMatches(tt.ruleLang, tt.msgLang); got != tt.want {
+			t.Errorf("languageMatches(%q, %q) = %v; want %v", tt.ruleLang, tt.msgLang, got, tt.want)
+		}
+	}
+}
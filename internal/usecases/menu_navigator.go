@@ -0,0 +1,222 @@
+package usecases
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"project_masAde/internal/entities"
+	"project_masAde/internal/infrastructure"
+	"project_masAde/internal/repository"
+	"strconv"
+	"strings"
+)
+
+// rootMenuSlug is the menu shown on /start, greetings and "home"
+const rootMenuSlug = "main_menu"
+
+// ErrInvalidMenu is returned when a menu save would leave the menu graph broken
+var ErrInvalidMenu = errors.New("invalid menu")
+
+// showMenu renders a menu and records it as the chat's current position.
+// path is the stack of parent menu slugs used by "back".
+func (s *MessageService) showMenu(msg entities.Message, slug string, path []string) error {
+	return s.showMenuWithHeader(msg, slug, path, "")
+}
+
+// showMenuWithHeader is showMenu with extra text (e.g. a welcome message) above the menu
+func (s *MessageService) showMenuWithHeader(msg entities.Message, slug string, path []string, header string) error {
+	items, title, ok := s.loadMenu(msg.SchemaName, slug)
+	if !ok {
+		if slug == rootMenuSlug {
+			return s.sendReplyWithKeyboard(msg, "👋 Choose a category:", infrastructure.CreateCategoryKeyboard())
+		}
+		return s.sendReply(msg, fmt.Sprintf("❌ Menu '%s' tidak ditemukan.", slug))
+	}
+
+	if s.Conversations != nil {
+		err := s.Conversations.Transition(conversationKeyFor(msg), StateBrowsingMenu, map[string]string{
+			"menu": slug,
+			"path": strings.Join(path, ","),
+		})
+		if err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+	}
+
+	if header != "" {
+		header += "\n\n"
+	}
+	nested := len(path) > 0
+	if msg.Platform == "telegram" {
+		return s.sendReplyWithKeyboard(msg, header+title+"\n\nChoose an option:", infrastructure.GenerateMenuKeyboard(items, nested))
+	}
+	return s.sendReply(msg, header+FormatNumberedMenu(title, items, nested))
+}
+
+// currentMenu returns the menu the chat is browsing and its parent path
+func (s *MessageService) currentMenu(msg entities.Message) (string, []string) {
+	if s.Conversations == nil {
+		return rootMenuSlug, nil
+	}
+	state := s.Conversations.Get(conversationKeyFor(msg))
+	if state.State != StateBrowsingMenu || state.Data["menu"] == "" {
+		return rootMenuSlug, nil
+	}
+	var path []string
+	if p := state.Data["path"]; p != "" {
+		path = strings.Split(p, ",")
+	}
+	return state.Data["menu"], path
+}
+
+// openSubmenu descends into a submenu, remembering where we came from
+func (s *MessageService) openSubmenu(msg entities.Message, slug string) error {
+	current, path := s.currentMenu(msg)
+	if current == slug {
+		return s.showMenu(msg, slug, path)
+	}
+	return s.showMenu(msg, slug, append(path, current))
+}
+
+// navigateBack returns to the parent menu (or the root menu at the top)
+func (s *MessageService) navigateBack(msg entities.Message) error {
+	_, path := s.currentMenu(msg)
+	if len(path) == 0 {
+		return s.showMenu(msg, rootMenuSlug, nil)
+	}
+	return s.showMenu(msg, path[len(path)-1], path[:len(path)-1])
+}
+
+// navigateHome returns to the root menu
+func (s *MessageService) navigateHome(msg entities.Message) error {
+	return s.showMenu(msg, rootMenuSlug, nil)
+}
+
+// selectNumberedItem handles a numeric reply ("2") to the numbered menu list last
+// shown in this chat, running the item's action through the shared dispatcher.
+// "0" goes back. Telegram uses buttons instead, so it is excluded.
+func (s *MessageService) selectNumberedItem(msg entities.Message, content string) (bool, error) {
+	if msg.Platform == "telegram" || s.Conversations == nil {
+		return false, nil
+	}
+	choice, err := strconv.Atoi(strings.TrimSuffix(content, "."))
+	if err != nil {
+		return false, nil
+	}
+	state := s.Conversations.Get(conversationKeyFor(msg))
+	if state.State != StateBrowsingMenu {
+		return false, nil
+	}
+
+	if choice == 0 {
+		return true, s.navigateBack(msg)
+	}
+
+	items, _, ok := s.loadMenu(msg.SchemaName, state.Data["menu"])
+	if !ok {
+		return false, nil
+	}
+	if choice < 1 || choice > len(items) {
+		return true, s.sendReply(msg, fmt.Sprintf("❌ Pilihan tidak tersedia. Balas dengan nomor 1-%d.", len(items)))
+	}
+
+	item := items[choice-1]
+	fmt.Printf("[BOT] Numbered selection %d: %s (%s)\n", choice, item.Label, item.Action)
+	handled, err := s.dispatchMenuAction(msg, item)
+	if !handled && err == nil {
+		return true, s.sendReply(msg, fmt.Sprintf("⚠️ Pilihan *%s* belum dapat diproses.", item.Label))
+	}
+	return true, err
+}
+
+// isBackCommand checks for typed "back" navigation
+func isBackCommand(content string) bool {
+	return content == "back" || content == "kembali"
+}
+
+// isHomeCommand checks for typed "home" navigation
+func isHomeCommand(content string) bool {
+	return content == "home" || content == "menu utama"
+}
+
+// FormatNumberedMenu renders menu items as a numbered text list for platforms without buttons
+func FormatNumberedMenu(title string, items []repository.MenuItem, nested bool) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📋 *%s*\n\n", title))
+	for i, item := range items {
+		sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, item.Label))
+	}
+	if nested {
+		sb.WriteString("0. ⬅️ Kembali\n")
+	}
+	sb.WriteString("\n_Balas dengan nomor untuk memilih_")
+	if nested {
+		sb.WriteString("\n_Ketik *HOME* untuk ke menu utama_")
+	}
+	return sb.String()
+}
+
+// ValidateMenuGraph checks that every submenu item points at an existing menu
+// and that no menu can reach itself through submenus.
+func ValidateMenuGraph(menus []repository.Menu) error {
+	edges := make(map[string][]string, len(menus))
+	for _, m := range menus {
+		var items []repository.MenuItem
+		if len(m.Items) > 0 {
+			if err := json.Unmarshal(m.Items, &items); err != nil {
+				return fmt.Errorf("%w: menu '%s' has invalid items: %v", ErrInvalidMenu, m.Slug, err)
+			}
+		}
+		edges[m.Slug] = nil
+		for _, item := range items {
+			if item.Action != repository.MenuActionSubmenu {
+				continue
+			}
+			if item.Payload == "" {
+				return fmt.Errorf("%w: submenu item '%s' in '%s' has no target", ErrInvalidMenu, item.Label, m.Slug)
+			}
+			edges[m.Slug] = append(edges[m.Slug], item.Payload)
+		}
+	}
+
+	for slug, targets := range edges {
+		for _, target := range targets {
+			if _, ok := edges[target]; !ok {
+				return fmt.Errorf("%w: menu '%s' links to unknown menu '%s'", ErrInvalidMenu, slug, target)
+			}
+		}
+	}
+
+	// Depth-first search for cycles (white/grey/black colouring)
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	color := make(map[string]int, len(edges))
+	var visit func(slug string, trail []string) error
+	visit = func(slug string, trail []string) error {
+		color[slug] = visiting
+		trail = append(trail, slug)
+		for _, target := range edges[slug] {
+			switch color[target] {
+			case visiting:
+				return fmt.Errorf("%w: submenu cycle %s -> %s", ErrInvalidMenu, strings.Join(trail, " -> "), target)
+			case unvisited:
+				if err := visit(target, trail); err != nil {
+					return err
+				}
+			}
+		}
+		color[slug] = done
+		return nil
+	}
+	for slug := range edges {
+		if color[slug] == unvisited {
+			if err := visit(slug, nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package usecases
+
+import (
+	"errors"
+	"project_masAde/internal/repository"
+	"strings"
+	"testing"
+)
+
+// testMenu builds a menu whose items are "label:action:payload" triples
+func testMenu(slug string, items ...string) repository.Menu {
+	var parts []string
+	for _, item := range items {
+		f := strings.SplitN(item, ":", 3)
+		parts = append(parts, `{"label":"`+f[0]+`","action":"`+f[1]+`","payload":"`+f[2]+`"}`)
+	}
+	return repository.Menu{Slug: slug, Items: []byte("[" + strings.Join(parts, ",") + "]")}
+}
+
+func TestValidateMenuGraph(t *testing.T) {
+	tests := []struct {
+		name    string
+		menus   []repository.Menu
+		wantErr string // "" = valid
+	}{
+		{"empty", nil, ""},
+		{"no submenus", []repository.Menu{testMenu("main_menu", "Harga:view_table:products", "Halo:reply:hi")}, ""},
+		{"tree", []repository.Menu{
+			testMenu("main_menu", "Produk:submenu:products", "Info:submenu:info"),
+			testMenu("products", "Kertas:submenu:paper", "Kembali:back:"),
+			testMenu("paper", "Hitung:calculate_from_table:paper", "Menu:home:"),
+			testMenu("info"),
+		}, ""},
+		{"shared submenu", []repository.Menu{
+			testMenu("main_menu", "A:submenu:a", "B:submenu:b"),
+			testMenu("a", "Bantuan:submenu:help"),
+			testMenu("b", "Bantuan:submenu:help"),
+			testMenu("help"),
+		}, ""},
+		{"back and home are not edges", []repository.Menu{
+			testMenu("main_menu", "Produk:submenu:products"),
+			testMenu("products", "Kembali:back:main_menu", "Menu:home:main_menu"),
+		}, ""},
+		{"dangling slug", []repository.Menu{
+			testMenu("main_menu", "Produk:submenu:products"),
+		}, "links to unknown menu 'products'"},
+		{"dangling slug deep", []repository.Menu{
+			testMenu("main_menu", "Produk:submenu:products"),
+			testMenu("products", "Kertas:submenu:paper"),
+		}, "menu 'products' links to unknown menu 'paper'"},
+		{"missing target", []repository.Menu{
+			testMenu("main_menu", "Produk:submenu:"),
+		}, "has no target"},
+		{"self loop", []repository.Menu{
+			testMenu("main_menu", "Lagi:submenu:main_menu"),
+		}, "submenu cycle main_menu -> main_menu"},
+		{"two menu cycle", []repository.Menu{
+			testMenu("a", "B:submenu:b"),
+			testMenu("b", "A:submenu:a"),
+		}, "submenu cycle"},
+		{"cycle below the root", []repository.Menu{
+			testMenu("main_menu", "Produk:submenu:products"),
+			testMenu("products", "Kertas:submenu:paper"),
+			testMenu("paper", "Produk:submenu:products"),
+		}, "submenu cycle"},
+		{"invalid items", []repository.Menu{{Slug: "main_menu", Items: []byte(`{"label":`)}}, "has invalid items"},
+	}
+	for _, tt := range tests {
+		err := ValidateMenuGraph(tt.menus)
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s: ValidateMenuGraph = %v; want nil", tt.name, err)
+		case tt.wantErr != "" && (!errors.Is(err, ErrInvalidMenu) || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s: ValidateMenuGraph = %v; want ErrInvalidMenu containing %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -5,34 +5,53 @@ import (
 	"fmt"
 	"project_masAde/internal/entities"
 	"project_masAde/internal/infrastructure"
-	"project_masAde/internal/interfaces"
 	"project_masAde/internal/repository"
+	"strconv"
 	"strings"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
+// maxSearchResults is the page size of a CARI search reply
+const maxSearchResults = 5
+
+// defaultCalcTable is the dataset used when a calculation is started without a menu item
+const defaultCalcTable = "products"
+
 // MessageService handles incoming messages with rule-based responses
 // AI functionality moved to separate microservice
 type MessageService struct {
-	messengerClient interfaces.Messenger
-	TelegramClient  *infrastructure.TelegramClient
-	WhatsAppClient  *infrastructure.WhatsAppClient
-	ConfigRepo      *repository.ConfigRepository
-	TableManager    *repository.TableManager
+	Router        *ChannelRouter
+	ConfigRepo    *repository.ConfigRepository
+	TableManager  *repository.TableManager
+	Calculator    *DynamicCalculator
+	Conversations *ConversationManager
+	Contacts      *repository.ContactRepository // Optional: broadcast opt-outs
+	Intents       *IntentRuleService            // Keyword rules; built-in defaults until a repository is set
+	Cart          *CartService                  // Optional: per-chat carts (TAMBAH, KERANJANG, HAPUS)
+	Orders        *OrderService                 // Optional: KONFIRMASI ORDER turns a quote into an order
+	Documents     *DocumentService              // PDF quotations and invoices
 }
 
 // NewMessageService creates a new rule-based message service
-func NewMessageService(messenger interfaces.Messenger, configRepo *repository.ConfigRepository, tableManager *repository.TableManager) *MessageService {
+func NewMessageService(router *ChannelRouter, configRepo *repository.ConfigRepository, tableManager *repository.TableManager, conversations *ConversationManager) *MessageService {
+	calculator := NewDynamicCalculator(tableManager)
+	calculator.Conversations = conversations
 	return &MessageService{
-		messengerClient: messenger,
-		ConfigRepo:      configRepo,
-		TableManager:    tableManager,
+		Router:        router,
+		ConfigRepo:    configRepo,
+		TableManager:  tableManager,
+		Calculator:    calculator,
+		Conversations: conversations,
+		Intents:       NewIntentRuleService(nil),
+		Documents:     NewDocumentService(configRepo),
 	}
 }
 
 // ProcessMessage handles incoming messages with priority-based rule system
-// Priority: 1. Greeting → 2. MENU → 3. Menu Selection → 4. Search → 5. Default
+// Priority: 0. Button callback / numbered menu reply → 1. Pending calculation →
+// 2. Menu item label → 3. Tenant intent rules (greeting, MENU, search, ...) → 4. Default
+// Chats handed over to a human agent are skipped entirely.
 func (s *MessageService) ProcessMessage(msg entities.Message) error {
 	content := strings.TrimSpace(msg.Content)
 	contentLower := strings.ToLower(content)
@@ -40,64 +59,83 @@ func (s *MessageService) ProcessMessage(msg entities.Message) error {
 	if schema == "" {
 		schema = "public"
 	}
+	msg.SchemaName = schema
 
-	// DEBUG: Log what we received
-	fmt.Printf("[BOT] Received: '%s' from %s, schema: %s\n", content, msg.From, schema)
-
-	// 1. GREETING DETECTION
-	if s.isGreeting(contentLower) {
-		fmt.Printf("[BOT] Matched: GREETING\n")
-		return s.sendReply(msg, s.getWelcomeMessage(schema))
+	// Broadcast opt-out/opt-in keywords
+	if handled, err := s.handleSubscriptionCommand(msg, contentLower); handled {
+		return err
 	}
 
-	// 2. MENU COMMAND - Show all available menus
-	if s.isMenuCommand(contentLower) {
-		fmt.Printf("[BOT] Matched: MENU command\n")
-		return s.sendReply(msg, s.getMenuList(schema))
+	// Chats handed over to a human agent get no automatic replies
+	if s.humanHandling(msg) {
+		fmt.Printf("[BOT] Chat %s is with a human agent, not replying\n", msg.From)
+		return nil
 	}
 
-	// 3. DYNAMIC MENU HANDLING (exact match and keywords)
-	if handled, err := s.handleDynamicMenu(msg); err != nil {
-		fmt.Printf("Menu handling error: %v\n", err)
-	} else if handled {
-		return nil
+	// 0. BUTTON CALLBACKS (Telegram inline keyboards)
+	if msg.IsCallback {
+		return s.handleCallback(msg)
 	}
 
-	// 4. DATASET SEARCH - "cari X", "search X", "harga X"
-	if strings.HasPrefix(contentLower, "cari ") || strings.HasPrefix(contentLower, "search ") || strings.HasPrefix(contentLower, "harga ") {
-		query := strings.TrimPrefix(strings.TrimPrefix(strings.TrimPrefix(contentLower, "cari "), "search "), "harga ")
-		return s.handleDatasetSearch(msg, schema, query)
+	// Menu navigation always works, even mid-flow
+	if isBackCommand(contentLower) {
+		return s.navigateBack(msg)
+	}
+	if isHomeCommand(contentLower) {
+		return s.navigateHome(msg)
 	}
 
-	// 5. CALCULATION hint (has weight like "2kg", "500g")
-	if s.hasWeightPattern(contentLower) {
-		return s.sendReply(msg, "🧮 *Untuk menghitung harga:*\nSilakan pilih produk dari MENU, lalu masukkan jumlah yang diinginkan.\n\nKetik *MENU* untuk melihat pilihan.")
+	// Order lookups (STATUS [number], MY ORDERS)
+	if handled, err := s.handleOrderCommand(msg, contentLower); handled {
+		return err
 	}
 
-	// 6. DEFAULT FALLBACK
-	return s.sendReply(msg, s.getDefaultResponse())
-}
+	// The last quote in another currency (IN IDR, DALAM USD)
+	if handled, err := s.handleCurrencyCommand(msg, contentLower); handled {
+		return err
+	}
 
-// isGreeting checks if message is a greeting
-func (s *MessageService) isGreeting(content string) bool {
-	greetings := []string{"halo", "hai", "hello", "hi", "selamat pagi", "selamat siang", "selamat sore", "selamat malam", "assalamualaikum", "asslmkm", "start", "/start"}
-	for _, g := range greetings {
-		if strings.Contains(content, g) {
-			return true
+	// Next page of the last search
+	if isNextCommand(contentLower) {
+		if handled, err := s.continueSearch(msg); handled {
+			return err
 		}
 	}
-	return false
-}
 
-// isMenuCommand checks if message is a menu request
-func (s *MessageService) isMenuCommand(content string) bool {
-	menuCommands := []string{"menu", "help", "?", "daftar", "pilihan", "opsi"}
-	for _, cmd := range menuCommands {
-		if content == cmd || strings.HasPrefix(content, cmd+" ") {
-			return true
-		}
+	// Numbered reply to the last menu list shown in this chat (WhatsApp/web)
+	if handled, err := s.selectNumberedItem(msg, contentLower); handled {
+		return err
+	}
+
+	// Cart and order commands (TAMBAH, PDF, KONFIRMASI ORDER), even mid-calculation
+	if handled, err := s.handleCartIntent(msg); handled {
+		return err
+	}
+
+	// 1. PENDING CALCULATION - user was asked for "qty product [weight]"
+	if result, ok := s.Calculator.ContinueCalculation(conversationKeyFor(msg), content); ok {
+		return s.sendCalculationResult(msg, result)
+	}
+
+	// "1" right after a result ("Reply with 1 to calculate again")
+	if _, hasResult := s.Calculator.LastCalculationTable(conversationKeyFor(msg)); contentLower == "1" && hasResult {
+		return s.restartCalculation(msg)
+	}
+
+	// 2. DYNAMIC MENU HANDLING (label of the menu being browsed)
+	if handled, err := s.handleDynamicMenu(msg); err != nil {
+		fmt.Printf("Menu handling error: %v\n", err)
+	} else if handled {
+		return nil
+	}
+
+	// 3. INTENT RULES - tenant keywords/regexes (greeting, MENU, CARI, ...)
+	if handled, err := s.handleIntent(msg); handled {
+		return err
 	}
-	return false
+
+	// 4. DEFAULT FALLBACK
+	return s.sendReplyWithKeyboard(msg, s.getDefaultResponse(), infrastructure.CreateSearchMenu())
 }
 
 // getWelcomeMessage returns configured or default welcome message
@@ -141,71 +179,70 @@ func (s *MessageService) getMenuList(schema string) string {
 	return sb.String()
 }
 
-// handleDatasetSearch searches all tables for matching data
-func (s *MessageService) handleDatasetSearch(msg entities.Message, schema, query string) error {
+// handleDatasetSearch replies with a page of ranked search hits across the
+// tenant's datasets. When more pages exist the chat remembers the query so
+// "lagi" (or the Telegram button) continues from offset.
+func (s *MessageService) handleDatasetSearch(msg entities.Message, schema, query string, offset int) error {
 	if s.TableManager == nil {
 		return s.sendReply(msg, "Fitur pencarian tidak tersedia.")
 	}
 
-	// Get all tables
-	tables, err := s.TableManager.ListTables(schema)
-	if err != nil || len(tables) == 0 {
-		return s.sendReply(msg, "Tidak ada dataset untuk dicari.")
+	result, err := s.TableManager.Search(schema, query, maxSearchResults, offset)
+	if err != nil {
+		fmt.Printf("Warning: search '%s' failed: %v\n", query, err)
+		return s.sendReply(msg, "❌ Pencarian gagal. Silakan coba lagi nanti.")
+	}
+	if len(result.Hits) == 0 {
+		if offset > 0 {
+			return s.sendReply(msg, fmt.Sprintf("Tidak ada hasil lain untuk \"%s\".", query))
+		}
+		return s.sendReply(msg, fmt.Sprintf("❌ Tidak ditemukan hasil untuk \"%s\".\n\nKetik *MENU* untuk melihat pilihan.", query))
 	}
 
 	var results strings.Builder
-	results.WriteString(fmt.Sprintf("🔍 *Hasil pencarian \"%s\":*\n\n", query))
-	totalFound := 0
+	results.WriteString(fmt.Sprintf("🔍 *Hasil pencarian \"%s\"* (%d-%d dari %d):\n\n", query, offset+1, offset+len(result.Hits), result.Total))
+	for i, hit := range result.Hits {
+		results.WriteString(fmt.Sprintf("%d. 📦 %s: %s\n", offset+i+1, hit.DisplayName,
+			formatDatasetRow(hit.Row, result.Columns[hit.TableName], result.Roles[hit.TableName])))
+	}
 
-	for _, table := range tables {
-		data, err := s.TableManager.GetTableData(schema, table.TableName)
-		if err != nil {
-			continue
+	next := offset + len(result.Hits)
+	if next >= result.Total {
+		if s.Conversations != nil && s.Conversations.Get(conversationKeyFor(msg)).State == StateSearchResults {
+			s.Conversations.Reset(conversationKeyFor(msg))
 		}
+		return s.sendReply(msg, results.String())
+	}
 
-		// Search in each row
-		for _, row := range data {
-			for _, value := range row {
-				if strings.Contains(strings.ToLower(fmt.Sprintf("%v", value)), query) {
-					totalFound++
-					results.WriteString(fmt.Sprintf("📦 *%s*: ", table.DisplayName))
-					for k, v := range row {
-						if k != "id" {
-							results.WriteString(fmt.Sprintf("%s=%v ", k, v))
-						}
-					}
-					results.WriteString("\n")
-					break // Only show row once
-				}
-			}
-			if totalFound >= 5 { // Limit results
-				break
-			}
-		}
-		if totalFound >= 5 {
-			break
+	if s.Conversations != nil {
+		err := s.Conversations.Transition(conversationKeyFor(msg), StateSearchResults, map[string]string{
+			"query":  query,
+			"offset": strconv.Itoa(next),
+		})
+		if err != nil {
+			fmt.Printf("Warning: %v\n", err)
 		}
 	}
+	results.WriteString("\n_Ketik *LAGI* untuk hasil berikutnya._")
+	return s.sendReplyWithKeyboard(msg, results.String(), infrastructure.CreateSearchPager())
+}
 
-	if totalFound == 0 {
-		return s.sendReply(msg, fmt.Sprintf("❌ Tidak ditemukan hasil untuk \"%s\".\n\nKetik *MENU* untuk melihat pilihan.", query))
+// continueSearch shows the next page of the chat's last search, if it has one
+func (s *MessageService) continueSearch(msg entities.Message) (bool, error) {
+	if s.Conversations == nil {
+		return false, nil
 	}
-
-	return s.sendReply(msg, results.String())
+	state := s.Conversations.Get(conversationKeyFor(msg))
+	if state.State != StateSearchResults {
+		return false, nil
+	}
+	offset, _ := strconv.Atoi(state.Data["offset"])
+	return true, s.handleDatasetSearch(msg, msg.SchemaName, state.Data["query"], offset)
 }
 
-// hasWeightPattern checks if message contains weight pattern like "2kg" or "500g"
-func (s *MessageService) hasWeightPattern(content string) bool {
-	for i, c := range content {
-		if c >= '0' && c <= '9' {
-			rest := content[i:]
-			if strings.Contains(rest, "kg") || strings.Contains(rest, " kg") ||
-				strings.Contains(rest, "g ") || strings.HasSuffix(rest, "g") {
-				return true
-			}
-		}
-	}
-	return false
+// isNextCommand checks for a typed request for the next page of results
+func isNextCommand(content string) bool {
+	return content == "lagi" || content == "next" || content == "berikutnya"
 }
 
 // getDefaultResponse returns default fallback message
@@ -217,18 +254,20 @@ func (s *MessageService) getDefaultResponse() string {
 		"• Atau pilih dari menu yang tersedia"
 }
 
-// sendReply sends message back to user based on platform
+// sendReply sends message back to user through the channel it came from
 func (s *MessageService) sendReply(msg entities.Message, text string) error {
-	if s.WhatsAppClient != nil && msg.Platform == "whatsapp" {
-		return s.WhatsAppClient.SendMessage(msg.From, text)
+	if s.Router == nil {
+		return fmt.Errorf("no messaging client available")
 	}
-	if s.TelegramClient != nil && msg.Platform == "telegram" {
-		return s.TelegramClient.SendMessage(msg.From, text)
-	}
-	if s.messengerClient != nil {
-		return s.messengerClient.SendMessage(msg.From, text)
+	return s.Router.Send(msg, text)
+}
+
+// sendReplyWithKeyboard sends a reply with inline buttons (text only on WhatsApp/web)
+func (s *MessageService) sendReplyWithKeyboard(msg entities.Message, text string, keyboard tgbotapi.InlineKeyboardMarkup) error {
+	if s.Router == nil {
+		return fmt.Errorf("no messaging client available")
 	}
-	return fmt.Errorf("no messaging client available")
+	return s.Router.SendWithKeyboard(msg, text, keyboard)
 }
 
 func (s *MessageService) handleDynamicMenu(msg entities.Message) (bool, error) {
@@ -241,33 +280,52 @@ func (s *MessageService) handleDynamicMenu(msg entities.Message) (bool, error) {
 		schema = "public"
 	}
 
-	// Fetch 'main_menu'
-	menu, err := s.ConfigRepo.GetMenu(schema, "main_menu")
+	// Match against the menu the chat is currently browsing (main_menu by default)
+	slug, _ := s.currentMenu(msg)
+	menu, err := s.ConfigRepo.GetMenu(schema, slug)
 	if err != nil {
 		return false, nil
 	}
 
 	// Parse Items
-	itemsBytes, _ := json.Marshal(menu.Items)
 	var items []repository.MenuItem
-	if err := json.Unmarshal(itemsBytes, &items); err != nil {
+	if err := json.Unmarshal(menu.Items, &items); err != nil {
 		return false, fmt.Errorf("invalid menu items json: %w", err)
 	}
 
+	content := strings.TrimSpace(msg.Content)
 	for _, item := range items {
-		if item.Label == msg.Content {
-			switch item.Action {
-			case "view_table":
-				return s.handleViewTable(msg, item.Payload)
-			case "reply":
-				return true, s.sendReply(msg, item.Payload)
-			}
+		if strings.EqualFold(item.Label, content) {
+			return s.dispatchMenuAction(msg, item)
 		}
 	}
 
 	return false, nil
 }
 
+// dispatchMenuAction runs a menu item's action. Shared by text label matches
+// and Telegram "dyn:" button callbacks.
+func (s *MessageService) dispatchMenuAction(msg entities.Message, item repository.MenuItem) (bool, error) {
+	msg.MenuAction = item.Action
+	switch item.Action {
+	case repository.MenuActionViewTable:
+		return s.handleViewTable(msg, item.Payload)
+	case repository.MenuActionReply:
+		return true, s.sendReply(msg, item.Payload)
+	case repository.MenuActionCalculate:
+		return true, s.startCalculation(msg, item.Payload)
+	case repository.MenuActionSubmenu:
+		return true, s.openSubmenu(msg, item.Payload)
+	case repository.MenuActionBack:
+		return true, s.navigateBack(msg)
+	case repository.MenuActionHandoff:
+		return true, s.requestHandoff(msg)
+	case repository.MenuActionHome:
+		return true, s.navigateHome(msg)
+	}
+	return false, nil
+}
+
 func (s *MessageService) handleViewTable(msg entities.Message, tableName string) (bool, error) {
 	if s.TableManager == nil {
 		return false, fmt.Errorf("table manager not initialized")
@@ -278,38 +336,31 @@ func (s *MessageService) handleViewTable(msg entities.Message, tableName string)
 		schema = "public"
 	}
 	
-	data, err := s.TableManager.GetTableData(schema, tableName)
+	// Limit to 10 rows
+	page, err := s.TableManager.QueryTable(schema, tableName, repository.TableQuery{Limit: 10})
 	if err != nil {
 		return true, s.sendReply(msg, fmt.Sprintf("Error fetching table '%s': %v", tableName, err))
 	}
 
-	if len(data) == 0 {
+	if len(page.Rows) == 0 {
 		return true, s.sendReply(msg, fmt.Sprintf("Table '%s' is empty.", tableName))
 	}
 
+	roles, err := s.TableManager.Roles(schema, tableName)
+	if err != nil {
+		fmt.Printf("Warning: failed to load column roles of %s: %v\n", tableName, err)
+	}
+
 	// Format as simple list
 	var sb string
 	sb = fmt.Sprintf("*%s Data:*\n\n", tableName)
-	
-	// Limit to 10 rows
-	limit := 10
-	if len(data) < limit {
-		limit = len(data)
-	}
 
-	for i := 0; i < limit; i++ {
-		row := data[i]
-		sb += "- "
-		for k, v := range row {
-			if k != "id" {
-				sb += fmt.Sprintf("%s: %v, ", k, v)
-			}
-		}
-		sb += "\n"
+	for _, row := range page.Rows {
+		sb += "- " + formatDatasetRow(row, page.Columns, roles) + "\n"
 	}
 	
-	if len(data) > limit {
-		sb += fmt.Sprintf("\n...and %d more rows.", len(data)-limit)
+	if page.Total > len(page.Rows) {
+		sb += fmt.Sprintf("\n...and %d more rows.", page.Total-len(page.Rows))
 	}
 
 	return true, s.sendReply(msg, sb)
@@ -321,23 +372,134 @@ func (s *MessageService) ProcessMessageWithContext(msg entities.Message) error {
 	// Without AI, just show a helpful message
 	response := "📦 *Data tersedia*\n\nKetik *MENU* untuk melihat pilihan.\nKetik *CARI [nama]* untuk mencari produk."
 	
-	// WhatsApp Specific Logic
-	if msg.Platform == "whatsapp" && s.WhatsAppClient != nil {
-		menuText := "\n\n1. 🧮 Hitung Harga\n2. 📋 Lihat Menu\n3. 🔍 Cari Produk\n\n_Balas dengan nomor untuk memilih_"
-		return s.WhatsAppClient.SendMessage(msg.From, response+menuText)
-	}
-
-	// Telegram Specific Logic
-	if s.TelegramClient != nil {
-		keyboard := tgbotapi.NewInlineKeyboardMarkup(
-			tgbotapi.NewInlineKeyboardRow(
-				tgbotapi.NewInlineKeyboardButtonData("📋 Menu", "action_menu"),
-				tgbotapi.NewInlineKeyboardButtonData("🔍 Cari", "action_search"),
-			),
-		)
-		return s.TelegramClient.SendMessageWithMenu(msg.From, response, keyboard)
-	}
-
-	// Fallback
-	return s.sendReply(msg, response)
-}
\ No newline at end of file
+	// WhatsApp Specific Logic: show the tenant's main menu as a numbered list
+	if msg.Platform == "whatsapp" {
+		if _, _, ok := s.loadMenu(msg.SchemaName, rootMenuSlug); ok {
+			return s.showMenuWithHeader(msg, rootMenuSlug, nil, response)
+		}
+		return s.sendReply(msg, response)
+	}
+
+	// Telegram gets Menu/Search buttons; other platforms receive the text only
+	return s.sendReplyWithKeyboard(msg, response, infrastructure.CreateSearchMenu())
+}
+
+// handleCallback processes Telegram inline button presses
+func (s *MessageService) handleCallback(msg entities.Message) error {
+	data := msg.Content
+
+	// Dynamic menu buttons. Format: dyn:Action:Payload
+	if strings.HasPrefix(data, "dyn:") {
+		parts := strings.SplitN(data, ":", 3)
+		if len(parts) < 2 {
+			return nil
+		}
+		item := repository.MenuItem{Action: parts[1]}
+		if len(parts) > 2 {
+			item.Payload = parts[2]
+		}
+		_, err := s.dispatchMenuAction(msg, item)
+		return err
+	}
+
+	if strings.HasPrefix(data, "action_") {
+		msg.MenuAction = data
+		switch strings.TrimPrefix(data, "action_") {
+		case "menu":
+			return s.sendMainMenu(msg)
+		case "calculate":
+			return s.restartCalculation(msg)
+		case "ask":
+			return s.sendReply(msg, "❓ Type your question about our products:")
+		case "search":
+			return s.sendReply(msg, "🔍 Ketik *CARI [nama]* untuk mencari produk.")
+		case "search_next":
+			if handled, err := s.continueSearch(msg); handled {
+				return err
+			}
+			return s.sendReply(msg, "🔍 Tidak ada pencarian aktif. Ketik *CARI [nama]* untuk mencari produk.")
+		case "cart_add":
+			return s.handleCartAdd(msg, "")
+		case "cart_clear":
+			return s.handleCartClear(msg)
+		case "order_confirm":
+			return s.handleOrderConfirm(msg)
+		case "quote_pdf":
+			return s.handleQuotePDF(msg)
+		}
+		return nil
+	}
+
+	// Legacy category/type buttons
+	var filterDesc string
+	if strings.HasPrefix(data, "cat_") {
+		filterDesc = strings.TrimPrefix(data, "cat_")
+	} else if strings.HasPrefix(data, "type_") {
+		filterDesc = strings.TrimPrefix(data, "type_")
+	}
+	if filterDesc == "" {
+		filterDesc = "products"
+	}
+	msg.Content = "Show me " + filterDesc + " with pricing and details."
+	return s.ProcessMessageWithContext(msg)
+}
+
+// sendWelcome greets the user and shows the main menu buttons when configured
+func (s *MessageService) sendWelcome(msg entities.Message, schema string) error {
+	welcome := s.getWelcomeMessage(schema)
+	if _, _, ok := s.loadMenu(schema, rootMenuSlug); ok {
+		return s.showMenuWithHeader(msg, rootMenuSlug, nil, welcome)
+	}
+	return s.sendReply(msg, welcome)
+}
+
+// sendMainMenu shows the tenant's main_menu, falling back to the category buttons
+func (s *MessageService) sendMainMenu(msg entities.Message) error {
+	return s.navigateHome(msg)
+}
+
+// loadMenu fetches a menu by slug and decodes its items
+func (s *MessageService) loadMenu(schema, slug string) ([]repository.MenuItem, string, bool) {
+	if s.ConfigRepo == nil {
+		return nil, "", false
+	}
+	if schema == "" {
+		schema = "public"
+	}
+	menu, err := s.ConfigRepo.GetMenu(schema, slug)
+	if err != nil {
+		return nil, "", false
+	}
+	var items []repository.MenuItem
+	if err := json.Unmarshal(menu.Items, &items); err != nil {
+		return nil, "", false
+	}
+	return items, menu.Title, true
+}
+
+// startCalculation asks the user for calculation input and remembers the dataset
+func (s *MessageService) startCalculation(msg entities.Message, tableName string) error {
+	if err := s.Calculator.BeginCalculation(conversationKeyFor(msg), tableName); err != nil {
+		fmt.Printf("Warning: could not start calculation: %v\n", err)
+		return s.sendReply(msg, "Fitur perhitungan tidak tersedia.")
+	}
+
+	return s.sendReply(msg, "📝 *Masukkan detail perhitungan:*\n\nFormat: `jumlah [satuan] nama_produk`\nContoh: `30 tumbler`, `1,5 kg beras`, `2 lusin gelas` atau `50 gelas 5kg`")
+}
+
+// restartCalculation starts a new calculation on the chat's last dataset (or the default one)
+func (s *MessageService) restartCalculation(msg entities.Message) error {
+	tableName, ok := s.Calculator.LastCalculationTable(conversationKeyFor(msg))
+	if !ok {
+		tableName = defaultCalcTable
+	}
+	return s.startCalculation(msg, tableName)
+}
+
+// sendCalculationResult replies with a calculation result and the follow-up options
+func (s *MessageService) sendCalculationResult(msg entities.Message, result string) error {
+	if msg.Platform == "whatsapp" {
+		return s.sendReply(msg, result+"\n\nReply with *1* to calculate again, *TAMBAH* to add it to your cart, *PDF* for a quotation, or *KONFIRMASI ORDER* to order.")
+	}
+	return s.sendReplyWithKeyboard(msg, result, infrastructure.CreateFollowUpMenu())
+}
@@ -0,0 +1,404 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"project_masAde/internal/entities"
+	"project_masAde/internal/infrastructure"
+	"project_masAde/internal/repository"
+	"regexp"
+	"strings"
+)
+
+// Errors returned by OrderService
+var (
+	ErrOrderNotFound      = errors.New("order not found")
+	ErrInvalidOrderStatus = errors.New("invalid order status")
+	ErrOrderStatusChange  = errors.New("order status cannot change")
+)
+
+// orderMenuAction tags order notifications in the transcript
+const orderMenuAction = "order"
+
+// orderTransitions lists, per status, the statuses an order may move to it from
+var orderTransitions = map[string][]string{
+	repository.OrderConfirmed: {repository.OrderNew},
+	repository.OrderPaid:      {repository.OrderNew, repository.OrderConfirmed},
+	repository.OrderShipped:   {repository.OrderConfirmed, repository.OrderPaid},
+	repository.OrderCancelled: {repository.OrderNew, repository.OrderConfirmed, repository.OrderPaid},
+}
+
+// orderStatusLabels are the statuses as shown to customers
+var orderStatusLabels = map[string]string{
+	repository.OrderNew:       "Baru",
+	repository.OrderConfirmed: "Dikonfirmasi",
+	repository.OrderPaid:      "Dibayar",
+	repository.OrderShipped:   "Dikirim",
+	repository.OrderCancelled: "Dibatalkan",
+}
+
+// orderStatusNotices are sent to the customer when staff change the status
+var orderStatusNotices = map[string]string{
+	repository.OrderConfirmed: "✅ Pesanan *%s* sudah dikonfirmasi.",
+	repository.OrderPaid:      "💳 Pembayaran pesanan *%s* sudah kami terima. Terima kasih!",
+	repository.OrderShipped:   "🚚 Pesanan *%s* sudah dikirim.",
+	repository.OrderCancelled: "❌ Pesanan *%s* dibatalkan.",
+}
+
+// OrderService turns confirmed quotes into orders and manages their
+// lifecycle. Customers are notified through ChannelRouter, on the chat the
+// order was placed from.
+type OrderService struct {
+	repo     *repository.OrderRepository
+	contacts *repository.ContactRepository // Optional: contact names on orders
+	router   *ChannelRouter
+}
+
+// NewOrderService creates the order service
+func NewOrderService(repo *repository.OrderRepository, contacts *repository.ContactRepository, router *ChannelRouter) *OrderService {
+	return &OrderService{repo: repo, contacts: contacts, router: router}
+}
+
+// Place stores a quote as a new order of the chat the message came from.
+// A quote of the cart passes the cart's lines, which the order consumes.
+func (s *OrderService) Place(msg entities.Message, quote *Quote, cart []repository.CartItem) (*repository.Order, error) {
+	key := conversationKeyFor(msg)
+	order := &repository.Order{
+		Platform:  key.Platform,
+		ChatID:    key.ChatID,
+		UserID:    msg.UserID,
+		Currency:  quote.Currency,
+		Subtotal:  quote.Subtotal,
+		Total:     quote.Total,
+		Status:    repository.OrderNew,
+		Discounts: make([]repository.OrderLine, 0, len(quote.Discounts)),
+		Taxes:     make([]repository.OrderLine, 0, len(quote.Taxes)),
+	}
+	for _, item := range quote.Items {
+		order.Items = append(order.Items, repository.OrderItem{
+			Product:   item.Product,
+			TableName: item.TableName,
+			Quantity:  item.Quantity,
+			Unit:      item.Unit,
+			ListPrice: item.ListPrice,
+			UnitPrice: item.UnitPrice,
+			Tier:      item.Tier,
+			Amount:    item.Amount,
+		})
+	}
+	for _, line := range quote.Discounts {
+		order.Discounts = append(order.Discounts, repository.OrderLine(line))
+	}
+	for _, line := range quote.Taxes {
+		order.Taxes = append(order.Taxes, repository.OrderLine(line))
+	}
+	if s.contacts != nil {
+		if contact, err := s.contacts.Find(key.Schema, key.Platform, key.ChatID); err == nil && contact != nil {
+			order.ContactName = contact.DisplayName
+		}
+	}
+
+	var cartItemIDs []int
+	for _, item := range cart {
+		cartItemIDs = append(cartItemIDs, item.ID)
+	}
+	if err := s.repo.Create(key.Schema, order, cartItemIDs); err != nil {
+		return nil, err
+	}
+	s.publish(key.Schema, order)
+	return order, nil
+}
+
+// List returns a page of orders with the total matching count
+func (s *OrderService) List(schema string, f repository.OrderFilter) ([]repository.Order, int, error) {
+	if f.Status != "" && orderStatusLabels[f.Status] == "" {
+		return nil, 0, fmt.Errorf("%w: %s", ErrInvalidOrderStatus, f.Status)
+	}
+	f.Limit = clampLogPageSize(f.Limit)
+	f.Offset = max(f.Offset, 0)
+	orders, err := s.repo.List(schema, f)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := s.repo.Count(schema, f)
+	return orders, total, err
+}
+
+// Get returns one order with its status history
+func (s *OrderService) Get(schema string, id int) (*repository.Order, error) {
+	order, err := s.repo.Get(schema, id)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil {
+		return nil, ErrOrderNotFound
+	}
+	order.History, err = s.repo.History(schema, order.ID)
+	return order, err
+}
+
+// Lookup returns an order of a chat by number, with its status history.
+// Orders of other chats are reported as not found.
+func (s *OrderService) Lookup(key ConversationKey, number string) (*repository.Order, error) {
+	order, err := s.repo.GetByNumber(key.Schema, number)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil || order.Platform != key.Platform || order.ChatID != key.ChatID {
+		return nil, ErrOrderNotFound
+	}
+	order.History, err = s.repo.History(key.Schema, order.ID)
+	return order, err
+}
+
+// ForChat returns a chat's most recent orders
+func (s *OrderService) ForChat(key ConversationKey, limit int) ([]repository.Order, error) {
+	return s.repo.List(key.Schema, repository.OrderFilter{Platform: key.Platform, ChatID: key.ChatID, Limit: limit})
+}
+
+// UpdateStatus moves an order along its lifecycle and notifies the customer.
+// The note, if any, is stored and included in the notification.
+func (s *OrderService) UpdateStatus(schema string, id int, status, note string) (*repository.Order, error) {
+	from, ok := orderTransitions[status]
+	if !ok {
+		return nil, fmt.Errorf("%w: must be confirmed, paid, shipped or cancelled", ErrInvalidOrderStatus)
+	}
+	note = strings.TrimSpace(note)
+	changed, err := s.repo.SetStatus(schema, id, from, status, note)
+	if err != nil {
+		return nil, err
+	}
+	order, err := s.Get(schema, id)
+	if err != nil {
+		return nil, err
+	}
+	if !changed {
+		return nil, fmt.Errorf("%w: %s order cannot become %s", ErrOrderStatusChange, order.Status, status)
+	}
+
+	s.notify(schema, order)
+	s.publish(schema, order)
+	return order, nil
+}
+
+// notify tells the customer about the order's new status. Failures are
+// logged: the status change itself has already been saved.
+func (s *OrderService) notify(schema string, order *repository.Order) {
+	notice, ok := orderStatusNotices[order.Status]
+	if !ok || s.router == nil {
+		return
+	}
+	text := fmt.Sprintf(notice, order.Number)
+	if order.StatusNote != "" {
+		text += "\n\n📝 " + order.StatusNote
+	}
+	text += fmt.Sprintf("\n\nKetik *STATUS %s* untuk detail pesanan.", order.Number)
+	msg := entities.Message{
+		From:       order.ChatID,
+		Platform:   order.Platform,
+		SchemaName: schema,
+		UserID:     order.UserID,
+		MenuAction: orderMenuAction,
+	}
+	if err := s.router.Send(msg, text); err != nil {
+		fmt.Printf("Warning: failed to notify %s about order %s: %v\n", order.ChatID, order.Number, err)
+	}
+}
+
+// publish tells dashboards that an order was placed or changed
+func (s *OrderService) publish(schema string, order *repository.Order) {
+	if s.router == nil {
+		return
+	}
+	s.router.Events.Publish(infrastructure.Event{
+		Type:       infrastructure.EventOrderUpdated,
+		SchemaName: schema,
+		UserID:     order.UserID,
+		Data: map[string]any{
+			"order_id": order.ID,
+			"number":   order.Number,
+			"status":   order.Status,
+			"platform": order.Platform,
+			"chat_id":  order.ChatID,
+			"total":    order.Total,
+		},
+	})
+}
+
+// orderQuote rebuilds the quote an order was placed from, for display
+func orderQuote(order *repository.Order) *Quote {
+	quote := &Quote{
+		Currency:  order.Currency,
+		Subtotal:  order.Subtotal,
+		Total:     order.Total,
+		Discounts: make([]QuoteLine, 0, len(order.Discounts)),
+		Taxes:     make([]QuoteLine, 0, len(order.Taxes)),
+	}
+	for _, item := range order.Items {
+		quote.Items = append(quote.Items, QuoteItem{
+			Product:   item.Product,
+			TableName: item.TableName,
+			Quantity:  item.Quantity,
+			Unit:      item.Unit,
+			ListPrice: item.ListPrice,
+			UnitPrice: item.UnitPrice,
+			Tier:      item.Tier,
+			Amount:    item.Amount,
+		})
+	}
+	for _, line := range order.Discounts {
+		quote.Discounts = append(quote.Discounts, QuoteLine(line))
+	}
+	for _, line := range order.Taxes {
+		quote.Taxes = append(quote.Taxes, QuoteLine(line))
+	}
+	return quote
+}
+
+// currentQuote prices what the chat would order: its cart, or else its last
+// calculation. A nil quote without failure means there is nothing to price.
+func (s *MessageService) currentQuote(key ConversationKey) (quote *Quote, cart []repository.CartItem, failure string) {
+	if s.Cart != nil {
+		quote, cart, failure = s.Cart.Quote(key)
+		if len(cart) > 0 || failure != "" {
+			return quote, cart, failure
+		}
+	}
+	if s.Conversations != nil {
+		if state := s.Conversations.Get(key); state.State == StateCalcCompleted && state.Data["last_input"] != "" {
+			queries, parseFailure := s.Calculator.ParseItems(state.Data["last_input"])
+			if parseFailure != "" {
+				return nil, nil, "❌ " + parseFailure
+			}
+			quote, failure = s.Calculator.QuoteIn(key.Schema, state.Data["table"], state.Data["currency"], queries...)
+			return quote, nil, failure
+		}
+	}
+	return nil, nil, ""
+}
+
+// handleOrderConfirm turns the chat's cart, or else its last calculation,
+// into an order
+func (s *MessageService) handleOrderConfirm(msg entities.Message) error {
+	if s.Orders == nil {
+		return s.sendReply(msg, "Fitur pemesanan tidak tersedia.")
+	}
+	key := conversationKeyFor(msg)
+
+	quote, cart, failure := s.currentQuote(key)
+	if failure != "" {
+		return s.sendReply(msg, failure)
+	}
+	nothingToOrder := "🧾 Belum ada yang bisa dipesan.\nHitung harga dulu (ketik *MENU*) atau isi keranjang dengan *TAMBAH [jumlah] [produk]*."
+	if quote == nil {
+		return s.sendReply(msg, nothingToOrder)
+	}
+	// The same quote must not be ordered twice, also when the confirmation
+	// arrives twice at once: the order takes the cart lines in its own
+	// transaction, and only one confirmation can end the calculation
+	if len(cart) == 0 && !s.Conversations.Consume(key, StateCalcCompleted) {
+		return s.sendReply(msg, nothingToOrder)
+	}
+
+	order, err := s.Orders.Place(msg, quote, cart)
+	if errors.Is(err, repository.ErrCartChanged) {
+		return s.sendReply(msg, "🧾 Keranjang sudah dipesan atau berubah.\nKetik *KERANJANG* untuk melihat isinya.")
+	}
+	if err != nil {
+		fmt.Printf("Warning: failed to place order: %v\n", err)
+		return s.sendReply(msg, "❌ Gagal membuat pesanan. Silakan coba lagi.")
+	}
+
+	return s.sendReply(msg, formatQuoteTitled(fmt.Sprintf("🧾 *Pesanan %s diterima*", order.Number), quote)+
+		fmt.Sprintf("\n\nStatus: *%s*\nKami akan mengabari Anda di sini saat pesanan dikonfirmasi.\nKetik *STATUS %s* untuk mengecek pesanan.", orderStatusLabels[order.Status], order.Number))
+}
+
+// maxChatOrders is how many orders MY ORDERS lists
+const maxChatOrders = 5
+
+// orderNumberPattern recognizes an order number typed on its own
+var orderNumberPattern = regexp.MustCompile(`^#?ord-\d{6}-\d+$`)
+
+// handleOrderCommand answers order lookups: STATUS [number] (or just the
+// number), INVOICE [number] and MY ORDERS. Customers only ever see the orders
+// of their own chat.
+func (s *MessageService) handleOrderCommand(msg entities.Message, content string) (bool, error) {
+	var number string
+	invoice := false
+	switch {
+	case content == "my orders" || content == "pesanan saya" || content == "status" || content == "status pesanan":
+	case orderNumberPattern.MatchString(content):
+		number = content
+	default:
+		arg, ok := "", false
+		for _, prefix := range []string{"status pesanan ", "cek pesanan ", "status ", "invoice ", "faktur "} {
+			if strings.HasPrefix(content, prefix) {
+				arg, ok = strings.TrimSpace(content[len(prefix):]), true
+				invoice = prefix == "invoice " || prefix == "faktur "
+				break
+			}
+		}
+		if !ok || !orderNumberPattern.MatchString(arg) {
+			return false, nil
+		}
+		number = arg
+	}
+	if s.Orders == nil {
+		return false, nil
+	}
+	if number == "" {
+		return true, s.sendMyOrders(msg)
+	}
+	number = strings.ToUpper(strings.TrimPrefix(number, "#"))
+	if invoice {
+		return true, s.sendInvoice(msg, number)
+	}
+	return true, s.sendOrderStatus(msg, number)
+}
+
+// sendOrderStatus replies with an order's status, items and history
+func (s *MessageService) sendOrderStatus(msg entities.Message, number string) error {
+	order, err := s.Orders.Lookup(conversationKeyFor(msg), number)
+	if errors.Is(err, ErrOrderNotFound) {
+		return s.sendReply(msg, fmt.Sprintf("❌ Pesanan *%s* tidak ditemukan.\nKetik *PESANAN SAYA* untuk melihat pesanan Anda.", number))
+	}
+	if err != nil {
+		fmt.Printf("Warning: failed to look up order %s: %v\n", number, err)
+		return s.sendReply(msg, "❌ Gagal mengambil data pesanan. Silakan coba lagi.")
+	}
+
+	var sb strings.Builder
+	sb.WriteString(formatQuoteTitled(fmt.Sprintf("🧾 *Pesanan %s*\nStatus: *%s*", order.Number, orderStatusLabels[order.Status]), orderQuote(order)))
+	sb.WriteString("\n\n📜 *Riwayat:*")
+	if len(order.History) == 0 {
+		sb.WriteString(fmt.Sprintf("\n• %s — %s", order.CreatedAt.Format("02/01/2006 15:04"), orderStatusLabels[repository.OrderNew]))
+	}
+	for _, change := range order.History {
+		sb.WriteString(fmt.Sprintf("\n• %s — %s", change.CreatedAt.Format("02/01/2006 15:04"), orderStatusLabels[change.Status]))
+		if change.Note != "" {
+			sb.WriteString(" (" + change.Note + ")")
+		}
+	}
+	return s.sendReply(msg, sb.String())
+}
+
+// sendMyOrders lists the chat's most recent orders
+func (s *MessageService) sendMyOrders(msg entities.Message) error {
+	orders, err := s.Orders.ForChat(conversationKeyFor(msg), maxChatOrders)
+	if err != nil {
+		fmt.Printf("Warning: failed to list orders: %v\n", err)
+		return s.sendReply(msg, "❌ Gagal mengambil data pesanan. Silakan coba lagi.")
+	}
+	if len(orders) == 0 {
+		return s.sendReply(msg, "🧾 Anda belum memiliki pesanan.\nKetik *KONFIRMASI ORDER* setelah menghitung harga untuk memesan.")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("🧾 *Pesanan Anda*\n")
+	for i, order := range orders {
+		sb.WriteString(fmt.Sprintf("\n%d. *%s* — %s\n   %s · %s", i+1, order.Number, orderStatusLabels[order.Status],
+			formatAmount(order.Total, order.Currency), order.CreatedAt.Format("02/01/2006")))
+	}
+	sb.WriteString("\n\nKetik *STATUS [nomor pesanan]* untuk detail atau *INVOICE [nomor pesanan]* untuk invoice PDF.")
+	return s.sendReply(msg, sb.String())
+}
@@ -115,7 +115,7 @@ func (pc *PricingCalculator) CalculatePrice(query QueryParse) string {
 📂 Category: %s | Type: %s
 📊 Quantity: %d units | Weight: %dg
 💰 Calculation: %s
-🏷️ **Total: %.2f %s**
+🏷️ **Total: %s**
 	`,
 		product.Name,
 		product.Category,
@@ -123,8 +123,7 @@ func (pc *PricingCalculator) CalculatePrice(query QueryParse) string {
 		query.Quantity,
 		query.WeightGrams,
 		calculation,
-		total,
-		product.Currency,
+		formatAmount(total, product.Currency),
 	)
 	
 	return result
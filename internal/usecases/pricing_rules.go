@@ -0,0 +1,330 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"project_masAde/internal/repository"
+	"strings"
+)
+
+// Errors returned by PricingRuleService and quoting
+var (
+	ErrInvalidPricingRule  = errors.New("invalid pricing rule")
+	ErrPricingRuleNotFound = errors.New("pricing rule not found")
+	ErrBelowMinOrder       = errors.New("below minimum order quantity")
+)
+
+// Common pricing bases: what a dataset row's price is per. Any unit of
+// quantityUnits can be a basis ("ton", "lusin", "cm").
+const (
+	PerUnit  = "pcs"
+	PerKg    = "kg"
+	PerMeter = "m"
+	PerBox   = "box"
+)
+
+// pricingBasis returns the canonical unit a price is given per (pcs when unknown)
+func pricingBasis(unit string) string {
+	unit = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(unit), "/")))
+	if u, ok := quantityUnits[unit]; ok {
+		return u.name
+	}
+	return PerUnit
+}
+
+// QuoteItem is one priced line of a quote
+type QuoteItem struct {
+	Product   string  `json:"product"`
+	TableName string  `json:"table_name"`
+	Quantity  float64 `json:"quantity"` // In Unit
+	Unit      string  `json:"unit"`     // Pricing basis: pcs, kg, m, lusin, ...
+	ListPrice float64 `json:"list_price"`
+	UnitPrice float64 `json:"unit_price"` // After volume tiers
+	Tier      string  `json:"tier,omitempty"`
+	MinOrder  float64 `json:"min_order,omitempty"`
+	Amount    float64 `json:"amount"` // Quantity × UnitPrice
+	Rate      float64 `json:"-"`      // Converts the dataset's currency to the quote's; 0 = the same currency
+}
+
+// currencyRate is what an amount in the item's dataset currency is multiplied
+// by to be in the quote's currency. Fixed rule values are in the currency of
+// the items they price, so they are converted with it too.
+func (item QuoteItem) currencyRate() float64 {
+	if item.Rate == 0 {
+		return 1
+	}
+	return item.Rate
+}
+
+// QuoteLine is a discount (negative) or tax line below the items
+type QuoteLine struct {
+	Label  string  `json:"label"`
+	Amount float64 `json:"amount"`
+}
+
+// Quote is an itemized price calculation
+type Quote struct {
+	Items     []QuoteItem      `json:"items"`
+	Currency  string           `json:"currency"`
+	Subtotal  float64          `json:"subtotal"`
+	Discounts []QuoteLine      `json:"discounts"`
+	Taxes     []QuoteLine      `json:"taxes"`
+	Total     float64          `json:"total"`
+	Converted *QuoteConversion `json:"converted,omitempty"` // Totals in the display or requested currency
+}
+
+// PricingRuleService stores tenant pricing rules and applies them to quotes
+type PricingRuleService struct {
+	repo *repository.PricingRuleRepository
+}
+
+// NewPricingRuleService creates the pricing rule service
+func NewPricingRuleService(repo *repository.PricingRuleRepository) *PricingRuleService {
+	return &PricingRuleService{repo: repo}
+}
+
+// Rules returns the tenant's rules in evaluation order
+func (s *PricingRuleService) Rules(schema string) ([]repository.PricingRule, error) {
+	if s == nil || s.repo == nil {
+		return []repository.PricingRule{}, nil
+	}
+	return s.repo.List(schema, false)
+}
+
+// Get returns one of the tenant's rules
+func (s *PricingRuleService) Get(schema string, id int) (*repository.PricingRule, error) {
+	rule, err := s.repo.Get(schema, id)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		return nil, ErrPricingRuleNotFound
+	}
+	return rule, nil
+}
+
+// Create stores a new rule
+func (s *PricingRuleService) Create(schema string, rule *repository.PricingRule) error {
+	if err := validatePricingRule(rule); err != nil {
+		return err
+	}
+	return s.repo.Create(schema, rule)
+}
+
+// Update saves an existing rule
+func (s *PricingRuleService) Update(schema string, rule *repository.PricingRule) error {
+	if err := validatePricingRule(rule); err != nil {
+		return err
+	}
+	ok, err := s.repo.Update(schema, rule)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrPricingRuleNotFound
+	}
+	return nil
+}
+
+// Delete removes a rule
+func (s *PricingRuleService) Delete(schema string, id int) error {
+	ok, err := s.repo.Delete(schema, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrPricingRuleNotFound
+	}
+	return nil
+}
+
+func validatePricingRule(rule *repository.PricingRule) error {
+	rule.Name = strings.TrimSpace(rule.Name)
+	rule.Product = strings.TrimSpace(rule.Product)
+	rule.TableName = strings.TrimSpace(rule.TableName)
+	if rule.Name == "" || len(rule.Name) > 128 {
+		return fmt.Errorf("%w: name must be 1-128 characters", ErrInvalidPricingRule)
+	}
+	if len(rule.Product) > 256 || len(rule.TableName) > 128 {
+		return fmt.Errorf("%w: product or table name too long", ErrInvalidPricingRule)
+	}
+	if rule.MinQty < 0 || rule.MaxQty < 0 || (rule.MaxQty > 0 && rule.MaxQty < rule.MinQty) {
+		return fmt.Errorf("%w: quantity range", ErrInvalidPricingRule)
+	}
+	if rule.Value < 0 {
+		return fmt.Errorf("%w: value must not be negative", ErrInvalidPricingRule)
+	}
+
+	switch rule.Kind {
+	case repository.PricingTier, repository.PricingDiscount, repository.PricingTax:
+	case repository.PricingMinOrder:
+		rule.ValueType = repository.PricingFixed
+	default:
+		return fmt.Errorf("%w: kind must be tier, discount, moq or tax", ErrInvalidPricingRule)
+	}
+	switch rule.ValueType {
+	case repository.PricingPercent:
+		if rule.Value > 100 {
+			return fmt.Errorf("%w: percent above 100", ErrInvalidPricingRule)
+		}
+	case repository.PricingFixed:
+	default:
+		return fmt.Errorf("%w: value_type must be percent or fixed", ErrInvalidPricingRule)
+	}
+	return nil
+}
+
+// ruleApplies reports whether a rule covers a quote item
+func ruleApplies(rule repository.PricingRule, item QuoteItem) bool {
+	if !rule.Enabled {
+		return false
+	}
+	if rule.TableName != "" && rule.TableName != item.TableName {
+		return false
+	}
+	if rule.Product != "" && !strings.Contains(strings.ToLower(item.Product), strings.ToLower(rule.Product)) {
+		return false
+	}
+	return item.Quantity >= rule.MinQty && (rule.MaxQty == 0 || item.Quantity <= rule.MaxQty)
+}
+
+// BuildQuote prices the items with the rules: the volume tier with the
+// highest min_qty sets each unit price, minimum order quantities are enforced,
+// percentage discounts come off each item, fixed ones once off the items they
+// cover, and taxes are added on the discounted amounts of the items they cover.
+// Items arrive with Product, TableName, Quantity, Unit, ListPrice and MinOrder
+// (from the dataset) set, and Rate if the list price was converted. A
+// quantity below the minimum order returns the quote with ErrBelowMinOrder.
+func BuildQuote(rules []repository.PricingRule, items []QuoteItem, currency string) (*Quote, error) {
+	quote := &Quote{Currency: currency, Discounts: []QuoteLine{}, Taxes: []QuoteLine{}}
+	for _, item := range items {
+		item.UnitPrice = item.ListPrice
+		var tier *repository.PricingRule
+		moqFromRule := false
+		for i, rule := range rules {
+			switch rule.Kind {
+			case repository.PricingTier:
+				if ruleApplies(rule, item) && (tier == nil || rule.MinQty > tier.MinQty) {
+					tier = &rules[i]
+				}
+			case repository.PricingMinOrder:
+				// The rule with the highest priority overrides the dataset's minimum
+				if !moqFromRule && ruleMatchesItem(rule, item) {
+					item.MinOrder, moqFromRule = rule.Value, true
+				}
+			}
+		}
+		if tier != nil {
+			item.Tier = tier.Name
+			if tier.ValueType == repository.PricingFixed {
+				item.UnitPrice = tier.Value * item.currencyRate()
+			} else {
+				item.UnitPrice = item.ListPrice * (1 - tier.Value/100)
+			}
+		}
+		item.Amount = roundMoney(item.Quantity * item.UnitPrice)
+		quote.Items = append(quote.Items, item)
+		quote.Subtotal += item.Amount
+	}
+
+	for _, item := range quote.Items {
+		if item.MinOrder > 0 && item.Quantity < item.MinOrder {
+			return quote, fmt.Errorf("%w: %s needs at least %g %s", ErrBelowMinOrder, item.Product, item.MinOrder, item.Unit)
+		}
+	}
+
+	// net is each item's amount after discounts, the base of percentage taxes
+	net := make([]float64, len(quote.Items))
+	for i, item := range quote.Items {
+		net[i] = item.Amount
+	}
+	discounted := quote.Subtotal
+	for _, rule := range rules {
+		if rule.Kind != repository.PricingDiscount {
+			continue
+		}
+		if rule.ValueType == repository.PricingFixed {
+			// A fixed amount comes off the order once, shared by the items it
+			// covers, in the currency of the first of them
+			matched, rate := 0.0, 0.0
+			for i, item := range quote.Items {
+				if ruleApplies(rule, item) {
+					matched += net[i]
+					if rate == 0 {
+						rate = item.currencyRate()
+					}
+				}
+			}
+			if matched <= 0 {
+				continue
+			}
+			off := roundMoney(math.Min(rule.Value*rate, matched))
+			for i, item := range quote.Items {
+				if ruleApplies(rule, item) {
+					net[i] -= off * net[i] / matched
+				}
+			}
+			quote.Discounts = append(quote.Discounts, QuoteLine{Label: rule.Name, Amount: -off})
+			discounted -= off
+			continue
+		}
+		for i, item := range quote.Items {
+			if !ruleApplies(rule, item) {
+				continue
+			}
+			off := roundMoney(math.Min(item.Amount*rule.Value/100, net[i]))
+			label := fmt.Sprintf("%s %g%%", rule.Name, rule.Value)
+			if len(quote.Items) > 1 {
+				label += " (" + item.Product + ")"
+			}
+			quote.Discounts = append(quote.Discounts, QuoteLine{Label: label, Amount: -off})
+			net[i] -= off
+			discounted -= off
+		}
+	}
+
+	quote.Total = discounted
+	for _, rule := range rules {
+		if rule.Kind != repository.PricingTax || !rule.Enabled {
+			continue
+		}
+		// Percentages are of the discounted amounts of the items the tax covers
+		taxable, rate := 0.0, 0.0
+		for i, item := range quote.Items {
+			if ruleMatchesItem(rule, item) {
+				taxable += net[i]
+				if rate == 0 {
+					rate = item.currencyRate()
+				}
+			}
+		}
+		if rate == 0 {
+			continue // Covers no item
+		}
+		tax := rule.Value * rate
+		label := rule.Name
+		if rule.ValueType == repository.PricingPercent {
+			tax = taxable * rule.Value / 100
+			label = fmt.Sprintf("%s %g%%", rule.Name, rule.Value)
+		}
+		tax = roundMoney(tax)
+		quote.Taxes = append(quote.Taxes, QuoteLine{Label: label, Amount: tax})
+		quote.Total += tax
+	}
+	quote.Subtotal = roundMoney(quote.Subtotal)
+	quote.Total = roundMoney(quote.Total)
+	return quote, nil
+}
+
+// ruleMatchesItem is ruleApplies without the quantity range, for minimum
+// order rules (which are about quantities below them)
+func ruleMatchesItem(rule repository.PricingRule, item QuoteItem) bool {
+	rule.MinQty, rule.MaxQty = 0, 0
+	return ruleApplies(rule, item)
+}
+
+// roundMoney rounds to cents
+func roundMoney(v float64) float64 {
+	return math.Round(v*100) / 100
+}
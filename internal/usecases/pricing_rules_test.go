@@ -0,0 +1,139 @@
+package usecases
+
+import (
+	"errors"
+	"project_masAde/internal/repository"
+	"slices"
+	"testing"
+)
+
+func TestBuildQuote(t *testing.T) {
+	rule := func(kind, valueType string, value float64, set func(*repository.PricingRule)) repository.PricingRule {
+		r := repository.PricingRule{Name: kind, Kind: kind, ValueType: valueType, Value: value, Enabled: true}
+		if set != nil {
+			set(&r)
+		}
+		return r
+	}
+	item := func(product, table string, qty, price float64) QuoteItem {
+		return QuoteItem{Product: product, TableName: table, Quantity: qty, ListPrice: price}
+	}
+
+	tests := []struct {
+		name      string
+		rules     []repository.PricingRule
+		items     []QuoteItem
+		subtotal  float64
+		discounts []float64
+		taxes     []float64
+		total     float64
+		err       error
+	}{
+		{
+			name:     "list prices",
+			items:    []QuoteItem{item("tumbler", "a", 3, 10000)},
+			subtotal: 30000, discounts: []float64{}, taxes: []float64{}, total: 30000,
+		},
+		{
+			name: "fixed discount once, shared by the items",
+			rules: []repository.PricingRule{
+				rule(repository.PricingDiscount, repository.PricingFixed, 50000, nil),
+			},
+			items:    []QuoteItem{item("x", "a", 1, 100000), item("y", "a", 1, 100000), item("z", "a", 1, 100000)},
+			subtotal: 300000, discounts: []float64{-50000}, taxes: []float64{}, total: 250000,
+		},
+		{
+			name: "fixed discount capped at the covered amount",
+			rules: []repository.PricingRule{
+				rule(repository.PricingDiscount, repository.PricingFixed, 50000, func(r *repository.PricingRule) { r.Product = "x" }),
+			},
+			items:    []QuoteItem{item("x", "a", 1, 20000), item("y", "a", 1, 100000)},
+			subtotal: 120000, discounts: []float64{-20000}, taxes: []float64{}, total: 100000,
+		},
+		{
+			name: "percentage discounts stack on the list amount",
+			rules: []repository.PricingRule{
+				rule(repository.PricingDiscount, repository.PricingPercent, 10, nil),
+				rule(repository.PricingDiscount, repository.PricingPercent, 5, nil),
+			},
+			items:    []QuoteItem{item("x", "a", 1, 100000)},
+			subtotal: 100000, discounts: []float64{-10000, -5000}, taxes: []float64{}, total: 85000,
+		},
+		{
+			name: "tax only on covered items, after their discounts",
+			rules: []repository.PricingRule{
+				rule(repository.PricingDiscount, repository.PricingFixed, 30000, nil),
+				rule(repository.PricingTax, repository.PricingPercent, 10, func(r *repository.PricingRule) { r.TableName = "a" }),
+			},
+			items:    []QuoteItem{item("x", "a", 1, 100000), item("y", "b", 2, 100000)},
+			subtotal: 300000, discounts: []float64{-30000}, taxes: []float64{9000}, total: 279000,
+		},
+		{
+			name: "tax covering no item",
+			rules: []repository.PricingRule{
+				rule(repository.PricingTax, repository.PricingPercent, 11, func(r *repository.PricingRule) { r.TableName = "c" }),
+			},
+			items:    []QuoteItem{item("x", "a", 1, 100000)},
+			subtotal: 100000, discounts: []float64{}, taxes: []float64{}, total: 100000,
+		},
+		{
+			name: "tier with the highest min_qty sets the unit price",
+			rules: []repository.PricingRule{
+				rule(repository.PricingTier, repository.PricingFixed, 9000, func(r *repository.PricingRule) { r.MinQty = 10 }),
+				rule(repository.PricingTier, repository.PricingPercent, 20, func(r *repository.PricingRule) { r.MinQty = 50 }),
+			},
+			items:    []QuoteItem{item("x", "a", 50, 10000)},
+			subtotal: 400000, discounts: []float64{}, taxes: []float64{}, total: 400000,
+		},
+		{
+			name: "moq rule overrides the dataset minimum",
+			rules: []repository.PricingRule{
+				rule(repository.PricingMinOrder, repository.PricingFixed, 5, nil),
+			},
+			items:    []QuoteItem{{Product: "x", TableName: "a", Quantity: 6, ListPrice: 1000, MinOrder: 10}},
+			subtotal: 6000, discounts: []float64{}, taxes: []float64{}, total: 6000,
+		},
+		{
+			name: "first moq rule wins",
+			rules: []repository.PricingRule{
+				rule(repository.PricingMinOrder, repository.PricingFixed, 10, nil),
+				rule(repository.PricingMinOrder, repository.PricingFixed, 2, nil),
+			},
+			items: []QuoteItem{item("x", "a", 6, 1000)},
+			err:   ErrBelowMinOrder,
+		},
+		{
+			name: "fixed values converted like the list price",
+			rules: []repository.PricingRule{
+				rule(repository.PricingTier, repository.PricingFixed, 8, func(r *repository.PricingRule) { r.TableName = "eur" }),
+				rule(repository.PricingDiscount, repository.PricingFixed, 2, func(r *repository.PricingRule) { r.TableName = "eur" }),
+			},
+			items:    []QuoteItem{{Product: "x", TableName: "eur", Quantity: 1, ListPrice: 10 * 17000, Rate: 17000}},
+			subtotal: 136000, discounts: []float64{-34000}, taxes: []float64{}, total: 102000,
+		},
+	}
+
+	amounts := func(lines []QuoteLine) []float64 {
+		result := []float64{}
+		for _, l := range lines {
+			result = append(result, l.Amount)
+		}
+		return result
+	}
+	for _, tt := range tests {
+		quote, err := BuildQuote(tt.rules, tt.items, "IDR")
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: error %v; want %v", tt.name, err, tt.err)
+			continue
+		}
+		if tt.err != nil {
+			continue
+		}
+		if quote.Subtotal != tt.subtotal || quote.Total != tt.total ||
+			!slices.Equal(amounts(quote.Discounts), tt.discounts) || !slices.Equal(amounts(quote.Taxes), tt.taxes) {
+			t.Errorf("%s: subtotal %v discounts %v taxes %v total %v; want %v %v %v %v", tt.name,
+				quote.Subtotal, amounts(quote.Discounts), amounts(quote.Taxes), quote.Total,
+				tt.subtotal, tt.discounts, tt.taxes, tt.total)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package usecases
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Unit dimensions: quantities only convert between units of one dimension
+const (
+	dimCount  = "count"
+	dimMass   = "mass"
+	dimLength = "length"
+	dimBox    = "box" // What a box holds differs per product, so boxes only convert to boxes
+)
+
+// quantityUnit is a unit quantities are typed in and prices are given per
+type quantityUnit struct {
+	name      string // Canonical name shown on quotes
+	dimension string
+	size      float64 // In the dimension's base unit: pcs, kg, m or box
+}
+
+var (
+	unitPcs   = quantityUnit{PerUnit, dimCount, 1}
+	unitLusin = quantityUnit{"lusin", dimCount, 12}
+	unitKodi  = quantityUnit{"kodi", dimCount, 20}
+	unitTon   = quantityUnit{"ton", dimMass, 1000}
+	unitKg    = quantityUnit{PerKg, dimMass, 1}
+	unitOns   = quantityUnit{"ons", dimMass, 0.1}
+	unitGram  = quantityUnit{"g", dimMass, 0.001}
+	unitLb    = quantityUnit{"lb", dimMass, 0.45359237}
+	unitMeter = quantityUnit{PerMeter, dimLength, 1}
+	unitCm    = quantityUnit{"cm", dimLength, 0.01}
+	unitMm    = quantityUnit{"mm", dimLength, 0.001}
+	unitBox   = quantityUnit{PerBox, dimBox, 1}
+)
+
+// quantityUnits maps what customers and datasets write to units
+var quantityUnits = map[string]quantityUnit{
+	"pcs": unitPcs, "pc": unitPcs, "piece": unitPcs, "pieces": unitPcs, "buah": unitPcs, "bh": unitPcs, "biji": unitPcs, "unit": unitPcs, "units": unitPcs,
+	"lusin": unitLusin, "dozen": unitLusin, "dz": unitLusin, "kodi": unitKodi,
+	"ton": unitTon, "tons": unitTon,
+	"kg": unitKg, "kgs": unitKg, "kilo": unitKg, "kilogram": unitKg, "kilograms": unitKg,
+	"g": unitGram, "gr": unitGram, "gram": unitGram, "grams": unitGram, "ons": unitOns,
+	"lb": unitLb, "lbs": unitLb, "pound": unitLb, "pounds": unitLb,
+	"m": unitMeter, "meter": unitMeter, "meters": unitMeter, "metre": unitMeter, "mtr": unitMeter,
+	"cm": unitCm, "mm": unitMm,
+	"box": unitBox, "boxes": unitBox, "boks": unitBox, "dus": unitBox, "kotak": unitBox, "karton": unitBox,
+}
+
+// baseUnits are the units each dimension is billed in when the dataset says nothing
+var baseUnits = map[string]quantityUnit{dimCount: unitPcs, dimMass: unitKg, dimLength: unitMeter, dimBox: unitBox}
+
+// convertQuantity converts a quantity between two units of one dimension
+func convertQuantity(qty float64, from, to string) (float64, bool) {
+	f, okFrom := quantityUnits[from]
+	t, okTo := quantityUnits[to]
+	if !okFrom || !okTo || f.dimension != t.dimension {
+		return 0, false
+	}
+	// Round away float noise: 1.5 kg is 1500 g, not 1500.0000000000002
+	return math.Round(qty*f.size/t.size*1e6) / 1e6, true
+}
+
+// maxQuantity rejects typos like "100000000000 tumbler"
+const maxQuantity = 1e9
+
+var (
+	// A quantity and the rest: an optional unit (may be attached: "1,5kg") and the product
+	quantityPattern = regexp.MustCompile(`^(\d[\d.,]*)\s*(.*)$`)
+	// A trailing weight: "30 tumbler 30kg"
+	trailingWeightPattern = regexp.MustCompile(`^(.+?)\s+(\d[\d.,]*)\s*([a-z]+)$`)
+)
+
+// parseQuantityNumber reads a quantity in Indonesian or English notation:
+// "1,5" and "1.5" are one and a half, "1.000", "1,000" and "1.000,5" are
+// thousands. A single separator followed by exactly three digits is a
+// thousands separator unless the number starts with 0 ("0,250").
+func parseQuantityNumber(s string) (float64, bool) {
+	dot, comma := strings.LastIndex(s, "."), strings.LastIndex(s, ",")
+	switch {
+	case dot >= 0 && comma >= 0:
+		thousands, decimal := ".", ","
+		if dot > comma {
+			thousands, decimal = ",", "."
+		}
+		if strings.Count(s, decimal) > 1 {
+			return 0, false
+		}
+		s = strings.Replace(strings.ReplaceAll(s, thousands, ""), decimal, ".", 1)
+	case dot >= 0 || comma >= 0:
+		sep := "."
+		if comma >= 0 {
+			sep = ","
+		}
+		parts := strings.Split(s, sep)
+		thousands := len(parts) > 2 || (len(parts[1]) == 3 && parts[0] != "0")
+		if thousands {
+			for _, group := range parts[1:] {
+				if len(group) != 3 {
+					return 0, false
+				}
+			}
+			s = strings.Join(parts, "")
+		} else {
+			s = parts[0] + "." + parts[1]
+		}
+	}
+	if strings.HasSuffix(s, ".") {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	return v, err == nil
+}
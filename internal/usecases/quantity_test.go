@@ -0,0 +1,32 @@
+package usecases
+
+import "testing"
+
+func TestParseQuantityNumber(t *testing.T) {
+	tests := []struct {
+		in   string
+		want float64
+		ok   bool
+	}{
+		{"30", 30, true},
+		{"1,5", 1.5, true},
+		{"1.5", 1.5, true},
+		{"1.000", 1000, true},
+		{"1,000", 1000, true},
+		{"1.000.000", 1000000, true},
+		{"1.000,5", 1000.5, true},
+		{"1,000.5", 1000.5, true},
+		{"0,250", 0.25, true},
+		{"12,50", 12.5, true},
+		{"1.00.0", 0, false},
+		{"1,5,5", 0, false},
+		{"1.000,5,5", 0, false},
+		{"5.", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseQuantityNumber(tt.in)
+		if ok != tt.ok || (ok && got != tt.want) {
+			t.Errorf("parseQuantityNumber(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -21,10 +21,12 @@ export default function DataManagerPage() {
     const [loading, setLoading] = useState(true);
     const [tablesLoading, setTablesLoading] = useState(false);
     const [uploading, setUploading] = useState(false);
+    const [importProgress, setImportProgress] = useState('');
     
     // Selection State
     const [selectedTable, setSelectedTable] = useState<DynamicTable | null>(null);
     const [tableData, setTableData] = useState<any[]>([]);
+    const [tableTotal, setTableTotal] = useState(0);
     const [dataLoading, setDataLoading] = useState(false);
 
     // Upload State
@@ -54,8 +56,9 @@ export default function DataManagerPage() {
         setSelectedTable(table);
         setEditingRowId(null);
         try {
-            const { data } = await api.get(`/tables/${table.table_name}/data`);
-            setTableData(data || []);
+            const { data } = await api.get(`/tables/${table.table_name}/data`, { params: { limit: 1000 } });
+            setTableData(data?.rows || []);
+            setTableTotal(data?.total || 0);
         } catch (error) {
             console.error('Failed to fetch table data', error);
         } finally {
@@ -73,9 +76,19 @@ export default function DataManagerPage() {
         formData.append('file', file);
 
         try {
-            await api.post('/tables/import', formData, {
+            const { data } = await api.post('/tables/import', formData, {
                 headers: { 'Content-Type': 'multipart/form-data' }
             });
+            // Imports run in the background; poll until the job finishes
+            let job = data.job;
+            while (job.status === 'queued' || job.status === 'running') {
+                await new Promise((resolve) => setTimeout(resolve, 1000));
+                ({ data: job } = await api.get(`/tables/import/jobs/${job.id}`));
+                setImportProgress(job.rows_total ? `${job.rows_done}/${job.rows_total}` : '');
+            }
+            if (job.status === 'failed') {
+                throw new Error(job.error);
+            }
             fetchTables();
             setDisplayName('');
             setFile(null);
@@ -85,6 +98,7 @@ export default function DataManagerPage() {
             console.error(error);
         } finally {
             setUploading(false);
+            setImportProgress('');
         }
     };
 
@@ -187,7 +201,7 @@ export default function DataManagerPage() {
                                 />
                             </div>
                             <Button type="submit" disabled={uploading} className="w-full">
-                                {uploading ? 'Uploading...' : 'Start Import'}
+                                {uploading ? `Importing... ${importProgress}` : 'Start Import'}
                             </Button>
                         </form>
                     </DialogContent>
@@ -251,7 +265,7 @@ export default function DataManagerPage() {
                         </CardTitle>
                         {selectedTable && (
                             <div className="flex items-center gap-3">
-                                <span className="text-sm text-gray-500">{tableData.length} Rows</span>
+                                <span className="text-sm text-gray-500">{tableTotal} Rows</span>
                                 <Button variant="destructive" size="sm" onClick={handleDeleteTable}>
                                     <Trash2 className="h-4 w-4 mr-1" /> Delete Table
                                 </Button>